@@ -6,10 +6,15 @@
 //   |-> TEST_NAME.log
 //   |-> summary.log
 //   |-> report.xml
+//   |-> report.html (with --format html)
+//   |-> report.md (with --format markdown)
 // where:
 // - `TEST_NAME.log` is a log for each test run that only includes the relevant logs for that test.
 // - `summary.log` is a summary of all the tests in the suite, including PASS/FAIL information.
 // - `report.xml` is the test summary in junit XML format to be consumed by a CI engine.
+// - `report.html` and `report.md` list the tests with their status, duration, the retries of the retry module, an
+//   excerpt of the log of failed tests, and links to the logs of the tests. `report.md` can be appended to
+//   `$GITHUB_STEP_SUMMARY`.
 //
 // Certain tradeoffs were made in the decision to implement this functionality as a separate parsing command, as opposed
 // to being built into the logger module as part of `Logf`. Specifically, this implementation avoids the difficulties of
@@ -31,6 +36,19 @@
 //   at certain points in code, the information of which is lost in the final log and have to parse out.
 // - Have to store all the logs twice (the full interleaved version, and the broken out version) because the parsing
 //   depends on logs being available. (NOTE: this is avoidable with a pipe).
+//
+// With `--follow`, the test output is parsed while the tests run: it is copied from stdin to stdout as it is read, the
+// logs of each test are written as their output comes in, and `summary.log` is rewritten periodically with the tests
+// that are still running, how long they have been running, and which of them have produced no output for a while
+// (e.g., because they are stuck):
+//
+//   go test -timeout 2h -json ./... | terratest_log_parser --follow --input-format json
+//
+// To make the parsing robust, tests can log with `logger.JSON`, whose entries carry the test name in a dedicated field
+// and are parsed natively, without relying on the format of the plain log lines. Alternatively, run the tests with
+// `go test -json` and pass `--input-format json`: the test events name the test (and subtest) that wrote each line of
+// output, as well as the precise duration of each test, so the output of parallel subtests is attributed correctly and
+// the junit report includes skipped tests and subtests.
 
 package main
 
@@ -49,7 +67,7 @@ import (
 
 var logger = logging.GetLogger("terratest_log_parser")
 
-const CUSTOM_USAGE_TEXT = `Usage: terratest_log_parser [--help] [--log-level=info] [--testlog=LOG_INPUT] [--outputdir=OUTPUT_DIR]
+const CUSTOM_USAGE_TEXT = `Usage: terratest_log_parser [--help] [--log-level=info] [--testlog=LOG_INPUT] [--outputdir=OUTPUT_DIR] [--input-format=text] [--format=html,markdown] [--follow]
 
 A tool for parsing parallel terratest output to produce a test summary and to break out the interleaved logs by test for better debuggability.
 
@@ -58,6 +76,20 @@ Options:
                       (default: "info")
    --testlog value    Path to file containing test log. If unset will use stdin.
    --outputdir value  Path to directory to output test output to. If unset will use the current directory.
+   --input-format FORMAT
+                      The format of the test log. Must be one of: text (the output of go test -v) or json (the output
+                      of go test -json). (default: "text")
+   --format FORMATS   Comma-separated list of reports to create in addition to report.xml. Must be html and/or
+                      markdown.
+   --failure-excerpt-lines value
+                      The number of lines of the log of each failed test to show in the reports. (default: 20)
+   --follow           Copy the test log to stdout as it is read, and periodically rewrite summary.log with the tests
+                      that are still running. Use this to follow the tests while they run.
+   --summary-interval value
+                      How often to rewrite summary.log with --follow. (default: 10s)
+   --silence-threshold value
+                      Flag running tests that have produced no output for this long in summary.log with --follow.
+                      (default: 5m0s)
    --help, -h         show help
 `
 
@@ -70,6 +102,19 @@ func run(cliContext *cli.Context) error {
 		return errors.WithStackTrace(err)
 	}
 	logger.SetLevel(level)
+	if cliContext.Bool("follow") {
+		// The test log is copied to stdout, so keep the messages of the parser out of it.
+		logger.SetOutput(os.Stderr)
+	}
+
+	inputFormat, err := parser.ParseInputFormat(cliContext.String("input-format"))
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	reportFormats, err := parser.ParseReportFormats(cliContext.String("format"))
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
 
 	var file *os.File
 	if filename != "" {
@@ -89,7 +134,15 @@ func run(cliContext *cli.Context) error {
 		logger.Fatalf("Error extracting absolute path of output directory: %s", err)
 	}
 
-	parser.SpawnParsers(logger, file, outputDir)
+	options := parser.Options{
+		InputFormat:         inputFormat,
+		ReportFormats:       reportFormats,
+		FailureExcerptLines: cliContext.Int("failure-excerpt-lines"),
+		Follow:              cliContext.Bool("follow"),
+		SummaryInterval:     cliContext.Duration("summary-interval"),
+		SilenceThreshold:    cliContext.Duration("silence-threshold"),
+	}
+	parser.SpawnParsersWithOptions(logger, file, outputDir, options)
 	return nil
 }
 
@@ -124,10 +177,45 @@ func main() {
 		Value: logrus.InfoLevel.String(),
 		Usage: fmt.Sprintf("Set the log level to `LEVEL`. Must be one of: %v", logrus.AllLevels),
 	}
+	inputFormatFlag := cli.StringFlag{
+		Name:  "input-format",
+		Value: string(parser.InputFormatText),
+		Usage: "The format of the test log. Must be one of: text (the output of go test -v) or json (the output of go test -json).",
+	}
+	reportFormatFlag := cli.StringFlag{
+		Name:  "format",
+		Value: "",
+		Usage: "Comma-separated list of reports to create in addition to report.xml. Must be html and/or markdown.",
+	}
+	failureExcerptLinesFlag := cli.IntFlag{
+		Name:  "failure-excerpt-lines",
+		Value: parser.DefaultFailureExcerptLines,
+		Usage: "The number of lines of the log of each failed test to show in the reports.",
+	}
+	followFlag := cli.BoolFlag{
+		Name:  "follow",
+		Usage: "Copy the test log to stdout as it is read, and periodically rewrite summary.log with the tests that are still running.",
+	}
+	summaryIntervalFlag := cli.DurationFlag{
+		Name:  "summary-interval",
+		Value: parser.DefaultSummaryInterval,
+		Usage: "How often to rewrite summary.log with --follow.",
+	}
+	silenceThresholdFlag := cli.DurationFlag{
+		Name:  "silence-threshold",
+		Value: parser.DefaultSilenceThreshold,
+		Usage: "Flag running tests that have produced no output for this long in summary.log with --follow.",
+	}
 	app.Flags = []cli.Flag{
 		logLevelFlag,
 		logInputFlag,
 		outputDirFlag,
+		inputFormatFlag,
+		reportFormatFlag,
+		failureExcerptLinesFlag,
+		followFlag,
+		summaryIntervalFlag,
+		silenceThresholdFlag,
 	}
 
 	entrypoint.RunApp(app)
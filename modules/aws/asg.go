@@ -104,11 +104,23 @@ func WaitForCapacityE(
 	maxRetries int,
 	sleepBetweenRetries time.Duration,
 ) error {
-	msg, err := retry.DoWithRetryE(
+	return WaitForCapacityWithPolicyE(t, asgName, region, retry.ConstantPolicy(maxRetries, sleepBetweenRetries))
+}
+
+// WaitForCapacityWithPolicy waits for the currently set desired capacity to be reached on the ASG, retrying the check as
+// configured by the given policy (e.g., with exponential backoff)
+func WaitForCapacityWithPolicy(t testing.TestingT, asgName string, region string, policy *retry.Policy) {
+	err := WaitForCapacityWithPolicyE(t, asgName, region, policy)
+	require.NoError(t, err)
+}
+
+// WaitForCapacityWithPolicyE waits for the currently set desired capacity to be reached on the ASG, retrying the check
+// as configured by the given policy (e.g., with exponential backoff)
+func WaitForCapacityWithPolicyE(t testing.TestingT, asgName string, region string, policy *retry.Policy) error {
+	msg, err := retry.DoWithPolicyE(
 		t,
 		fmt.Sprintf("Waiting for ASG %s to reach desired capacity.", asgName),
-		maxRetries,
-		sleepBetweenRetries,
+		policy,
 		func() (string, error) {
 			capacityInfo, err := GetCapacityInfoForAsgE(t, asgName, region)
 			if err != nil {
@@ -54,7 +54,9 @@ func GetSecretValueE(t testing.TestingT, awsRegion, id string) (string, error) {
 		return "", err
 	}
 
-	return aws.StringValue(secret.SecretString), nil
+	value := aws.StringValue(secret.SecretString)
+	logger.RegisterSecret(t, value)
+	return value, nil
 }
 
 // DeleteSecret deletes a secret. If forceDelete is true, the secret will be deleted after a short delay. If forceDelete is false, the secret will be deleted after a 30 day recovery window.
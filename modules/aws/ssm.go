@@ -121,6 +121,22 @@ func WaitForSsmInstanceE(t testing.TestingT, awsRegion, instanceID string, timeo
 func WaitForSsmInstanceWithClientE(t testing.TestingT, client *ssm.SSM, instanceID string, timeout time.Duration) error {
 	timeBetweenRetries := 2 * time.Second
 	maxRetries := int(timeout.Seconds() / timeBetweenRetries.Seconds())
+	return WaitForSsmInstanceWithClientAndPolicyE(t, client, instanceID, retry.ConstantPolicy(maxRetries, timeBetweenRetries))
+}
+
+// WaitForSsmInstanceWithPolicyE waits until the instance get registered to the SSM inventory, retrying the check as
+// configured by the given policy (e.g., with exponential backoff).
+func WaitForSsmInstanceWithPolicyE(t testing.TestingT, awsRegion, instanceID string, policy *retry.Policy) error {
+	client, err := NewSsmClientE(t, awsRegion)
+	if err != nil {
+		return err
+	}
+	return WaitForSsmInstanceWithClientAndPolicyE(t, client, instanceID, policy)
+}
+
+// WaitForSsmInstanceWithClientAndPolicyE waits until the instance get registered to the SSM inventory with the ability
+// to provide the SSM client, retrying the check as configured by the given policy (e.g., with exponential backoff).
+func WaitForSsmInstanceWithClientAndPolicyE(t testing.TestingT, client *ssm.SSM, instanceID string, policy *retry.Policy) error {
 	description := fmt.Sprintf("Waiting for %s to appear in the SSM inventory", instanceID)
 
 	input := &ssm.GetInventoryInput{
@@ -132,7 +148,7 @@ func WaitForSsmInstanceWithClientE(t testing.TestingT, client *ssm.SSM, instance
 			},
 		},
 	}
-	_, err := retry.DoWithRetryE(t, description, maxRetries, timeBetweenRetries, func() (string, error) {
+	_, err := retry.DoWithPolicyE(t, description, policy, func() (string, error) {
 		resp, err := client.GetInventory(input)
 
 		if err != nil {
@@ -149,6 +165,13 @@ func WaitForSsmInstanceWithClientE(t testing.TestingT, client *ssm.SSM, instance
 	return err
 }
 
+// WaitForSsmInstanceWithPolicy waits until the instance get registered to the SSM inventory, retrying the check as
+// configured by the given policy (e.g., with exponential backoff).
+func WaitForSsmInstanceWithPolicy(t testing.TestingT, awsRegion, instanceID string, policy *retry.Policy) {
+	err := WaitForSsmInstanceWithPolicyE(t, awsRegion, instanceID, policy)
+	require.NoError(t, err)
+}
+
 // WaitForSsmInstance waits until the instance get registered to the SSM inventory.
 func WaitForSsmInstance(t testing.TestingT, awsRegion, instanceID string, timeout time.Duration) {
 	err := WaitForSsmInstanceE(t, awsRegion, instanceID, timeout)
@@ -1,6 +1,7 @@
 package docker
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -57,6 +58,9 @@ type BuildOptions struct {
 
 	// Set a logger that should be used. See the logger package for more info.
 	Logger *logger.Logger
+
+	// If set, running docker build commands are interrupted (and eventually killed) when the context is done.
+	Context context.Context
 }
 
 // Build runs the 'docker build' command at the given path with the given options and fails the test if there are any
@@ -83,6 +87,7 @@ func BuildE(t testing.TestingT, path string, options *BuildOptions) error {
 		Args:    formatDockerBuildArgs(path, options),
 		Logger:  options.Logger,
 		Env:     env,
+		Context: options.Context,
 	}
 
 	if err := shell.RunCommandE(t, cmd); err != nil {
@@ -108,6 +113,7 @@ func BuildE(t testing.TestingT, path string, options *BuildOptions) error {
 			Command: "docker",
 			Args:    formatDockerBuildxLoadArgs(path, options),
 			Logger:  options.Logger,
+			Context: options.Context,
 		}
 		return shell.RunCommandE(t, loadCmd)
 	}
@@ -1,6 +1,7 @@
 package docker
 
 import (
+	"context"
 	"regexp"
 	"strings"
 
@@ -22,6 +23,9 @@ type Options struct {
 	// Set a logger that should be used. See the logger package for more info.
 	Logger      *logger.Logger
 	ProjectName string
+
+	// If set, running docker compose commands are interrupted (and eventually killed) when the context is done.
+	Context context.Context
 }
 
 // RunDockerCompose runs docker compose with the given arguments and options and return stdout/stderr.
@@ -72,6 +76,7 @@ func runDockerComposeE(t testing.TestingT, stdout bool, options *Options, args .
 			WorkingDir: options.WorkingDir,
 			Env:        options.EnvVars,
 			Logger:     options.Logger,
+			Context:    options.Context,
 		}
 	} else {
 		cmd = shell.Command{
@@ -82,6 +87,7 @@ func runDockerComposeE(t testing.TestingT, stdout bool, options *Options, args .
 			WorkingDir: options.WorkingDir,
 			Env:        options.EnvVars,
 			Logger:     options.Logger,
+			Context:    options.Context,
 		}
 	}
 
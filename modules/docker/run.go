@@ -1,6 +1,8 @@
 package docker
 
 import (
+	"context"
+
 	"github.com/nholuongut/terratest/modules/logger"
 	"github.com/nholuongut/terratest/modules/shell"
 	"github.com/nholuongut/terratest/modules/testing"
@@ -50,6 +52,9 @@ type RunOptions struct {
 
 	// Set a logger that should be used. See the logger package for more info.
 	Logger *logger.Logger
+
+	// If set, running docker run commands are interrupted (and eventually killed) when the context is done.
+	Context context.Context
 }
 
 // Run runs the 'docker run' command on the given image with the given options and return stdout/stderr. This method
@@ -73,6 +78,7 @@ func RunE(t testing.TestingT, image string, options *RunOptions) (string, error)
 		Command: "docker",
 		Args:    args,
 		Logger:  options.Logger,
+		Context: options.Context,
 	}
 
 	return shell.RunCommandAndGetOutputE(t, cmd)
@@ -100,6 +106,7 @@ func RunAndGetIDE(t testing.TestingT, image string, options *RunOptions) (string
 		Command: "docker",
 		Args:    args,
 		Logger:  options.Logger,
+		Context: options.Context,
 	}
 
 	return shell.RunCommandAndGetStdOutE(t, cmd)
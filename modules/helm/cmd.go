@@ -80,6 +80,7 @@ func prepareHelmCommand(t testing.TestingT, options *Options, cmd string, additi
 		WorkingDir: ".",
 		Env:        options.EnvVars,
 		Logger:     options.Logger,
+		Context:    options.Context,
 	}
 	return helmCmd
 }
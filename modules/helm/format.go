@@ -2,6 +2,7 @@ package helm
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/nholuongut-io/go-commons/collections"
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/nholuongut/terratest/modules/files"
+	"github.com/nholuongut/terratest/modules/logger"
 	"github.com/nholuongut/terratest/modules/testing"
 )
 
@@ -66,7 +68,8 @@ func formatSetFilesAsArgs(t testing.TestingT, setFiles map[string]string) []stri
 }
 
 // formatSetFilesAsArgsE formats the given list of keys and file paths as command line args for helm to set from file
-// (e.g of the format --set-file key=path)
+// (e.g of the format --set-file key=path). The contents of the files are registered as secrets, so that they are
+// redacted from the logs (e.g., from the output of helm template).
 func formatSetFilesAsArgsE(t testing.TestingT, setFiles map[string]string) ([]string, error) {
 	args := []string{}
 
@@ -82,6 +85,11 @@ func formatSetFilesAsArgsE(t testing.TestingT, setFiles map[string]string) ([]st
 		if !files.FileExists(absSetFilePath) {
 			return args, errors.WithStackTrace(SetFileNotFoundError{setFilePath})
 		}
+		contents, err := os.ReadFile(absSetFilePath)
+		if err != nil {
+			return args, errors.WithStackTrace(err)
+		}
+		logger.RegisterSecret(t, string(contents))
 		argValue := fmt.Sprintf("%s=%s", key, absSetFilePath)
 		args = append(args, "--set-file", argValue)
 	}
@@ -8,6 +8,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/nholuongut/terratest/modules/logger"
+	"github.com/nholuongut/terratest/modules/random"
 )
 
 func TestFormatSetValuesAsArgs(t *testing.T) {
@@ -135,6 +138,18 @@ func TestFormatSetFilesAsArgs(t *testing.T) {
 	})
 }
 
+func TestFormatSetFilesAsArgsRegistersContentsAsSecrets(t *testing.T) {
+	t.Parallel()
+
+	secret := "tls-key-" + random.UniqueId()
+	path := filepath.Join(t.TempDir(), "tls.key")
+	require.NoError(t, os.WriteFile(path, []byte(secret+"\n"), 0600))
+
+	formatSetFilesAsArgs(t, map[string]string{"tls.key": path})
+
+	assert.Equal(t, "key: [REDACTED]", logger.Redact("key: "+secret))
+}
+
 func TestFormatValuesFilesAsArgs(t *testing.T) {
 	t.Parallel()
 
@@ -1,16 +1,18 @@
 package helm
 
 import (
+	"context"
+
 	"github.com/nholuongut/terratest/modules/k8s"
 	"github.com/nholuongut/terratest/modules/logger"
 )
 
 type Options struct {
 	ValuesFiles       []string            // List of values files to render.
-	SetValues         map[string]string   // Values that should be set via the command line.
+	SetValues         map[string]string   // Values that should be set via the command line. Use logger.RegisterSecret to redact secrets from the logs.
 	SetStrValues      map[string]string   // Values that should be set via the command line explicitly as `string` types.
 	SetJsonValues     map[string]string   // Values that should be set via the command line in JSON format.
-	SetFiles          map[string]string   // Values that should be set from a file. These should be file paths. Use to avoid logging secrets.
+	SetFiles          map[string]string   // Values that should be set from a file. These should be file paths. Use to avoid logging secrets: the contents of the files are redacted from the logs.
 	KubectlOptions    *k8s.KubectlOptions // KubectlOptions to control how to authenticate to kubernetes cluster. `nil` => use defaults.
 	HomePath          string              // The path to the helm home to use when calling out to helm. Empty string means use default ($HOME/.helm).
 	EnvVars           map[string]string   // Environment variables to set when running helm
@@ -19,4 +21,5 @@ type Options struct {
 	ExtraArgs         map[string][]string // Extra arguments to pass to the helm install/upgrade/rollback/delete and helm repo add commands. The key signals the command (e.g., install) while the values are the extra arguments to pass through.
 	BuildDependencies bool                // If true, helm dependencies will be built before rendering template, installing or upgrade the chart.
 	SnapshotPath      string              // The path to the snapshot directory when using snapshot based testing. Empty string means use default ($PWD/__snapshot__).
+	Context           context.Context     // If set, running helm commands are interrupted (and eventually killed) when the context is done.
 }
@@ -213,6 +213,46 @@ func HttpGetWithRetryWithCustomValidationWithOptionsE(t testing.TestingT, option
 	return err
 }
 
+// HttpGetWithRetryPolicy repeatedly performs an HTTP GET on the given URL until the given status code and body are
+// returned, retrying as configured by the given policy (e.g., with exponential backoff). This will fail the test if the
+// policy is exhausted.
+func HttpGetWithRetryPolicy(t testing.TestingT, options HttpGetOptions, expectedStatus int, expectedBody string, policy *retry.Policy) {
+	err := HttpGetWithRetryPolicyE(t, options, expectedStatus, expectedBody, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// HttpGetWithRetryPolicyE repeatedly performs an HTTP GET on the given URL until the given status code and body are
+// returned, retrying as configured by the given policy (e.g., with exponential backoff).
+func HttpGetWithRetryPolicyE(t testing.TestingT, options HttpGetOptions, expectedStatus int, expectedBody string, policy *retry.Policy) error {
+	_, err := retry.DoWithPolicyE(t, fmt.Sprintf("HTTP GET to URL %s", options.Url), policy, func() (string, error) {
+		return "", HttpGetWithValidationWithOptionsE(t, options, expectedStatus, expectedBody)
+	})
+
+	return err
+}
+
+// HttpGetWithRetryPolicyWithCustomValidation repeatedly performs an HTTP GET on the given URL until the given
+// validation function returns true, retrying as configured by the given policy (e.g., with exponential backoff). This
+// will fail the test if the policy is exhausted.
+func HttpGetWithRetryPolicyWithCustomValidation(t testing.TestingT, options HttpGetOptions, policy *retry.Policy, validateResponse func(int, string) bool) {
+	err := HttpGetWithRetryPolicyWithCustomValidationE(t, options, policy, validateResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// HttpGetWithRetryPolicyWithCustomValidationE repeatedly performs an HTTP GET on the given URL until the given
+// validation function returns true, retrying as configured by the given policy (e.g., with exponential backoff).
+func HttpGetWithRetryPolicyWithCustomValidationE(t testing.TestingT, options HttpGetOptions, policy *retry.Policy, validateResponse func(int, string) bool) error {
+	_, err := retry.DoWithPolicyE(t, fmt.Sprintf("HTTP GET to URL %s", options.Url), policy, func() (string, error) {
+		return "", HttpGetWithCustomValidationWithOptionsE(t, options, validateResponse)
+	})
+
+	return err
+}
+
 // HTTPDo performs the given HTTP method on the given URL and return the HTTP status code and body.
 // If there's any error, fail the test.
 func HTTPDo(
@@ -346,35 +386,66 @@ func HTTPDoWithRetryWithOptionsE(
 	t testing.TestingT, options HttpDoOptions, expectedStatus int,
 	retries int, sleepBetweenRetries time.Duration,
 ) (string, error) {
+	action, err := httpDoWithExpectedStatus(t, options, expectedStatus)
+	if err != nil {
+		return "", err
+	}
+
+	return retry.DoWithRetryE(
+		t, fmt.Sprintf("HTTP %s to URL %s", options.Method, options.Url), retries,
+		sleepBetweenRetries, action)
+}
+
+// HTTPDoWithRetryPolicy repeatedly performs the given HTTP method on the given URL until the given status code is
+// returned, retrying as configured by the given policy (e.g., with exponential backoff). This will fail the test if
+// the policy is exhausted.
+func HTTPDoWithRetryPolicy(t testing.TestingT, options HttpDoOptions, expectedStatus int, policy *retry.Policy) string {
+	out, err := HTTPDoWithRetryPolicyE(t, options, expectedStatus, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// HTTPDoWithRetryPolicyE repeatedly performs the given HTTP method on the given URL until the given status code is
+// returned, retrying as configured by the given policy (e.g., with exponential backoff).
+func HTTPDoWithRetryPolicyE(t testing.TestingT, options HttpDoOptions, expectedStatus int, policy *retry.Policy) (string, error) {
+	action, err := httpDoWithExpectedStatus(t, options, expectedStatus)
+	if err != nil {
+		return "", err
+	}
+
+	return retry.DoWithPolicyE(t, fmt.Sprintf("HTTP %s to URL %s", options.Method, options.Url), policy, action)
+}
+
+// httpDoWithExpectedStatus returns an action that performs the given HTTP method on the given URL and returns an error
+// if the given status code is not returned. The action can be retried.
+func httpDoWithExpectedStatus(t testing.TestingT, options HttpDoOptions, expectedStatus int) (func() (string, error), error) {
 	var data []byte
 	if options.Body != nil {
 		// The request body is closed after a request is complete.
 		// Read the underlying data and cache it, so we can reuse for retried requests.
 		b, err := io.ReadAll(options.Body)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		data = b
 	}
 
 	options.Body = nil
 
-	out, err := retry.DoWithRetryE(
-		t, fmt.Sprintf("HTTP %s to URL %s", options.Method, options.Url), retries,
-		sleepBetweenRetries, func() (string, error) {
-			options.Body = bytes.NewReader(data)
-			statusCode, out, err := HTTPDoWithOptionsE(t, options)
-			if err != nil {
-				return "", err
-			}
-			logger.Default.Logf(t, "output: %v", out)
-			if statusCode != expectedStatus {
-				return "", ValidationFunctionFailed{Url: options.Url, Status: statusCode}
-			}
-			return out, nil
-		})
-
-	return out, err
+	return func() (string, error) {
+		options.Body = bytes.NewReader(data)
+		statusCode, out, err := HTTPDoWithOptionsE(t, options)
+		if err != nil {
+			return "", err
+		}
+		logger.Default.Logf(t, "output: %v", out)
+		if statusCode != expectedStatus {
+			return "", ValidationFunctionFailed{Url: options.Url, Status: statusCode}
+		}
+		return out, nil
+	}, nil
 }
 
 // HTTPDoWithValidationRetry repeatedly performs the given HTTP method on the given URL until the given status code and
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/nholuongut/terratest/modules/retry"
 	"github.com/stretchr/testify/require"
 )
 
@@ -139,6 +140,34 @@ func TestErrorWithRetry(t *testing.T) {
 	}
 }
 
+func TestOkWithRetryPolicy(t *testing.T) {
+	t.Parallel()
+	failures := 3
+	ts := getTestServerForFunction(func(w http.ResponseWriter, r *http.Request) {
+		bytes, _ := io.ReadAll(r.Body)
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write(bytes)
+	})
+	defer ts.Close()
+
+	body := "TEST_CONTENT"
+	options := HttpDoOptions{Method: "POST", Url: ts.URL, Body: strings.NewReader(body), Timeout: 10}
+	policy := &retry.Policy{MaxRetries: 5, InitialInterval: 10 * time.Millisecond, MaxInterval: 100 * time.Millisecond}
+	response := HTTPDoWithRetryPolicy(t, options, 200, policy)
+	require.Equal(t, body, response)
+
+	failures = 10
+	_, err := HTTPDoWithRetryPolicyE(t, options, 200, &retry.Policy{MaxRetries: 2, InitialInterval: time.Millisecond})
+	var retryErr retry.MaxRetriesExceeded
+	require.ErrorAs(t, err, &retryErr)
+	require.Len(t, retryErr.Errors, 3)
+	require.ErrorAs(t, err, &ValidationFunctionFailed{})
+}
+
 func TestEmptyRequestBodyWithRetryWithOptions(t *testing.T) {
 	t.Parallel()
 	ts := getTestServerForFunction(bodyCopyHandler)
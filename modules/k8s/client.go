@@ -45,7 +45,7 @@ func GetKubernetesClientFromOptionsE(t testing.TestingT, options *KubectlOptions
 		// Load API config (instead of more low level ClientConfig)
 		config, err = LoadApiClientConfigE(kubeConfigPath, options.ContextName)
 		if err != nil {
-			options.Logger.Logf(t, "Error loading api client config, falling back to in-cluster authentication via serviceaccount token: %s", err)
+			options.Logger.Warnf(t, "Error loading api client config, falling back to in-cluster authentication via serviceaccount token: %s", err)
 			config, err = rest.InClusterConfig()
 			if err != nil {
 				return nil, err
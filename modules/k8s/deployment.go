@@ -70,12 +70,24 @@ func WaitUntilDeploymentAvailableE(
 	retries int,
 	sleepBetweenRetries time.Duration,
 ) error {
+	return WaitUntilDeploymentAvailableWithPolicyE(t, options, deploymentName, retry.ConstantPolicy(retries, sleepBetweenRetries))
+}
+
+// WaitUntilDeploymentAvailableWithPolicy waits until all pods within the deployment are ready and started, retrying the
+// check as configured by the given policy (e.g., with exponential backoff). This will fail the test if there is an
+// error or if the policy is exhausted.
+func WaitUntilDeploymentAvailableWithPolicy(t testing.TestingT, options *KubectlOptions, deploymentName string, policy *retry.Policy) {
+	require.NoError(t, WaitUntilDeploymentAvailableWithPolicyE(t, options, deploymentName, policy))
+}
+
+// WaitUntilDeploymentAvailableWithPolicyE waits until all pods within the deployment are ready and started, retrying
+// the check as configured by the given policy (e.g., with exponential backoff).
+func WaitUntilDeploymentAvailableWithPolicyE(t testing.TestingT, options *KubectlOptions, deploymentName string, policy *retry.Policy) error {
 	statusMsg := fmt.Sprintf("Wait for deployment %s to be provisioned.", deploymentName)
-	message, err := retry.DoWithRetryE(
+	message, err := retry.DoWithPolicyE(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		policy,
 		func() (string, error) {
 			deployment, err := GetDeploymentE(t, options, deploymentName)
 			if err != nil {
@@ -88,7 +100,7 @@ func WaitUntilDeploymentAvailableE(
 		},
 	)
 	if err != nil {
-		options.Logger.Logf(t, "Timedout waiting for Deployment to be provisioned: %s", err)
+		options.Logger.Warnf(t, "Timedout waiting for Deployment to be provisioned: %s", err)
 		return err
 	}
 	options.Logger.Logf(t, message)
@@ -62,12 +62,23 @@ func WaitUntilJobSucceed(t testing.TestingT, options *KubectlOptions, jobName st
 // WaitUntilJobSucceedE waits until requested job is succeeded, retrying the check for the specified amount of times, sleeping
 // for the provided duration between each try.
 func WaitUntilJobSucceedE(t testing.TestingT, options *KubectlOptions, jobName string, retries int, sleepBetweenRetries time.Duration) error {
+	return WaitUntilJobSucceedWithPolicyE(t, options, jobName, retry.ConstantPolicy(retries, sleepBetweenRetries))
+}
+
+// WaitUntilJobSucceedWithPolicy waits until requested job is succeeded, retrying the check as configured by the given
+// policy (e.g., with exponential backoff). This will fail the test if there is an error or if the policy is exhausted.
+func WaitUntilJobSucceedWithPolicy(t testing.TestingT, options *KubectlOptions, jobName string, policy *retry.Policy) {
+	require.NoError(t, WaitUntilJobSucceedWithPolicyE(t, options, jobName, policy))
+}
+
+// WaitUntilJobSucceedWithPolicyE waits until requested job is succeeded, retrying the check as configured by the given
+// policy (e.g., with exponential backoff).
+func WaitUntilJobSucceedWithPolicyE(t testing.TestingT, options *KubectlOptions, jobName string, policy *retry.Policy) error {
 	statusMsg := fmt.Sprintf("Wait for job %s to be provisioned.", jobName)
-	message, err := retry.DoWithRetryE(
+	message, err := retry.DoWithPolicyE(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		policy,
 		func() (string, error) {
 			job, err := GetJobE(t, options, jobName)
 			if err != nil {
@@ -80,7 +91,7 @@ func WaitUntilJobSucceedE(t testing.TestingT, options *KubectlOptions, jobName s
 		},
 	)
 	if err != nil {
-		options.Logger.Logf(t, "Timed out waiting for Job to be provisioned: %s", err)
+		options.Logger.Warnf(t, "Timed out waiting for Job to be provisioned: %s", err)
 		return err
 	}
 	options.Logger.Logf(t, message)
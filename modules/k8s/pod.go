@@ -94,7 +94,7 @@ func WaitUntilNumPodsCreatedE(
 		},
 	)
 	if err != nil {
-		options.Logger.Logf(t, "Timedout waiting for the desired number of Pods to be created: %s", err)
+		options.Logger.Warnf(t, "Timedout waiting for the desired number of Pods to be created: %s", err)
 		return err
 	}
 	options.Logger.Logf(t, message)
@@ -110,12 +110,24 @@ func WaitUntilPodAvailable(t testing.TestingT, options *KubectlOptions, podName
 // WaitUntilPodAvailableE waits until all of the containers within the pod are ready and started, retrying the check for the specified amount of times, sleeping
 // for the provided duration between each try.
 func WaitUntilPodAvailableE(t testing.TestingT, options *KubectlOptions, podName string, retries int, sleepBetweenRetries time.Duration) error {
+	return WaitUntilPodAvailableWithPolicyE(t, options, podName, retry.ConstantPolicy(retries, sleepBetweenRetries))
+}
+
+// WaitUntilPodAvailableWithPolicy waits until all of the containers within the pod are ready and started, retrying the
+// check as configured by the given policy (e.g., with exponential backoff). This will fail the test if there is an
+// error or if the policy is exhausted.
+func WaitUntilPodAvailableWithPolicy(t testing.TestingT, options *KubectlOptions, podName string, policy *retry.Policy) {
+	require.NoError(t, WaitUntilPodAvailableWithPolicyE(t, options, podName, policy))
+}
+
+// WaitUntilPodAvailableWithPolicyE waits until all of the containers within the pod are ready and started, retrying the
+// check as configured by the given policy (e.g., with exponential backoff).
+func WaitUntilPodAvailableWithPolicyE(t testing.TestingT, options *KubectlOptions, podName string, policy *retry.Policy) error {
 	statusMsg := fmt.Sprintf("Wait for pod %s to be provisioned.", podName)
-	message, err := retry.DoWithRetryE(
+	message, err := retry.DoWithPolicyE(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		policy,
 		func() (string, error) {
 			pod, err := GetPodE(t, options, podName)
 			if err != nil {
@@ -128,7 +140,7 @@ func WaitUntilPodAvailableE(t testing.TestingT, options *KubectlOptions, podName
 		},
 	)
 	if err != nil {
-		options.Logger.Logf(t, "Timedout waiting for Pod to be provisioned: %s", err)
+		options.Logger.Warnf(t, "Timedout waiting for Pod to be provisioned: %s", err)
 		return err
 	}
 	options.Logger.Logf(t, message)
@@ -12,6 +12,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 
+	"github.com/nholuongut/terratest/modules/logger"
 	"github.com/nholuongut/terratest/modules/retry"
 	"github.com/nholuongut/terratest/modules/testing"
 )
@@ -100,7 +101,9 @@ func GetServiceAccountAuthTokenE(t testing.TestingT, kubectlOptions *KubectlOpti
 		return "", errors.WithStackTrace(ServiceAccountTokenNotAvailable{serviceAccountName})
 	}
 	secret := GetSecret(t, kubectlOptions, serviceAccount.Secrets[0].Name)
-	return string(secret.Data["token"]), nil
+	token := string(secret.Data["token"])
+	logger.RegisterSecret(t, token)
+	return token, nil
 }
 
 // AddConfigContextForServiceAccountE will add a new config context that binds the ServiceAccount auth token to the
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nholuongut/terratest/modules/testing"
+)
+
+// Entry is a message logged by the JSON logger. Each entry is written as a JSON object on a single line.
+type Entry struct {
+	// The name of the test that logged the message.
+	Test string `json:"test"`
+	// The time the message was logged.
+	Time time.Time `json:"time"`
+	// The file and line number that logged the message (e.g., "apply.go:42").
+	Caller string `json:"caller"`
+	// The level of the message (e.g., "info"), as returned by Level.String.
+	Level string `json:"level"`
+	// The component that logged the message (e.g., "terraform" or "helm"). This is the component set with
+	// Logger.WithComponent, or else the terratest module (or the package, outside of terratest) of the caller.
+	Component string `json:"component,omitempty"`
+	// The message.
+	Message string `json:"msg"`
+}
+
+// String formats the entry like DoLog does.
+func (entry Entry) String() string {
+	return fmt.Sprintf("%s %s %s: %s", entry.Test, entry.Time.Format(time.RFC3339), entry.Caller, entry.Message)
+}
+
+// ParseEntry parses a line written by the JSON logger. It returns false if the line is not a JSON log entry (e.g., if
+// it is a plain log line or output of go test).
+func ParseEntry(line string) (Entry, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return Entry{}, false
+	}
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Test == "" {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// JSONLogger is a TestLogger that logs each message as an Entry, written as a JSON object on a single line.
+type JSONLogger struct {
+	// The writer to write the entries to. Defaults to os.Stdout.
+	Writer io.Writer
+	// The component to tag all messages with. Defaults to the component set with Logger.WithComponent, or else the
+	// terratest module (or the package, outside of terratest) of the caller.
+	Component string
+}
+
+func (l JSONLogger) Logf(t testing.TestingT, format string, args ...interface{}) {
+	l.doLog(t, LevelInfo, l.Component, fmt.Sprintf(format, args...))
+}
+
+func (l JSONLogger) LogfAtLevel(t testing.TestingT, level Level, component string, format string, args ...interface{}) {
+	if l.Component != "" {
+		component = l.Component
+	}
+	l.doLog(t, level, component, fmt.Sprintf(format, args...))
+}
+
+// doLog must be called directly from Logf or LogfAtLevel, so that the caller of the Logger is found at a fixed call
+// depth.
+func (l JSONLogger) doLog(t testing.TestingT, level Level, component string, message string) {
+	writer := l.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+	DoJSONLog(t, 5, writer, level, component, message)
+}
+
+// jsonWriteLock ensures that entries logged concurrently, e.g. by parallel tests, are not interleaved.
+var jsonWriteLock sync.Mutex
+
+// DoJSONLog logs the given message to the given writer as an Entry, written as a JSON object on a single line. The
+// argument callDepth is the number of stack frames to ascend to find the caller, as in CallerPrefix. If component is
+// empty, the terratest module (or the package, outside of terratest) of the caller is used.
+func DoJSONLog(t testing.TestingT, callDepth int, writer io.Writer, level Level, component string, message string) {
+	if component == "" {
+		component = callerComponent(callDepth + 1)
+	}
+
+	entry := Entry{
+		Test:      t.Name(),
+		Time:      time.Now(),
+		Caller:    CallerPrefix(callDepth + 1),
+		Level:     level.String(),
+		Component: component,
+		Message:   Redact(message),
+	}
+
+	out, err := json.Marshal(entry)
+	if err != nil {
+		// This should never happen, as an Entry contains only strings and a time.
+		DoLog(t, callDepth+1, writer, message)
+		return
+	}
+
+	jsonWriteLock.Lock()
+	defer jsonWriteLock.Unlock()
+	fmt.Fprintln(writer, string(out))
+}
+
+// terratestModulesPath is the import path prefix of the terratest modules.
+const terratestModulesPath = "github.com/nholuongut/terratest/modules/"
+
+// callerComponent returns the terratest module (e.g., "terraform" or "k8s") of the function at the given call depth, or
+// the last element of its package path if it is not part of terratest. The call depth is counted as in CallerPrefix.
+func callerComponent(callDepth int) string {
+	pc, _, _, ok := runtime.Caller(callDepth)
+	if !ok {
+		return ""
+	}
+	function := runtime.FuncForPC(pc)
+	if function == nil {
+		return ""
+	}
+
+	// Function names are of the form "github.com/org/repo/pkg.Func" or "github.com/org/repo/pkg.(*Type).Method".
+	name := function.Name()
+	pkg := name
+	if slash := strings.LastIndex(name, "/"); slash >= 0 {
+		if dot := strings.Index(name[slash:], "."); dot >= 0 {
+			pkg = name[:slash+dot]
+		}
+	} else if dot := strings.Index(name, "."); dot >= 0 {
+		pkg = name[:dot]
+	}
+
+	if strings.HasPrefix(pkg, terratestModulesPath) {
+		return strings.SplitN(strings.TrimPrefix(pkg, terratestModulesPath), "/", 2)[0]
+	}
+	return pkg[strings.LastIndex(pkg, "/")+1:]
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLogger(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	l := New(JSONLogger{Writer: &buffer})
+	l.Logf(t, "first line\nsecond line: %d", 42)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 1)
+
+	entry, isEntry := ParseEntry(lines[0])
+	require.True(t, isEntry)
+	assert.Equal(t, t.Name(), entry.Test)
+	assert.Regexp(t, `^json_test.go:[0-9]+$`, entry.Caller)
+	assert.Equal(t, "info", entry.Level)
+	assert.Equal(t, "logger", entry.Component)
+	assert.Equal(t, "first line\nsecond line: 42", entry.Message)
+	assert.False(t, entry.Time.IsZero())
+}
+
+func TestJSONLoggerWithComponent(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	l := New(JSONLogger{Writer: &buffer}).WithComponent("terraform")
+	l.Logf(t, "Apply complete!")
+
+	entry, isEntry := ParseEntry(buffer.String())
+	require.True(t, isEntry)
+	assert.Equal(t, "terraform", entry.Component)
+	assert.Equal(t, "Apply complete!", entry.Message)
+}
+
+func TestParseEntryIgnoresOtherLines(t *testing.T) {
+	t.Parallel()
+
+	for _, line := range []string{
+		"",
+		"=== RUN   TestFoo",
+		"TestFoo 2024-01-02T03:04:05Z foo_test.go:10: {\"not\": \"an entry\"}",
+		`{"some": "other json"}`,
+		`{"test": "TestFoo", "msg": "truncated`,
+	} {
+		_, isEntry := ParseEntry(line)
+		assert.False(t, isEntry, line)
+	}
+}
+
+func TestCallerComponent(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "logger", callerComponent(1))
+}
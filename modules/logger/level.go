@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a log message. Messages below the threshold for their component (see ThresholdFor) are not
+// logged.
+type Level int
+
+const (
+	// LevelDebug is for detail that is mostly useful when debugging, such as retry attempts and the output of commands.
+	LevelDebug Level = iota
+	// LevelInfo is for regular progress messages. This is the level of Logf.
+	LevelInfo
+	// LevelWarn is for problems that may make the test fail, such as exhausted retries.
+	LevelWarn
+	// LevelError is for failures.
+	LevelError
+)
+
+// LogLevelEnvVar is the environment variable that sets the log level threshold. Its value is a level (e.g., "info"),
+// optionally followed by comma-separated per-component overrides (e.g., "warn,terraform=info,retry=debug"). The
+// component of a message is the terratest module that logged it (e.g., "k8s" or "retry"), or the command that wrote it
+// for the output of commands run with the shell module (e.g., "terraform" or "helm"). Levels set with this variable
+// take precedence over those set with SetThreshold and SetComponentThreshold.
+const LogLevelEnvVar = "TERRATEST_LOG_LEVEL"
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (level Level) String() string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(level))
+}
+
+// ParseLevel parses the name of a level (debug, info, warn or error), ignoring case. "warning" is accepted for warn.
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warning" {
+		return LevelWarn, nil
+	}
+	for level, levelName := range levelNames {
+		if name == levelName {
+			return level, nil
+		}
+	}
+	return LevelDebug, fmt.Errorf("invalid log level %q: must be one of debug, info, warn or error", name)
+}
+
+// thresholds are log level thresholds, for all components and per component.
+type thresholds struct {
+	defaultLevel    *Level
+	componentLevels map[string]Level
+}
+
+var levelConfig = struct {
+	sync.RWMutex
+
+	// The thresholds set in code.
+	code thresholds
+
+	// The thresholds parsed from LogLevelEnvVar, cached as long as its value doesn't change.
+	envValue string
+	env      thresholds
+}{code: thresholds{componentLevels: map[string]Level{}}}
+
+// SetThreshold sets the log level threshold for all components, unless overridden for a component. By default, all
+// messages are logged.
+func SetThreshold(level Level) {
+	levelConfig.Lock()
+	defer levelConfig.Unlock()
+
+	levelConfig.code.defaultLevel = &level
+}
+
+// SetComponentThreshold sets the log level threshold for the given component (e.g., "retry" or "terraform").
+func SetComponentThreshold(component string, level Level) {
+	levelConfig.Lock()
+	defer levelConfig.Unlock()
+
+	levelConfig.code.componentLevels[component] = level
+}
+
+// ThresholdFor returns the log level threshold for the given component. Messages below the threshold are not logged.
+// The threshold is looked up, in order, in the overrides of LogLevelEnvVar for the component, the thresholds set with
+// SetComponentThreshold, the level of LogLevelEnvVar, and the threshold set with SetThreshold. If none is set, all
+// messages are logged.
+func ThresholdFor(component string) Level {
+	env := envThresholds()
+
+	levelConfig.RLock()
+	defer levelConfig.RUnlock()
+
+	if level, ok := env.componentLevels[component]; ok {
+		return level
+	}
+	if level, ok := levelConfig.code.componentLevels[component]; ok {
+		return level
+	}
+	if env.defaultLevel != nil {
+		return *env.defaultLevel
+	}
+	if levelConfig.code.defaultLevel != nil {
+		return *levelConfig.code.defaultLevel
+	}
+	return LevelDebug
+}
+
+// envThresholds returns the thresholds set with LogLevelEnvVar. If its value is invalid, it is ignored as a whole.
+func envThresholds() thresholds {
+	value := os.Getenv(LogLevelEnvVar)
+
+	levelConfig.RLock()
+	if value == levelConfig.envValue {
+		defer levelConfig.RUnlock()
+		return levelConfig.env
+	}
+	levelConfig.RUnlock()
+
+	parsed, err := parseThresholds(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring invalid value of %s: %v\n", LogLevelEnvVar, err)
+		parsed = thresholds{}
+	}
+
+	levelConfig.Lock()
+	defer levelConfig.Unlock()
+	levelConfig.envValue = value
+	levelConfig.env = parsed
+	return parsed
+}
+
+// parseThresholds parses thresholds in the format of LogLevelEnvVar (e.g., "warn,terraform=info").
+func parseThresholds(value string) (thresholds, error) {
+	parsed := thresholds{componentLevels: map[string]Level{}}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		component, levelName, isOverride := strings.Cut(part, "=")
+		if !isOverride {
+			levelName = component
+		}
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return parsed, err
+		}
+
+		if isOverride {
+			parsed.componentLevels[strings.TrimSpace(component)] = level
+		} else {
+			parsed.defaultLevel = &level
+		}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tftesting "github.com/nholuongut/terratest/modules/testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		in       string
+		expected Level
+	}{
+		{"debug", LevelDebug},
+		{"INFO", LevelInfo},
+		{" warn ", LevelWarn},
+		{"warning", LevelWarn},
+		{"Error", LevelError},
+	}
+	for _, testCase := range testCases {
+		level, err := ParseLevel(testCase.in)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, level)
+		assert.Equal(t, testCase.expected, mustParseLevel(t, level.String()))
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func mustParseLevel(t *testing.T, name string) Level {
+	level, err := ParseLevel(name)
+	require.NoError(t, err)
+	return level
+}
+
+type levelLogger struct {
+	logs []string
+}
+
+func (l *levelLogger) Logf(t tftesting.TestingT, format string, args ...interface{}) {
+	l.LogfAtLevel(t, LevelInfo, "", format, args...)
+}
+
+func (l *levelLogger) LogfAtLevel(t tftesting.TestingT, level Level, component string, format string, args ...interface{}) {
+	l.logs = append(l.logs, fmt.Sprintf("%s %s %s", level, component, fmt.Sprintf(format, args...)))
+}
+
+func TestLoggerFiltersByComponentThreshold(t *testing.T) {
+	t.Parallel()
+
+	c := &levelLogger{}
+	l := New(c).WithComponent("level-test-component")
+	SetComponentThreshold("level-test-component", LevelWarn)
+
+	l.Debugf(t, "debug")
+	l.Logf(t, "info")
+	l.Infof(t, "info")
+	l.Warnf(t, "warn")
+	l.Errorf(t, "error")
+
+	assert.Equal(t, []string{"warn level-test-component warn", "error level-test-component error"}, c.logs)
+}
+
+func TestLoggerPassesCallerComponent(t *testing.T) {
+	t.Parallel()
+
+	c := &levelLogger{}
+	New(c).Debugf(t, "debug")
+
+	assert.Equal(t, []string{"debug logger debug"}, c.logs)
+}
+
+func TestThresholdFromEnvVar(t *testing.T) {
+	t.Setenv(LogLevelEnvVar, "warn, level-test-env=debug")
+	SetComponentThreshold("level-test-code", LevelError)
+	SetComponentThreshold("level-test-env", LevelError)
+
+	assert.Equal(t, LevelWarn, ThresholdFor("level-test-other"))
+	assert.Equal(t, LevelError, ThresholdFor("level-test-code"))
+	assert.Equal(t, LevelDebug, ThresholdFor("level-test-env"))
+
+	var buffer bytes.Buffer
+	l := New(JSONLogger{Writer: &buffer}).WithComponent("level-test-other")
+	l.Logf(t, "filtered")
+	l.Warnf(t, "logged")
+
+	entry, isEntry := ParseEntry(buffer.String())
+	require.True(t, isEntry)
+	assert.Equal(t, "warn", entry.Level)
+	assert.Equal(t, "logged", entry.Message)
+
+	t.Setenv(LogLevelEnvVar, "loud")
+	assert.Equal(t, LevelDebug, ThresholdFor("level-test-other"))
+}
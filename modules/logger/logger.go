@@ -39,6 +39,10 @@ var (
 	// TestingT can be used to use Go's testing.T to log. If this is used, but no testing.T is provided, it will fallback
 	// to Default.
 	TestingT = New(testingT{})
+	// JSON logs each message to stdout as a JSON object on a single line, with the test name, timestamp, caller, level,
+	// component and message as separate fields (see Entry). Unlike the plain output of Terratest, this can be parsed
+	// reliably, e.g. by terratest_log_parser, even if messages span multiple lines.
+	JSON = New(JSONLogger{})
 )
 
 type TestLogger interface {
@@ -47,27 +51,110 @@ type TestLogger interface {
 
 type Logger struct {
 	l TestLogger
+
+	// The component to tag messages with, for TestLoggers that support it (see LevelLogger), and to look up the
+	// log level threshold for. Defaults to the terratest module of the caller.
+	component string
 }
 
 func New(l TestLogger) *Logger {
 	return &Logger{
-		l,
+		l: l,
+	}
+}
+
+// WithComponent returns a copy of this Logger that tags all messages with the given component (e.g., "terraform" or
+// "helm"), for TestLoggers that support it, such as JSON. The component is also used to look up the log level
+// threshold (see ThresholdFor).
+func (l *Logger) WithComponent(component string) *Logger {
+	if l == nil {
+		return &Logger{component: component}
 	}
+	return &Logger{l: l.l, component: component}
 }
 
+// Logf logs the given format and arguments at LevelInfo.
 func (l *Logger) Logf(t testing.TestingT, format string, args ...interface{}) {
 	if tt, ok := t.(helper); ok {
 		tt.Helper()
 	}
+	l.log(t, LevelInfo, format, args...)
+}
 
-	// methods can be called on (typed) nil pointers. In this case, use the Default function to log. This enables the
+// Debugf logs the given format and arguments at LevelDebug, e.g. for retry attempts or the output of commands.
+func (l *Logger) Debugf(t testing.TestingT, format string, args ...interface{}) {
+	if tt, ok := t.(helper); ok {
+		tt.Helper()
+	}
+	l.log(t, LevelDebug, format, args...)
+}
+
+// Infof logs the given format and arguments at LevelInfo. This is the same as Logf.
+func (l *Logger) Infof(t testing.TestingT, format string, args ...interface{}) {
+	if tt, ok := t.(helper); ok {
+		tt.Helper()
+	}
+	l.log(t, LevelInfo, format, args...)
+}
+
+// Warnf logs the given format and arguments at LevelWarn, e.g. when retries are exhausted.
+func (l *Logger) Warnf(t testing.TestingT, format string, args ...interface{}) {
+	if tt, ok := t.(helper); ok {
+		tt.Helper()
+	}
+	l.log(t, LevelWarn, format, args...)
+}
+
+// Errorf logs the given format and arguments at LevelError.
+func (l *Logger) Errorf(t testing.TestingT, format string, args ...interface{}) {
+	if tt, ok := t.(helper); ok {
+		tt.Helper()
+	}
+	l.log(t, LevelError, format, args...)
+}
+
+// log logs the given format and arguments at the given level, if the level is at or above the threshold for the
+// component. This must be called directly from the exported log methods, so that the TestLoggers find the caller at a
+// fixed call depth.
+func (l *Logger) log(t testing.TestingT, level Level, format string, args ...interface{}) {
+	if tt, ok := t.(helper); ok {
+		tt.Helper()
+	}
+
+	// methods can be called on (typed) nil pointers. In this case, use the Default logger to log. This enables the
 	// caller to do `var l *Logger` and then use the logger already.
-	if l == nil || l.l == nil {
-		Default.Logf(t, format, args...)
+	testLogger := Default.l
+	component := ""
+	if l != nil {
+		component = l.component
+		if l.l != nil {
+			testLogger = l.l
+		}
+	}
+	if component == "" {
+		component = callerComponent(3)
+	}
+
+	if level < ThresholdFor(component) {
+		return
+	}
+
+	if hasSecrets() {
+		format, args = "%s", []interface{}{Redact(fmt.Sprintf(format, args...))}
+	}
+
+	if levelLogger, ok := testLogger.(LevelLogger); ok {
+		levelLogger.LogfAtLevel(t, level, component, format, args...)
 		return
 	}
+	testLogger.Logf(t, format, args...)
+}
 
-	l.l.Logf(t, format, args...)
+// LevelLogger is a TestLogger that can log the level of messages and the component that logged them (e.g.,
+// "terraform" or "helm"). Logger uses LogfAtLevel instead of Logf for such TestLoggers.
+type LevelLogger interface {
+	TestLogger
+	LogfAtLevel(t testing.TestingT, level Level, component string, format string, args ...interface{})
 }
 
 // helper is used to mark this library as a "helper", and thus not appearing in the line numbers. testing.T implements
@@ -99,7 +186,7 @@ func (_ testingT) Logf(t testing.TestingT, format string, args ...interface{}) {
 type terratestLogger struct{}
 
 func (_ terratestLogger) Logf(t testing.TestingT, format string, args ...interface{}) {
-	DoLog(t, 3, os.Stdout, fmt.Sprintf(format, args...))
+	DoLog(t, 4, os.Stdout, fmt.Sprintf(format, args...))
 }
 
 // Deprecated: use Logger instead, as it provides more flexibility on logging.
@@ -144,6 +231,10 @@ func Log(t testing.TestingT, args ...interface{}) {
 func DoLog(t testing.TestingT, callDepth int, writer io.Writer, args ...interface{}) {
 	date := time.Now()
 	prefix := fmt.Sprintf("%s %s %s:", t.Name(), date.Format(time.RFC3339), CallerPrefix(callDepth+1))
+	if hasSecrets() {
+		fmt.Fprintln(writer, prefix, Redact(strings.TrimSuffix(fmt.Sprintln(args...), "\n")))
+		return
+	}
 	allArgs := append([]interface{}{prefix}, args...)
 	fmt.Fprintln(writer, allArgs...)
 }
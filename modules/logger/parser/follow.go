@@ -0,0 +1,185 @@
+// Package logger/parser contains methods to parse and restructure log output from go testing and terratest
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSummaryInterval is the default interval at which summary.log is rewritten while following test output.
+const DefaultSummaryInterval = 10 * time.Second
+
+// DefaultSilenceThreshold is the default time after which a running test that has produced no output is flagged as
+// silent in summary.log while following test output.
+const DefaultSilenceThreshold = 5 * time.Minute
+
+// progress tracks the tests that are running while following test output, so that summary.log can be rewritten
+// periodically with the tests that are still running. It is safe for concurrent use.
+type progress struct {
+	mutex sync.Mutex
+
+	// The lines of the summary so far, such as the results of the tests that finished.
+	summaryLines []string
+
+	// The tests that are running, in the order they started.
+	running []string
+	// The time each running test started.
+	started map[string]time.Time
+	// The last time each running test, or one of its subtests, produced output.
+	lastOutput map[string]time.Time
+}
+
+func newProgress() *progress {
+	return &progress{
+		started:    map[string]time.Time{},
+		lastOutput: map[string]time.Time{},
+	}
+}
+
+// testStarted records that the given test started at the given time, unless it is already running (e.g., when a
+// paused test continues).
+func (progress *progress) testStarted(testName string, now time.Time) {
+	if progress == nil {
+		return
+	}
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	if _, isRunning := progress.started[testName]; isRunning {
+		return
+	}
+	progress.running = append(progress.running, testName)
+	progress.started[testName] = now
+	progress.lastOutput[testName] = now
+}
+
+// testOutput records that the given test produced output at the given time. This counts as output of its parent tests
+// too, as they wait for their subtests.
+func (progress *progress) testOutput(testName string, now time.Time) {
+	if progress == nil {
+		return
+	}
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	for name := testName; name != ""; name = name[:max(strings.LastIndex(name, "/"), 0)] {
+		if _, isRunning := progress.started[name]; isRunning {
+			progress.lastOutput[name] = now
+		}
+	}
+}
+
+// testFinished records that the given test finished.
+func (progress *progress) testFinished(testName string) {
+	if progress == nil {
+		return
+	}
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	if _, isRunning := progress.started[testName]; !isRunning {
+		return
+	}
+	delete(progress.started, testName)
+	delete(progress.lastOutput, testName)
+	for i, name := range progress.running {
+		if name == testName {
+			progress.running = append(progress.running[:i], progress.running[i+1:]...)
+			break
+		}
+	}
+}
+
+// addSummaryLine adds the given line to the summary.
+func (progress *progress) addSummaryLine(line string) {
+	if progress == nil {
+		return
+	}
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	progress.summaryLines = append(progress.summaryLines, line)
+}
+
+// render renders the summary so far, followed by a line for each test that is still running as of the given time, in
+// the format of go test's result lines (e.g., "--- RUNNING: TestFoo (42.00s)"). Tests that have produced no output for
+// at least silenceThreshold are flagged as silent.
+func (progress *progress) render(now time.Time, silenceThreshold time.Duration) string {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	lines := append([]string{}, progress.summaryLines...)
+	for _, testName := range progress.running {
+		indent := strings.Repeat("    ", strings.Count(testName, "/"))
+		line := fmt.Sprintf("%s--- RUNNING: %s (%.2fs", indent, testName, now.Sub(progress.started[testName]).Seconds())
+		if silence := now.Sub(progress.lastOutput[testName]); silence >= silenceThreshold {
+			line += fmt.Sprintf(", no output for %s", silence.Round(time.Second))
+		}
+		lines = append(lines, line+")")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// storeProgress rewrites summary.log in the output directory with the summary so far and the tests that are still
+// running.
+func storeProgress(logger *logrus.Logger, outputDir string, progress *progress, silenceThreshold time.Duration) {
+	ensureDirectoryExists(logger, outputDir)
+	filename := filepath.Join(outputDir, "summary.log")
+	if err := writeFileAtomically(filename, progress.render(time.Now(), silenceThreshold)); err != nil {
+		logger.Errorf("Error writing summary %s: %s", filename, err)
+	}
+}
+
+// writeFileAtomically writes the given contents to the given file by renaming a temporary file, so that readers never
+// see a partially written file.
+func writeFileAtomically(filename string, contents string) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.WriteString(contents); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), filename)
+}
+
+// storeProgressPeriodically rewrites summary.log every interval until the returned function is called, which waits for
+// the last rewrite to complete.
+func storeProgressPeriodically(logger *logrus.Logger, outputDir string, progress *progress, interval time.Duration, silenceThreshold time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				storeProgress(logger, outputDir, progress, silenceThreshold)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressRender(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	progress := newProgress()
+	progress.addSummaryLine("--- PASS: TestDone (1.00s)")
+	progress.testStarted("TestStuck", start)
+	progress.testStarted("TestParent", start)
+	progress.testStarted("TestParent/sub", start.Add(time.Minute))
+	progress.testStarted("TestFinished", start)
+	progress.testFinished("TestFinished")
+
+	// Output of a subtest counts as output of its parent, which waits for it.
+	progress.testOutput("TestParent/sub", start.Add(9*time.Minute))
+	// Continuing a paused test doesn't restart it.
+	progress.testStarted("TestStuck", start.Add(2*time.Minute))
+
+	expected := "--- PASS: TestDone (1.00s)\n" +
+		"--- RUNNING: TestStuck (600.00s, no output for 10m0s)\n" +
+		"--- RUNNING: TestParent (600.00s)\n" +
+		"    --- RUNNING: TestParent/sub (540.00s)\n"
+	assert.Equal(t, expected, progress.render(start.Add(10*time.Minute), 5*time.Minute))
+}
+
+func TestSpawnParsersFollow(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	reader, writer := io.Pipe()
+	var followOutput bytes.Buffer
+	options := Options{
+		Follow:           true,
+		FollowOutput:     &followOutput,
+		SummaryInterval:  10 * time.Millisecond,
+		SilenceThreshold: time.Hour,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		SpawnParsersWithOptions(NewTestLogger(t), reader, dir, options)
+	}()
+
+	_, err := io.WriteString(writer, "=== RUN   TestA\nTestA 2024-03-01T10:00:00Z a_test.go:10: deploying\n")
+	require.NoError(t, err)
+
+	// While the test runs, the summary lists it as running, and its log is written as the output is read.
+	summaryFile := filepath.Join(dir, "summary.log")
+	require.Eventually(t, func() bool {
+		summary, err := os.ReadFile(summaryFile)
+		return err == nil && bytes.HasPrefix(summary, []byte("--- RUNNING: TestA ("))
+	}, 5*time.Second, 10*time.Millisecond)
+	testLog, err := os.ReadFile(filepath.Join(dir, "TestA.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "=== RUN   TestA\nTestA 2024-03-01T10:00:00Z a_test.go:10: deploying\n", string(testLog))
+
+	_, err = io.WriteString(writer, "--- PASS: TestA (1.00s)\nPASS\nok  \texample\t1.00s\n")
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	<-done
+
+	summary, err := os.ReadFile(summaryFile)
+	require.NoError(t, err)
+	assert.Equal(t, "--- PASS: TestA (1.00s)\nok  \texample\t1.00s\n", string(summary))
+	assert.Equal(
+		t,
+		"=== RUN   TestA\nTestA 2024-03-01T10:00:00Z a_test.go:10: deploying\n--- PASS: TestA (1.00s)\nPASS\nok  \texample\t1.00s\n",
+		followOutput.String(),
+	)
+}
+
+func TestSpawnParsersFollowTestEvents(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var followOutput bytes.Buffer
+	options := Options{
+		InputFormat:  InputFormatTestJSON,
+		Follow:       true,
+		FollowOutput: &followOutput,
+	}
+	SpawnParsersWithOptions(NewTestLogger(t), openFile(t, "./fixtures/json_example.log"), dir, options)
+
+	expectedLog, err := os.ReadFile("./fixtures/json_example.log")
+	require.NoError(t, err)
+	assert.Equal(t, string(expectedLog), followOutput.String())
+
+	// The final summary is the same as without following.
+	expectedSummary, err := os.ReadFile("./fixtures/json_example_expected/summary.log")
+	require.NoError(t, err)
+	summary, err := os.ReadFile(filepath.Join(dir, "summary.log"))
+	require.NoError(t, err)
+	assert.Equal(t, string(expectedSummary), string(summary))
+}
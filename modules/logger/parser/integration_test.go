@@ -1,13 +1,16 @@
 package parser
 
 import (
+	"encoding/xml"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
+	junitformatter "github.com/jstemmer/go-junit-report/formatter"
 	"github.com/nholuongut/terratest/modules/shell"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func DirectoryEqual(t *testing.T, dirA string, dirB string) bool {
@@ -70,3 +73,38 @@ func TestIntegrationNewGoExample(t *testing.T) {
 	t.Parallel()
 	testExample(t, "new_go_failing")
 }
+
+func TestIntegrationTestJSONExample(t *testing.T) {
+	t.Parallel()
+
+	logger := NewTestLogger(t)
+	dir := t.TempDir()
+	file := openFile(t, "./fixtures/json_example.log")
+	SpawnParsersWithOptions(logger, file, dir, Options{InputFormat: InputFormatTestJSON})
+
+	// The JUnit report contains the version of go, so check it separately from the other files.
+	reportXML, err := os.ReadFile(filepath.Join(dir, "report.xml"))
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, "report.xml")))
+	assert.True(t, DirectoryEqual(t, dir, "./fixtures/json_example_expected"))
+
+	var report junitformatter.JUnitTestSuites
+	require.NoError(t, xml.Unmarshal(reportXML, &report))
+	require.Len(t, report.Suites, 1)
+	suite := report.Suites[0]
+	assert.Equal(t, "github.com/example/app/example", suite.Name)
+	assert.Equal(t, "0.126", suite.Time)
+	assert.Equal(t, 2, suite.Failures)
+
+	testCases := map[string]junitformatter.JUnitTestCase{}
+	for _, testCase := range suite.TestCases {
+		testCases[testCase.Name] = testCase
+	}
+	assert.Len(t, testCases, 5)
+	assert.Equal(t, "0.050", testCases["TestDeploy/us-east-1"].Time)
+	assert.Nil(t, testCases["TestDeploy/us-east-1"].Failure)
+	require.NotNil(t, testCases["TestDeploy/eu-west-1"].Failure)
+	assert.Contains(t, testCases["TestDeploy/eu-west-1"].Failure.Contents, "instance did not become healthy")
+	require.NotNil(t, testCases["TestUpgrade"].SkipMessage)
+	assert.Contains(t, testCases["TestUpgrade"].SkipMessage.Message, "upgrade tests are disabled")
+}
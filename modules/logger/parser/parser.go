@@ -3,18 +3,109 @@ package parser
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"os"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	junitparser "github.com/jstemmer/go-junit-report/parser"
 	"github.com/sirupsen/logrus"
+
+	terratestlogger "github.com/nholuongut/terratest/modules/logger"
+)
+
+// InputFormat is the format of the test output to parse.
+type InputFormat string
+
+const (
+	// InputFormatText is the plain output of `go test -v`.
+	InputFormatText InputFormat = "text"
+	// InputFormatTestJSON is the output of `go test -json`, which is a stream of test events (see TestEvent).
+	InputFormatTestJSON InputFormat = "json"
 )
 
-// SpawnParsers will spawn the log parser and junit report parsers off of a single reader.
+// ParseInputFormat parses the name of an input format (text or json).
+func ParseInputFormat(name string) (InputFormat, error) {
+	switch format := InputFormat(strings.ToLower(strings.TrimSpace(name))); format {
+	case InputFormatText, InputFormatTestJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid input format %q: must be one of text or json", name)
+	}
+}
+
+// Options are the options for SpawnParsersWithOptions.
+type Options struct {
+	// The format of the test output to parse. Defaults to InputFormatText.
+	InputFormat InputFormat
+
+	// The formats of the reports to store in addition to the JUnit XML report (e.g., ReportFormatHTML).
+	ReportFormats []ReportFormat
+
+	// The number of lines of the log of each failed test to show in the reports. Defaults to
+	// DefaultFailureExcerptLines.
+	FailureExcerptLines int
+
+	// Whether to follow the test output while the tests run: the output is copied to FollowOutput as it is read, and
+	// summary.log is rewritten every SummaryInterval with the results so far and the tests that are still running.
+	Follow bool
+
+	// The writer to copy the test output to when following. Defaults to os.Stdout.
+	FollowOutput io.Writer
+
+	// The interval at which summary.log is rewritten when following. Defaults to DefaultSummaryInterval.
+	SummaryInterval time.Duration
+
+	// Running tests that have produced no output for this long are flagged as silent in summary.log when following.
+	// Defaults to DefaultSilenceThreshold.
+	SilenceThreshold time.Duration
+}
+
+// SpawnParsers will spawn the log parser and junit report parsers off of a single reader, which reads the plain output
+// of `go test -v`.
 func SpawnParsers(logger *logrus.Logger, reader io.Reader, outputDir string) {
+	SpawnParsersWithOptions(logger, reader, outputDir, Options{})
+}
+
+// SpawnParsersWithOptions will spawn the log parser and junit report parsers off of a single reader, which reads test
+// output in the given input format. Once the logs are broken out by test, the reports in the given formats are stored.
+func SpawnParsersWithOptions(logger *logrus.Logger, reader io.Reader, outputDir string, options Options) {
+	var progress *progress
+	stopFollowing := func() {}
+	if options.Follow {
+		followOutput := options.FollowOutput
+		if followOutput == nil {
+			followOutput = os.Stdout
+		}
+		reader = io.TeeReader(reader, followOutput)
+
+		summaryInterval := options.SummaryInterval
+		if summaryInterval <= 0 {
+			summaryInterval = DefaultSummaryInterval
+		}
+		if options.SilenceThreshold <= 0 {
+			options.SilenceThreshold = DefaultSilenceThreshold
+		}
+		progress = newProgress()
+		stopFollowing = storeProgressPeriodically(logger, outputDir, progress, summaryInterval, options.SilenceThreshold)
+	}
+
+	if options.InputFormat == InputFormatTestJSON {
+		// Test events carry the results of the tests, so there is no need to parse the output twice.
+		parser := parseTestEvents(logger, reader, outputDir, progress)
+		stopFollowing()
+		storeSummary(logger, outputDir, parser.packages)
+		report := parser.report()
+		storeJunitReport(logger, outputDir, report)
+		storeReports(logger, outputDir, report, options.ReportFormats, options.FailureExcerptLines)
+		return
+	}
+
+	var report *junitparser.Report
+
 	forkedReader, forkedWriter := io.Pipe()
 	teedReader := io.TeeReader(reader, forkedWriter)
 	var waitForParsers sync.WaitGroup
@@ -23,11 +114,12 @@ func SpawnParsers(logger *logrus.Logger, reader io.Reader, outputDir string) {
 		// close pipe writer, because this section drains the tee reader indicating reader is done draining
 		defer forkedWriter.Close()
 		defer waitForParsers.Done()
-		parseAndStoreTestOutput(logger, teedReader, outputDir)
+		parseAndStoreTestOutput(logger, teedReader, outputDir, progress)
 	}()
 	go func() {
 		defer waitForParsers.Done()
-		report, err := junitparser.Parse(forkedReader, "")
+		var err error
+		report, err = junitparser.Parse(forkedReader, "")
 		if err == nil {
 			storeJunitReport(logger, outputDir, report)
 		} else {
@@ -35,6 +127,18 @@ func SpawnParsers(logger *logrus.Logger, reader io.Reader, outputDir string) {
 		}
 	}()
 	waitForParsers.Wait()
+
+	if options.Follow {
+		// Store the final summary, which lists the tests that were still running if the output ended before they
+		// finished (e.g., because the tests were interrupted).
+		stopFollowing()
+		storeProgress(logger, outputDir, progress, options.SilenceThreshold)
+	}
+
+	// The reports read the logs of the tests, so they can only be stored once both parsers are done.
+	if report != nil {
+		storeReports(logger, outputDir, report, options.ReportFormats, options.FailureExcerptLines)
+	}
 }
 
 // RegEx for parsing test status lines. Pulled from jstemmer/go-junit-report
@@ -97,7 +201,8 @@ func isPanicLine(text string) bool {
 }
 
 // parseAndStoreTestOutput will take test log entries from terratest and aggregate the output by test. Takes advantage
-// of the fact that terratest logs are prefixed by the test name. This will store the broken out logs into files under
+// of the fact that terratest logs are prefixed by the test name, or carry it in the test field if they were logged with
+// the JSON logger. This will store the broken out logs into files under
 // the outputDir, named by test name.
 // Additionally will take test result lines and collect them under a summary log file named `summary.log`.
 // See the `fixtures` directory for some examples.
@@ -105,10 +210,12 @@ func parseAndStoreTestOutput(
 	logger *logrus.Logger,
 	read io.Reader,
 	outputDir string,
+	progress *progress,
 ) {
 	logWriter := LogWriter{
 		lookup:    make(map[string]*os.File),
 		outputDir: outputDir,
+		progress:  progress,
 	}
 	defer logWriter.closeFiles(logger)
 
@@ -136,14 +243,23 @@ func parseAndStoreTestOutput(
 			// detected when we reach a dedented line.
 			testResultMarkers = testResultMarkers.removeDedentedTestResultMarkers(indentLevel)
 
+			entry, isJSONEntry := terratestlogger.ParseEntry(data)
+
 			// Handle each possible category of test lines
 			switch {
+			case isJSONEntry:
+				// Logged with logger.JSON, so we know the test for sure, and can write the entry in the same format as
+				// logger.DoLog for readability.
+				previousTestName = entry.Test
+				logWriter.writeLog(logger, entry.Test, entry.String())
+
 			case isSummaryLine(data):
 				logWriter.writeLog(logger, "summary", data)
 
 			case isStatusLine(data):
 				testName := getTestNameFromStatusLine(data)
 				previousTestName = testName
+				progress.testStarted(testName, time.Now())
 				logWriter.writeLog(logger, testName, data)
 
 			case strings.HasPrefix(data, "Test"):
@@ -185,6 +301,7 @@ func parseAndStoreTestOutput(
 			// hence this special block.
 			if isResultLine(data) {
 				testName := getTestNameFromResultLine(data)
+				progress.testFinished(testName)
 				logWriter.writeLog(logger, testName, data)
 				logWriter.writeLog(logger, "summary", data)
 
@@ -1,9 +1,13 @@
 package parser
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetIndent(t *testing.T) {
@@ -304,3 +308,41 @@ func TestIsPanicLine(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAndStoreTestOutputJSONEntries(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	log := strings.Join([]string{
+		"=== RUN   TestA",
+		"=== PAUSE TestA",
+		"=== RUN   TestB",
+		"=== PAUSE TestB",
+		"=== CONT  TestA",
+		"=== CONT  TestB",
+		`{"test":"TestA","time":"2024-01-02T03:04:05Z","caller":"a_test.go:10","level":"info","component":"terraform","msg":"Apply complete!\nOutputs:"}`,
+		`{"test":"TestB/sub","time":"2024-01-02T03:04:06Z","caller":"b_test.go:20","level":"info","component":"k8s","msg":"Pod is now available"}`,
+		`{"test":"TestA","time":"2024-01-02T03:04:07Z","caller":"a_test.go:11","level":"info","msg":"Done"}`,
+		"--- PASS: TestA (1.00s)",
+		"--- PASS: TestB (2.00s)",
+	}, "\n")
+
+	parseAndStoreTestOutput(NewTestLogger(t), strings.NewReader(log), dir, nil)
+
+	testALog, err := os.ReadFile(filepath.Join(dir, "TestA.log"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"=== RUN   TestA",
+		"=== PAUSE TestA",
+		"=== CONT  TestA",
+		"TestA 2024-01-02T03:04:05Z a_test.go:10: Apply complete!",
+		"Outputs:",
+		"TestA 2024-01-02T03:04:07Z a_test.go:11: Done",
+		"--- PASS: TestA (1.00s)",
+		"",
+	}, "\n"), string(testALog))
+
+	subtestLog, err := os.ReadFile(filepath.Join(dir, "TestB", "sub.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "TestB/sub 2024-01-02T03:04:06Z b_test.go:20: Pod is now available\n", string(subtestLog))
+}
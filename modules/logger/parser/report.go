@@ -0,0 +1,356 @@
+// Package logger/parser contains methods to parse and restructure log output from go testing and terratest
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	junitparser "github.com/jstemmer/go-junit-report/parser"
+	"github.com/sirupsen/logrus"
+)
+
+// ReportFormat is the format of a test report, which is stored in addition to the JUnit XML report.
+type ReportFormat string
+
+const (
+	// ReportFormatHTML is a self-contained HTML report, stored as report.html.
+	ReportFormatHTML ReportFormat = "html"
+	// ReportFormatMarkdown is a Markdown report, stored as report.md, e.g. to append to $GITHUB_STEP_SUMMARY.
+	ReportFormatMarkdown ReportFormat = "markdown"
+)
+
+// reportFiles are the names of the files the reports are stored as in the output directory.
+var reportFiles = map[ReportFormat]string{
+	ReportFormatHTML:     "report.html",
+	ReportFormatMarkdown: "report.md",
+}
+
+// ParseReportFormats parses a comma-separated list of report formats (e.g., "html,markdown").
+func ParseReportFormats(names string) ([]ReportFormat, error) {
+	formats := []ReportFormat{}
+	for _, name := range strings.Split(names, ",") {
+		format := ReportFormat(strings.ToLower(strings.TrimSpace(name)))
+		if format == "" {
+			continue
+		}
+		if _, isKnown := reportFiles[format]; !isKnown {
+			return nil, fmt.Errorf("invalid report format %q: must be one of html or markdown", name)
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// DefaultFailureExcerptLines is the default number of lines of the log of a failed test that are shown in the reports.
+const DefaultFailureExcerptLines = 20
+
+// TestReport summarizes the results of a test run for the HTML and Markdown reports.
+type TestReport struct {
+	Tests []TestCaseReport
+
+	// The total duration of the tests of all packages.
+	Duration time.Duration
+}
+
+// TestCaseReport summarizes the result of a single test or subtest.
+type TestCaseReport struct {
+	Package  string
+	Name     string
+	Status   string // PASS, FAIL or SKIP
+	Duration time.Duration
+
+	// The path of the log file of the test, relative to the output directory. Empty if the test has no log file.
+	LogFile string
+
+	// The last lines of the log of the test before it failed. Empty unless the test failed.
+	FailureExcerpt []string
+
+	// The lines of the log of the test that were logged by the retry module when an action was retried, or when it
+	// gave up.
+	RetryEvents []string
+}
+
+// Count returns the number of tests with the given status.
+func (report TestReport) Count(status string) int {
+	count := 0
+	for _, test := range report.Tests {
+		if test.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// regexRetryEvent matches the lines logged by the retry module when an action is retried, or when it gives up.
+var regexRetryEvent = regexp.MustCompile(`returned an error: .* Sleeping for \S+ and will try again\.|but this error was expected and warrants a retry|unsuccessful after \d+ retries|did not complete before timeout of|Returning due to fatal error`)
+
+// buildTestReport builds the report for the HTML and Markdown reports from the given JUnit report and the per-test
+// log files in the output directory, showing up to excerptLines lines of the log of each failed test.
+func buildTestReport(logger *logrus.Logger, junitReport *junitparser.Report, outputDir string, excerptLines int) TestReport {
+	report := TestReport{Tests: []TestCaseReport{}}
+	for _, pkg := range junitReport.Packages {
+		report.Duration += pkg.Duration
+		for _, test := range pkg.Tests {
+			testReport := TestCaseReport{
+				Package:  pkg.Name,
+				Name:     test.Name,
+				Status:   resultNames[test.Result],
+				Duration: test.Duration,
+			}
+
+			logFile := test.Name + ".log"
+			lines, err := readLines(filepath.Join(outputDir, logFile))
+			if err != nil {
+				logger.Warnf("Error reading log of test %s: %s", test.Name, err)
+			} else if lines != nil {
+				testReport.LogFile = logFile
+			}
+
+			for _, line := range lines {
+				if regexRetryEvent.MatchString(line) {
+					testReport.RetryEvents = append(testReport.RetryEvents, line)
+				}
+			}
+			if test.Result == junitparser.FAIL {
+				testReport.FailureExcerpt = failureExcerpt(lines, test.Name, excerptLines)
+			}
+
+			report.Tests = append(report.Tests, testReport)
+		}
+	}
+	return report
+}
+
+// failureExcerpt returns up to maxLines lines of the given log of a test before the line with its failure result
+// ("--- FAIL: TestName"), excluding status and result lines. If there is no such line (e.g., because the test panicked
+// or did not finish), the last lines of the log are returned. Before go 1.14, the output of a test was printed after its
+// result, so if there are no lines before the result, the lines after it are returned instead.
+func failureExcerpt(lines []string, testName string, maxLines int) []string {
+	end := len(lines)
+	for i, line := range lines {
+		if isResultLine(line) && getTestNameFromResultLine(line) == testName {
+			end = i
+			break
+		}
+	}
+
+	excerpt := []string{}
+	for i := end - 1; i >= 0 && len(excerpt) < maxLines; i-- {
+		if !isStatusLine(lines[i]) && !isResultLine(lines[i]) {
+			excerpt = append([]string{lines[i]}, excerpt...)
+		}
+	}
+	if len(excerpt) > 0 {
+		return excerpt
+	}
+	for i := end + 1; i < len(lines) && len(excerpt) < maxLines; i++ {
+		if isStatusLine(lines[i]) || isResultLine(lines[i]) {
+			break
+		}
+		excerpt = append(excerpt, lines[i])
+	}
+	return excerpt
+}
+
+// readLines returns the lines of the given file, or nil if it does not exist.
+func readLines(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// storeReports builds the report from the given JUnit report and stores it in the output directory in each of the
+// given formats.
+func storeReports(logger *logrus.Logger, outputDir string, junitReport *junitparser.Report, formats []ReportFormat, excerptLines int) {
+	if len(formats) == 0 {
+		return
+	}
+	if excerptLines <= 0 {
+		excerptLines = DefaultFailureExcerptLines
+	}
+
+	report := buildTestReport(logger, junitReport, outputDir, excerptLines)
+	for _, format := range formats {
+		render := RenderHTMLReport
+		if format == ReportFormatMarkdown {
+			render = RenderMarkdownReport
+		}
+		storeReport(logger, filepath.Join(outputDir, reportFiles[format]), report, render)
+	}
+}
+
+// storeReport renders the given report with the given renderer into the given file.
+func storeReport(logger *logrus.Logger, filename string, report TestReport, render func(io.Writer, TestReport) error) {
+	ensureDirectoryExists(logger, filepath.Dir(filename))
+	file, err := os.Create(filename)
+	if err != nil {
+		logger.Errorf("Error making file %s for report: %s", filename, err)
+		return
+	}
+	defer file.Close()
+
+	if err := render(file, report); err != nil {
+		logger.Errorf("Error rendering report %s: %s", filename, err)
+	}
+}
+
+// logFileURL returns the relative URL of the given log file, escaping each element of the path (e.g., for subtests
+// with spaces in their names).
+func logFileURL(logFile string) string {
+	elements := strings.Split(filepath.ToSlash(logFile), "/")
+	for i, element := range elements {
+		elements[i] = url.PathEscape(element)
+	}
+	return strings.Join(elements, "/")
+}
+
+// formatDuration formats a duration in seconds, like go test does.
+func formatDuration(duration time.Duration) string {
+	return fmt.Sprintf("%.2fs", duration.Seconds())
+}
+
+// RenderMarkdownReport renders the given report as Markdown, e.g. to append to $GITHUB_STEP_SUMMARY. The report
+// lists all tests, followed by the details of the tests that failed or retried an action.
+func RenderMarkdownReport(writer io.Writer, report TestReport) error {
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "## Test results\n\n")
+	fmt.Fprintf(
+		&out,
+		"**%d failed**, %d passed, %d skipped, %d total in %s\n\n",
+		report.Count("FAIL"), report.Count("PASS"), report.Count("SKIP"), len(report.Tests), formatDuration(report.Duration),
+	)
+
+	fmt.Fprintf(&out, "| Status | Test | Duration | Retries | Log |\n")
+	fmt.Fprintf(&out, "| --- | --- | --- | --- | --- |\n")
+	for _, test := range report.Tests {
+		log := ""
+		if test.LogFile != "" {
+			log = fmt.Sprintf("[%s](%s)", markdownTableCell(test.LogFile), logFileURL(test.LogFile))
+		}
+		fmt.Fprintf(
+			&out,
+			"| %s | `%s` | %s | %d | %s |\n",
+			test.Status, markdownTableCell(test.Name), formatDuration(test.Duration), len(test.RetryEvents), log,
+		)
+	}
+
+	for _, test := range report.Tests {
+		if test.Status != "FAIL" && len(test.RetryEvents) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&out, "\n### %s: `%s`\n", test.Status, test.Name)
+		if test.LogFile != "" {
+			fmt.Fprintf(&out, "\nLog: [%s](%s)\n", test.LogFile, logFileURL(test.LogFile))
+		}
+		if len(test.RetryEvents) > 0 {
+			fmt.Fprintf(&out, "\n<details><summary>Retry events (%d)</summary>\n\n", len(test.RetryEvents))
+			writeMarkdownCodeBlock(&out, test.RetryEvents)
+			fmt.Fprintf(&out, "</details>\n")
+		}
+		if len(test.FailureExcerpt) > 0 {
+			fmt.Fprintf(&out, "\nLog excerpt of the failure:\n\n")
+			writeMarkdownCodeBlock(&out, test.FailureExcerpt)
+		}
+	}
+
+	_, err := io.WriteString(writer, out.String())
+	return err
+}
+
+// markdownTableCell escapes the given text for a cell of a Markdown table.
+func markdownTableCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// writeMarkdownCodeBlock writes the given lines as a fenced code block, using a fence that is longer than any run of
+// backticks in the lines.
+func writeMarkdownCodeBlock(out *strings.Builder, lines []string) {
+	fence := "```"
+	for _, line := range lines {
+		for strings.Contains(line, fence) {
+			fence += "`"
+		}
+	}
+	fmt.Fprintf(out, "%stext\n%s\n%s\n", fence, strings.Join(lines, "\n"), fence)
+}
+
+// RenderHTMLReport renders the given report as a self-contained HTML page. The report lists all tests, with the
+// details of the tests that failed or retried an action.
+func RenderHTMLReport(writer io.Writer, report TestReport) error {
+	return htmlReportTemplate.Execute(writer, report)
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": formatDuration,
+	"logURL":   logFileURL,
+	"lower":    strings.ToLower,
+	"join":     strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Test results</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; margin: 4px 0; }
+.status { font-weight: bold; }
+.pass { color: #1a7f37; }
+.fail { color: #cf222e; }
+.skip { color: #9a6700; }
+</style>
+</head>
+<body>
+<h1>Test results</h1>
+<p>
+<span class="status fail">{{.Count "FAIL"}} failed</span>,
+<span class="status pass">{{.Count "PASS"}} passed</span>,
+<span class="status skip">{{.Count "SKIP"}} skipped</span>,
+{{len .Tests}} total in {{duration .Duration}}
+</p>
+<table>
+<tr><th>Status</th><th>Test</th><th>Duration</th><th>Details</th></tr>
+{{- range .Tests}}
+<tr>
+<td class="status {{lower .Status}}">{{.Status}}</td>
+<td>{{if .LogFile}}<a href="{{logURL .LogFile}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td>
+<td>{{duration .Duration}}</td>
+<td>
+{{- if .RetryEvents}}
+<details><summary>Retry events ({{len .RetryEvents}})</summary><pre>{{join .RetryEvents "\n"}}</pre></details>
+{{- end}}
+{{- if .FailureExcerpt}}
+<details open><summary>Log excerpt of the failure</summary><pre>{{join .FailureExcerpt "\n"}}</pre></details>
+{{- end}}
+</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
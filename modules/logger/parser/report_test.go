@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	junitparser "github.com/jstemmer/go-junit-report/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReportFormats(t *testing.T) {
+	t.Parallel()
+
+	formats, err := ParseReportFormats("html, Markdown")
+	require.NoError(t, err)
+	assert.Equal(t, []ReportFormat{ReportFormatHTML, ReportFormatMarkdown}, formats)
+
+	formats, err = ParseReportFormats("")
+	require.NoError(t, err)
+	assert.Empty(t, formats)
+
+	_, err = ParseReportFormats("html,pdf")
+	assert.Error(t, err)
+}
+
+func TestFailureExcerpt(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		lines    []string
+		expected []string
+	}{
+		{
+			"BeforeResult",
+			[]string{"=== RUN   TestA", "one", "two", "three", "--- FAIL: TestA (1.00s)", "after"},
+			[]string{"two", "three"},
+		},
+		{
+			"AfterResult",
+			[]string{"=== RUN   TestA", "--- FAIL: TestA (1.00s)", "    a_test.go:10: one", "    a_test.go:11: two", "    a_test.go:12: three", "=== RUN   TestB"},
+			[]string{"    a_test.go:10: one", "    a_test.go:11: two"},
+		},
+		{
+			"NoResult",
+			[]string{"=== RUN   TestA", "one", "two", "panic: boom"},
+			[]string{"two", "panic: boom"},
+		},
+		{
+			"SubtestResultInParentLog",
+			[]string{"=== RUN   TestA", "one", "    --- FAIL: TestA/sub (1.00s)", "two", "--- FAIL: TestA (1.00s)"},
+			[]string{"one", "two"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, failureExcerpt(testCase.lines, "TestA", 2))
+		})
+	}
+}
+
+func TestBuildTestReport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "TestA"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "TestA.log"), []byte(strings.Join([]string{
+		"=== RUN   TestA",
+		"TestA 2024-03-01T10:00:00Z retry.go:91: Wait for the load balancer returned an error: 503. Sleeping for 10s and will try again.",
+		"TestA 2024-03-01T10:00:10Z retry.go:96: 'Wait for the load balancer' unsuccessful after 3 retries. Last error: 503",
+		"TestA 2024-03-01T10:00:10Z apply.go:15: Error: load balancer is unhealthy",
+		"--- FAIL: TestA (10.00s)",
+	}, "\n")+"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "TestA", "sub.log"), []byte("=== RUN   TestA/sub\n--- PASS: TestA/sub (1.00s)\n"), 0644))
+
+	junitReport := &junitparser.Report{Packages: []junitparser.Package{{
+		Name:     "example",
+		Duration: 12 * time.Second,
+		Tests: []*junitparser.Test{
+			{Name: "TestA", Result: junitparser.FAIL, Duration: 10 * time.Second},
+			{Name: "TestA/sub", Result: junitparser.PASS, Duration: time.Second},
+			{Name: "TestB", Result: junitparser.SKIP},
+		},
+	}}}
+
+	report := buildTestReport(NewTestLogger(t), junitReport, dir, 1)
+
+	assert.Equal(t, 12*time.Second, report.Duration)
+	assert.Equal(t, 1, report.Count("FAIL"))
+	assert.Equal(t, 1, report.Count("PASS"))
+	assert.Equal(t, 1, report.Count("SKIP"))
+	require.Len(t, report.Tests, 3)
+
+	testA := report.Tests[0]
+	assert.Equal(t, "TestA.log", testA.LogFile)
+	assert.Len(t, testA.RetryEvents, 2)
+	assert.Equal(t, []string{"TestA 2024-03-01T10:00:10Z apply.go:15: Error: load balancer is unhealthy"}, testA.FailureExcerpt)
+
+	assert.Equal(t, "TestA/sub.log", report.Tests[1].LogFile)
+	assert.Empty(t, report.Tests[1].FailureExcerpt)
+	assert.Equal(t, "", report.Tests[2].LogFile)
+}
+
+var renderTestReport = TestReport{
+	Duration: 12 * time.Second,
+	Tests: []TestCaseReport{
+		{
+			Name:           "TestA",
+			Status:         "FAIL",
+			Duration:       10 * time.Second,
+			LogFile:        "TestA.log",
+			FailureExcerpt: []string{"Error: <unhealthy>"},
+			RetryEvents:    []string{"Wait returned an error: 503. Sleeping for 10s and will try again."},
+		},
+		{Name: "TestA/with space", Status: "PASS", Duration: time.Second, LogFile: "TestA/with space.log"},
+	},
+}
+
+func TestRenderMarkdownReport(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, RenderMarkdownReport(&out, renderTestReport))
+
+	expected := "## Test results\n" +
+		"\n" +
+		"**1 failed**, 1 passed, 0 skipped, 2 total in 12.00s\n" +
+		"\n" +
+		"| Status | Test | Duration | Retries | Log |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| FAIL | `TestA` | 10.00s | 1 | [TestA.log](TestA.log) |\n" +
+		"| PASS | `TestA/with space` | 1.00s | 0 | [TestA/with space.log](TestA/with%20space.log) |\n" +
+		"\n" +
+		"### FAIL: `TestA`\n" +
+		"\n" +
+		"Log: [TestA.log](TestA.log)\n" +
+		"\n" +
+		"<details><summary>Retry events (1)</summary>\n" +
+		"\n" +
+		"```text\n" +
+		"Wait returned an error: 503. Sleeping for 10s and will try again.\n" +
+		"```\n" +
+		"</details>\n" +
+		"\n" +
+		"Log excerpt of the failure:\n" +
+		"\n" +
+		"```text\n" +
+		"Error: <unhealthy>\n" +
+		"```\n"
+	assert.Equal(t, expected, out.String())
+}
+
+func TestRenderHTMLReport(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	require.NoError(t, RenderHTMLReport(&out, renderTestReport))
+
+	html := out.String()
+	assert.Contains(t, html, `<a href="TestA.log">TestA</a>`)
+	assert.Contains(t, html, `<a href="TestA/with%20space.log">TestA/with space</a>`)
+	assert.Contains(t, html, `<pre>Error: &lt;unhealthy&gt;</pre>`)
+	assert.Contains(t, html, `Retry events (1)`)
+	assert.Contains(t, html, `1 failed`)
+}
@@ -4,6 +4,7 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/nholuongut-io/go-commons/errors"
 	"github.com/nholuongut-io/go-commons/files"
@@ -16,6 +17,10 @@ type LogWriter struct {
 	// Represents an open file to a log corresponding to a test (key = test name)
 	lookup    map[string]*os.File
 	outputDir string
+
+	// Tracks the running tests while following test output, in which case summary lines are collected here instead of
+	// being written to summary.log directly, as it is rewritten periodically. Nil unless following.
+	progress *progress
 }
 
 // LogWriter.getOrCreateFile will get the corresponding file to a log for the provided test name, or create a new file.
@@ -47,6 +52,14 @@ func (logWriter LogWriter) closeFiles(logger *logrus.Logger) {
 
 // writeLog will write the provided text to the corresponding log file for the provided test.
 func (logWriter LogWriter) writeLog(logger *logrus.Logger, testName string, text string) error {
+	if logWriter.progress != nil {
+		if testName == "summary" {
+			logWriter.progress.addSummaryLine(text)
+			return nil
+		}
+		logWriter.progress.testOutput(testName, time.Now())
+	}
+
 	file, err := logWriter.getOrCreateFile(logger, testName)
 	if err != nil {
 		logger.Errorf("Error retrieving log for test: %s", testName)
@@ -0,0 +1,374 @@
+// Package logger/parser contains methods to parse and restructure log output from go testing and terratest
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	junitparser "github.com/jstemmer/go-junit-report/parser"
+	"github.com/sirupsen/logrus"
+
+	terratestlogger "github.com/nholuongut/terratest/modules/logger"
+)
+
+// TestEvent is an event in the output of `go test -json`, as emitted by test2json. See `go doc test2json` for the
+// meaning of each field.
+type TestEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64 // seconds
+	Output  string
+}
+
+// parseTestEvent parses a line of `go test -json` output. It returns false if the line is not an event (e.g., if it is
+// output of the go tool, such as a build error).
+func parseTestEvent(line string) (TestEvent, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return TestEvent{}, false
+	}
+
+	var event TestEvent
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Action == "" {
+		return TestEvent{}, false
+	}
+	return event, true
+}
+
+// maxTestOutputLines is the number of lines of output of each test that is kept for the JUnit report, counting from
+// the end, as the last lines are the most relevant for a failure.
+const maxTestOutputLines = 100
+
+// testRun tracks the state of a single test or subtest while parsing test events.
+type testRun struct {
+	Name     string
+	Started  time.Time
+	LastSeen time.Time
+	Finished bool
+	Result   junitparser.Result
+	Duration time.Duration
+
+	// The last lines of output of the test, excluding the lines that go test prints when a test starts, pauses,
+	// continues or finishes.
+	Output []string
+
+	// Output that was not terminated with a newline yet. test2json splits long lines.
+	partialOutput string
+}
+
+// packageRun tracks the state of the tests of a single package while parsing test events.
+type packageRun struct {
+	Name     string
+	Tests    []*testRun
+	Finished bool
+	Failed   bool
+	Duration time.Duration
+
+	// The output that does not belong to any test (e.g., "FAIL" or a panic).
+	Output []string
+
+	testsByName map[string]*testRun
+}
+
+// testEventParser attributes the output in test events to tests, and tracks the results of the tests.
+type testEventParser struct {
+	logger    *logrus.Logger
+	logWriter LogWriter
+
+	packages       []*packageRun
+	packagesByName map[string]*packageRun
+}
+
+func newTestEventParser(logger *logrus.Logger, outputDir string, progress *progress) *testEventParser {
+	return &testEventParser{
+		logger: logger,
+		logWriter: LogWriter{
+			lookup:    make(map[string]*os.File),
+			outputDir: outputDir,
+			progress:  progress,
+		},
+		packagesByName: map[string]*packageRun{},
+	}
+}
+
+// parseTestEvents will take the output of `go test -json` and aggregate the output by test, like
+// parseAndStoreTestOutput does for plain test output. Unlike plain test output, test events name the test that wrote
+// each line of output, and carry the elapsed time of each test. Output logged by terratest is attributed to the test
+// named in the log entry instead, as go test can't tell which parallel test wrote to stdout. Once all events are read,
+// this returns the parser with the results of the tests, to store the summary (see storeSummary) and the JUnit report
+// (see testEventParser.report). If progress is not nil, the running tests and the results so far are tracked in it
+// while following the test output.
+func parseTestEvents(logger *logrus.Logger, read io.Reader, outputDir string, progress *progress) *testEventParser {
+	parser := newTestEventParser(logger, outputDir, progress)
+	defer parser.logWriter.closeFiles(logger)
+
+	reader := bufio.NewReader(read)
+	for {
+		data, err := reader.ReadString('\n')
+		if len(data) > 0 {
+			parser.handleLine(strings.TrimSuffix(data, "\n"))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Fatalf("Error reading from Reader: %s", err)
+		}
+	}
+
+	parser.finish(time.Now())
+	return parser
+}
+
+// handleLine handles a single line of `go test -json` output.
+func (parser *testEventParser) handleLine(line string) {
+	event, isEvent := parseTestEvent(line)
+	if !isEvent {
+		// Output of the go tool that is not wrapped in an event, such as build errors when stderr is redirected to
+		// stdout, so it doesn't belong to any package either.
+		if strings.TrimSpace(line) != "" {
+			pkg := parser.getOrCreatePackage("")
+			pkg.Output = append(pkg.Output, line)
+			parser.logWriter.progress.addSummaryLine(line)
+		}
+		return
+	}
+	parser.handleEvent(event)
+}
+
+// handleEvent updates the state of the tests with the given event, and writes its output to the log of the test.
+func (parser *testEventParser) handleEvent(event TestEvent) {
+	pkg := parser.getOrCreatePackage(event.Package)
+
+	if event.Test == "" {
+		switch event.Action {
+		case "output":
+			line := strings.TrimSuffix(event.Output, "\n")
+			pkg.Output = append(pkg.Output, line)
+			parser.logWriter.progress.addSummaryLine(line)
+		case "pass", "fail", "skip":
+			pkg.Finished = true
+			pkg.Failed = event.Action == "fail"
+			pkg.Duration = elapsedDuration(event.Elapsed)
+		}
+		return
+	}
+
+	test := pkg.getOrCreateTest(event.Test, event.Time)
+	test.LastSeen = event.Time
+
+	switch event.Action {
+	case "run":
+		parser.logWriter.progress.testStarted(test.Name, time.Now())
+	case "output":
+		parser.handleOutput(pkg, test, event.Output)
+	case "pass", "fail", "skip":
+		parser.flushPartialOutput(pkg, test)
+		test.Finished = true
+		test.Result = actionResults[event.Action]
+		test.Duration = elapsedDuration(event.Elapsed)
+		parser.logWriter.progress.testFinished(test.Name)
+		parser.logWriter.progress.addSummaryLine(formatTestResult(test))
+	}
+}
+
+// actionResults maps the actions of test events that end a test to the result of the test.
+var actionResults = map[string]junitparser.Result{
+	"pass": junitparser.PASS,
+	"fail": junitparser.FAIL,
+	"skip": junitparser.SKIP,
+}
+
+// handleOutput handles output that test2json attributed to the given test, joining lines that it split.
+func (parser *testEventParser) handleOutput(pkg *packageRun, test *testRun, output string) {
+	output = test.partialOutput + output
+	test.partialOutput = ""
+
+	lines := strings.Split(output, "\n")
+	// The last element is empty if the output ends with a newline, or else a line that is continued in the next event.
+	test.partialOutput = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		parser.handleOutputLine(pkg, test, line)
+	}
+}
+
+// flushPartialOutput handles the output of the given test that was not terminated with a newline.
+func (parser *testEventParser) flushPartialOutput(pkg *packageRun, test *testRun) {
+	if test.partialOutput != "" {
+		line := test.partialOutput
+		test.partialOutput = ""
+		parser.handleOutputLine(pkg, test, line)
+	}
+}
+
+// handleOutputLine writes the given line of output to the log of the test that wrote it, which is the given test
+// unless the line was logged by terratest for another test of the package.
+func (parser *testEventParser) handleOutputLine(pkg *packageRun, test *testRun, line string) {
+	if entry, isJSONEntry := terratestlogger.ParseEntry(line); isJSONEntry {
+		line = entry.String()
+		if owner, hasOwner := pkg.testsByName[entry.Test]; hasOwner {
+			test = owner
+		}
+	} else if owner, hasOwner := pkg.testsByName[strings.SplitN(line, " ", 2)[0]]; hasOwner {
+		// Heuristic: logger.DoLog prefixes the output with the name of the test, which can't contain spaces.
+		test = owner
+	}
+
+	parser.logWriter.writeLog(parser.logger, test.Name, line)
+	if !isStatusLine(line) && !isResultLine(line) {
+		test.Output = appendTail(test.Output, line, maxTestOutputLines)
+	}
+}
+
+// finish marks the tests that did not finish (e.g., because the test binary timed out or crashed) as failed, as of
+// the given time.
+func (parser *testEventParser) finish(now time.Time) {
+	for _, pkg := range parser.packages {
+		for _, test := range pkg.Tests {
+			parser.flushPartialOutput(pkg, test)
+			if !test.Finished {
+				test.Result = junitparser.FAIL
+				test.Duration = test.LastSeen.Sub(test.Started)
+			}
+		}
+	}
+}
+
+// report returns the results of the tests as a report for JUnit XML.
+func (parser *testEventParser) report() *junitparser.Report {
+	report := &junitparser.Report{Packages: []junitparser.Package{}}
+	for _, pkg := range parser.packages {
+		if pkg.Name == "" {
+			continue
+		}
+
+		junitPackage := junitparser.Package{
+			Name:     pkg.Name,
+			Duration: pkg.Duration,
+			Time:     int(pkg.Duration / time.Millisecond),
+			Tests:    []*junitparser.Test{},
+		}
+		for _, test := range pkg.Tests {
+			output := test.Output
+			if !test.Finished {
+				output = append(output, "Test did not finish")
+			}
+			junitPackage.Tests = append(junitPackage.Tests, &junitparser.Test{
+				Name:     test.Name,
+				Duration: test.Duration,
+				Time:     int(test.Duration / time.Millisecond),
+				Result:   test.Result,
+				Output:   output,
+			})
+		}
+		report.Packages = append(report.Packages, junitPackage)
+	}
+	return report
+}
+
+func (parser *testEventParser) getOrCreatePackage(name string) *packageRun {
+	pkg, hasPackage := parser.packagesByName[name]
+	if !hasPackage {
+		pkg = &packageRun{Name: name, testsByName: map[string]*testRun{}}
+		parser.packagesByName[name] = pkg
+		parser.packages = append(parser.packages, pkg)
+	}
+	return pkg
+}
+
+func (pkg *packageRun) getOrCreateTest(name string, started time.Time) *testRun {
+	test, hasTest := pkg.testsByName[name]
+	if !hasTest {
+		test = &testRun{Name: name, Started: started, LastSeen: started}
+		pkg.testsByName[name] = test
+		pkg.Tests = append(pkg.Tests, test)
+	}
+	return test
+}
+
+// storeSummary stores a summary of the results of the tests of the given packages as summary.log in the output
+// directory. Subtests are listed under their parent test, indented like go test does.
+func storeSummary(logger *logrus.Logger, outputDir string, packages []*packageRun) {
+	lines := []string{}
+	for _, pkg := range packages {
+		for _, test := range sortTestsByParent(pkg.Tests) {
+			lines = append(lines, formatTestResult(test))
+		}
+		lines = append(lines, pkg.Output...)
+	}
+
+	ensureDirectoryExists(logger, outputDir)
+	filename := filepath.Join(outputDir, "summary.log")
+	if err := os.WriteFile(filename, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		logger.Errorf("Error writing summary %s: %s", filename, err)
+	}
+}
+
+// formatTestResult formats the result of the given test like go test does (e.g., "--- PASS: TestFoo (1.23s)").
+func formatTestResult(test *testRun) string {
+	indent := strings.Repeat("    ", strings.Count(test.Name, "/"))
+	if !test.Finished {
+		return fmt.Sprintf("%s--- FAIL: %s (%.2fs, did not finish)", indent, test.Name, test.Duration.Seconds())
+	}
+	return fmt.Sprintf("%s--- %s: %s (%.2fs)", indent, resultNames[test.Result], test.Name, test.Duration.Seconds())
+}
+
+var resultNames = map[junitparser.Result]string{
+	junitparser.PASS: "PASS",
+	junitparser.FAIL: "FAIL",
+	junitparser.SKIP: "SKIP",
+}
+
+// sortTestsByParent orders the given tests, which are in the order they started, so that subtests follow their parent
+// test.
+func sortTestsByParent(tests []*testRun) []*testRun {
+	children := map[string][]*testRun{}
+	roots := []*testRun{}
+	names := map[string]bool{}
+	for _, test := range tests {
+		names[test.Name] = true
+	}
+	for _, test := range tests {
+		parent := test.Name[:max(strings.LastIndex(test.Name, "/"), 0)]
+		if parent != "" && names[parent] {
+			children[parent] = append(children[parent], test)
+		} else {
+			roots = append(roots, test)
+		}
+	}
+
+	sorted := []*testRun{}
+	var visit func(test *testRun)
+	visit = func(test *testRun) {
+		sorted = append(sorted, test)
+		for _, child := range children[test.Name] {
+			visit(child)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return sorted
+}
+
+// appendTail appends the given line to the given lines, dropping the first lines to keep at most maxLines.
+func appendTail(lines []string, line string, maxLines int) []string {
+	lines = append(lines, line)
+	if len(lines) > maxLines {
+		lines = append(lines[:0], lines[len(lines)-maxLines:]...)
+	}
+	return lines
+}
+
+// elapsedDuration converts the elapsed time of a test event, in seconds, to a duration.
+func elapsedDuration(elapsed float64) time.Duration {
+	return time.Duration(elapsed * float64(time.Second))
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	junitparser "github.com/jstemmer/go-junit-report/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTestEvent(t *testing.T) {
+	t.Parallel()
+
+	event, isEvent := parseTestEvent(`{"Time":"2024-03-01T10:00:00Z","Action":"pass","Package":"example","Test":"TestFoo","Elapsed":1.5}`)
+	require.True(t, isEvent)
+	assert.Equal(t, "pass", event.Action)
+	assert.Equal(t, "example", event.Package)
+	assert.Equal(t, "TestFoo", event.Test)
+	assert.Equal(t, 1.5, event.Elapsed)
+
+	_, isEvent = parseTestEvent("# example [build failed]")
+	assert.False(t, isEvent)
+	_, isEvent = parseTestEvent(`{"test":"TestFoo","msg":"not a test event"}`)
+	assert.False(t, isEvent)
+}
+
+func TestParseTestEventsMarksUnfinishedTestsAsFailed(t *testing.T) {
+	t.Parallel()
+
+	events := strings.Join([]string{
+		`{"Time":"2024-03-01T10:00:00Z","Action":"run","Package":"example","Test":"TestHangs"}`,
+		`{"Time":"2024-03-01T10:00:30Z","Action":"output","Package":"example","Test":"TestHangs","Output":"waiting for the load balancer\n"}`,
+		`{"Time":"2024-03-01T10:00:30Z","Action":"output","Package":"example","Output":"panic: test timed out after 30s\n"}`,
+		`{"Time":"2024-03-01T10:00:31Z","Action":"fail","Package":"example","Elapsed":31}`,
+	}, "\n")
+
+	dir := t.TempDir()
+	logger := NewTestLogger(t)
+	parser := parseTestEvents(logger, strings.NewReader(events), dir, nil)
+	storeSummary(logger, dir, parser.packages)
+	report := parser.report()
+
+	require.Len(t, report.Packages, 1)
+	require.Len(t, report.Packages[0].Tests, 1)
+	test := report.Packages[0].Tests[0]
+	assert.Equal(t, junitparser.FAIL, test.Result)
+	assert.Equal(t, "30s", test.Duration.String())
+	assert.Equal(t, []string{"waiting for the load balancer", "Test did not finish"}, test.Output)
+
+	summary, err := os.ReadFile(filepath.Join(dir, "summary.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "--- FAIL: TestHangs (30.00s, did not finish)\npanic: test timed out after 30s\n", string(summary))
+}
+
+func TestSortTestsByParent(t *testing.T) {
+	t.Parallel()
+
+	tests := []*testRun{{Name: "TestA"}, {Name: "TestB"}, {Name: "TestA/x"}, {Name: "TestB/y"}, {Name: "TestA/x/z"}}
+	names := []string{}
+	for _, test := range sortTestsByParent(tests) {
+		names = append(names, test.Name)
+	}
+	assert.Equal(t, []string{"TestA", "TestA/x", "TestA/x/z", "TestB", "TestB/y"}, names)
+}
+
+func TestAppendTail(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{}
+	for _, line := range []string{"a", "b", "c", "d"} {
+		lines = appendTail(lines, line, 3)
+	}
+	assert.Equal(t, []string{"b", "c", "d"}, lines)
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nholuongut/terratest/modules/testing"
+)
+
+// RedactedText is the text that registered secrets are replaced with in log output.
+const RedactedText = "[REDACTED]"
+
+// secrets is the registry of the values that are redacted from all log output.
+var secrets = struct {
+	sync.RWMutex
+	values   map[string]bool
+	replacer *strings.Replacer
+}{values: map[string]bool{}}
+
+// MinSecretLength is the minimum length of a secret. Shorter values are not registered, as redacting them would garble
+// unrelated log output.
+const MinSecretLength = 4
+
+// minSecretLineLength is the minimum length of a line of a multi-line secret (e.g., a private key) for the line to be
+// redacted on its own. Shorter lines, such as blank lines or "}", are too common to redact.
+const minSecretLineLength = 16
+
+// RegisterSecret registers the given value as a secret (e.g., a password or a token), so that it is replaced with
+// RedactedText in all log output from now on, by every logger backend. The base64-encoded and JSON-escaped forms of the
+// value are redacted too, as well as each line of a multi-line value, as commands typically log their output line by
+// line. Secrets stay registered after the test completes, as they may still be logged by other tests. Values shorter
+// than MinSecretLength are ignored.
+func RegisterSecret(t testing.TestingT, value string) {
+	if tt, ok := t.(helper); ok {
+		tt.Helper()
+	}
+
+	forms := secretForms(value)
+	if len(forms) == 0 {
+		return
+	}
+
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	added := false
+	for _, form := range forms {
+		if !secrets.values[form] {
+			secrets.values[form] = true
+			added = true
+		}
+	}
+	if !added {
+		return
+	}
+
+	// Replace longer secrets first, so that a secret that contains another one is redacted as a whole.
+	values := make([]string, 0, len(secrets.values))
+	for value := range secrets.values {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+
+	oldNew := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		oldNew = append(oldNew, value, RedactedText)
+	}
+	secrets.replacer = strings.NewReplacer(oldNew...)
+}
+
+// secretForms returns the forms of the given secret to redact: the value itself, its base64-encoded and JSON-escaped
+// forms, and its lines if it spans multiple lines.
+func secretForms(value string) []string {
+	forms := []string{}
+	add := func(form string, minLength int) {
+		if len(form) >= minLength {
+			forms = append(forms, form)
+		}
+	}
+
+	for _, trimmed := range []string{value, strings.TrimSpace(value)} {
+		add(trimmed, MinSecretLength)
+		if len(trimmed) < MinSecretLength {
+			continue
+		}
+		for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			add(encoding.EncodeToString([]byte(trimmed)), MinSecretLength)
+		}
+		if escaped, err := json.Marshal(trimmed); err == nil {
+			add(strings.TrimSuffix(strings.TrimPrefix(string(escaped), `"`), `"`), MinSecretLength)
+		}
+	}
+
+	if strings.Contains(value, "\n") {
+		for _, line := range strings.Split(value, "\n") {
+			add(strings.TrimSpace(line), minSecretLineLength)
+		}
+	}
+	return forms
+}
+
+// Redact replaces all registered secrets in the given text with RedactedText.
+func Redact(text string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	if secrets.replacer == nil {
+		return text
+	}
+	return secrets.replacer.Replace(text)
+}
+
+// hasSecrets returns true if any secrets are registered.
+func hasSecrets() bool {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	return secrets.replacer != nil
+}
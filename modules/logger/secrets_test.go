@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nholuongut/terratest/modules/random"
+)
+
+func TestRedactRegisteredSecrets(t *testing.T) {
+	t.Parallel()
+
+	secret := "secret-" + random.UniqueId()
+	longerSecret := secret + "-and-more"
+	RegisterSecret(t, secret)
+	RegisterSecret(t, longerSecret)
+	RegisterSecret(t, "")
+
+	assert.Equal(t, "password is [REDACTED], token is [REDACTED]", Redact("password is "+secret+", token is "+longerSecret))
+	assert.Equal(t, "nothing to hide", Redact("nothing to hide"))
+}
+
+func TestRedactEncodedAndMultiLineSecrets(t *testing.T) {
+	t.Parallel()
+
+	secret := "secret-" + random.UniqueId()
+	RegisterSecret(t, secret)
+	assert.Equal(t, "data: [REDACTED]", Redact("data: "+base64.StdEncoding.EncodeToString([]byte(secret))))
+	assert.Equal(t, "data: [REDACTED]", Redact("data: "+base64.RawURLEncoding.EncodeToString([]byte(secret))))
+
+	firstLine := "first-line-" + random.UniqueId()
+	secondLine := "second-line-" + random.UniqueId()
+	multiLineSecret := firstLine + "\n}\n" + secondLine + "\n"
+	RegisterSecret(t, multiLineSecret)
+	assert.Equal(t, "[REDACTED]", Redact(multiLineSecret))
+	assert.Equal(t, "key: [REDACTED]", Redact("key: "+secondLine))
+	assert.Equal(t, `{"key": "[REDACTED]"}`, Redact(`{"key": "`+firstLine+`\n}\n`+secondLine+`\n"}`))
+	assert.Equal(t, "}", Redact("}"))
+	assert.Equal(t, "[REDACTED]", Redact(base64.StdEncoding.EncodeToString([]byte(multiLineSecret))))
+}
+
+func TestRegisterSecretIgnoresShortValues(t *testing.T) {
+	t.Parallel()
+
+	RegisterSecret(t, "abc")
+	assert.Equal(t, "abc", Redact("abc"))
+}
+
+func TestLoggersRedactRegisteredSecrets(t *testing.T) {
+	t.Parallel()
+
+	secret := "secret-" + random.UniqueId()
+	RegisterSecret(t, secret)
+
+	c := &customLogger{}
+	New(c).Logf(t, "the password is %s", secret)
+	assert.Equal(t, []string{"the password is [REDACTED]"}, c.logs)
+
+	var buffer bytes.Buffer
+	DoLog(t, 1, &buffer, "the password is", secret)
+	assert.Contains(t, buffer.String(), "the password is [REDACTED]\n")
+	assert.NotContains(t, buffer.String(), secret)
+
+	buffer.Reset()
+	New(JSONLogger{Writer: &buffer}).Logf(t, "the password is %s", secret)
+	entry, isEntry := ParseEntry(buffer.String())
+	assert.True(t, isEntry)
+	assert.Equal(t, "the password is [REDACTED]", entry.Message)
+}
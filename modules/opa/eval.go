@@ -1,6 +1,7 @@
 package opa
 
 import (
+	"context"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -25,6 +26,9 @@ type EvalOptions struct {
 	// Set a logger that should be used. See the logger package for more info.
 	Logger *logger.Logger
 
+	// If set, running opa commands are interrupted (and eventually killed) when the context is done.
+	Context context.Context
+
 	// The following options can be used to change the behavior of the related functions for debuggability.
 
 	// When true, keep any temp files and folders that are created for the purpose of running opa eval.
@@ -103,7 +107,8 @@ func asyncEval(
 
 		// Do not log output from shell package so we can log the full json without breaking it up. This is ok, because
 		// opa eval is typically very quick.
-		Logger: logger.Discard,
+		Logger:  logger.Discard,
+		Context: options.Context,
 	}
 	err := runCommandWithFullLoggingE(t, options.Logger, cmd)
 	ruleBasePath := filepath.Base(downloadedPolicyPath)
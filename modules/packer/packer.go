@@ -2,6 +2,7 @@
 package packer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -31,9 +32,11 @@ type Options struct {
 	RetryableErrors            map[string]string // If packer build fails with one of these (transient) errors, retry. The keys are a regexp to match against the error and the message is what to display to a user if that error is matched.
 	MaxRetries                 int               // Maximum number of times to retry errors matching RetryableErrors
 	TimeBetweenRetries         time.Duration     // The amount of time to wait between retries
+	RetryPolicy                *retry.Policy     // If set, retry errors matching RetryableErrors as configured by this policy (e.g., with exponential backoff) instead of MaxRetries and TimeBetweenRetries
 	WorkingDir                 string            // The directory to run packer in
 	Logger                     *logger.Logger    // If set, use a non-default logger
 	DisableTemporaryPluginPath bool              // If set, do not use a temporary directory for Packer plugins.
+	Context                    context.Context   // If set, running Packer commands are interrupted (and eventually killed) when the context is done.
 }
 
 // BuildArtifacts can take a map of identifierName <-> Options and then parallelize
@@ -124,10 +127,11 @@ func BuildArtifactE(t testing.TestingT, options *Options) (string, error) {
 		Args:       formatPackerArgs(options),
 		Env:        options.Env,
 		WorkingDir: options.WorkingDir,
+		Context:    options.Context,
 	}
 
 	description := fmt.Sprintf("%s %v", cmd.Command, cmd.Args)
-	output, err := retry.DoWithRetryableErrorsE(t, description, options.RetryableErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
+	output, err := retryCommandE(t, options, description, func() (string, error) {
 		return shell.RunCommandAndGetOutputE(t, cmd)
 	})
 
@@ -185,6 +189,7 @@ func hasPackerInit(t testing.TestingT, options *Options) (bool, error) {
 		Args:       []string{"-version"},
 		Env:        options.Env,
 		WorkingDir: options.WorkingDir,
+		Context:    options.Context,
 	}
 	versionCmdOutput, err := shell.RunCommandAndGetOutputE(t, cmd)
 	if err != nil {
@@ -225,10 +230,11 @@ func packerInit(t testing.TestingT, options *Options) error {
 		Args:       []string{"init", options.Template},
 		Env:        options.Env,
 		WorkingDir: options.WorkingDir,
+		Context:    options.Context,
 	}
 
 	description := "Running Packer init"
-	_, err = retry.DoWithRetryableErrorsE(t, description, options.RetryableErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
+	_, err = retryCommandE(t, options, description, func() (string, error) {
 		return shell.RunCommandAndGetOutputE(t, cmd)
 	})
 
@@ -273,3 +279,17 @@ func trimPackerVersion(versionCmdOutput string) string {
 	}
 	return ""
 }
+
+// retryCommandE runs the given action, retrying errors matching options.RetryableErrors as configured by
+// options.RetryPolicy, or by options.MaxRetries and options.TimeBetweenRetries if no policy is set.
+func retryCommandE(t testing.TestingT, options *Options, description string, action func() (string, error)) (string, error) {
+	if options.RetryPolicy == nil {
+		return retry.DoWithRetryableErrorsE(t, description, options.RetryableErrors, options.MaxRetries, options.TimeBetweenRetries, action)
+	}
+
+	policy := *options.RetryPolicy
+	if policy.Context == nil {
+		policy.Context = options.Context
+	}
+	return retry.DoWithRetryableErrorsAndPolicyE(t, description, options.RetryableErrors, &policy, action)
+}
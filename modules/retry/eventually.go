@@ -0,0 +1,61 @@
+package retry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nholuongut/terratest/modules/logger"
+	"github.com/nholuongut/terratest/modules/testing"
+)
+
+// Eventually polls the given check, as configured by the given policy, until it reports that it is done, and returns
+// the value it returned. The check returns the current value (e.g., the status of a resource), whether it is done, and
+// an error. Errors are retried like values that are not done, unless they are a FatalError. The progress (number of
+// checks, elapsed time and last value) is logged on every check that is not done. This will fail the test if the
+// check returns a FatalError or if the policy is exhausted. For example:
+//
+//	status := retry.Eventually(t, "Wait for the cluster to be active", policy, func() (string, bool, error) {
+//		status, err := getClusterStatus()
+//		return status, status == "ACTIVE", err
+//	})
+func Eventually[T any](t testing.TestingT, description string, policy *Policy, check func() (T, bool, error)) T {
+	out, err := EventuallyE(t, description, policy, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// EventuallyE polls the given check, as configured by the given policy, until it reports that it is done, and returns
+// the value it returned. The check returns the current value (e.g., the status of a resource), whether it is done, and
+// an error. Errors are retried like values that are not done, unless they are a FatalError, which is returned
+// immediately. The progress (number of checks, elapsed time and last value) is logged on every check that is not done.
+// See DoWithPolicyE for the errors returned if the policy is exhausted; values that were not done are recorded as
+// ConditionNotMet errors.
+func EventuallyE[T any](t testing.TestingT, description string, policy *Policy, check func() (T, bool, error)) (T, error) {
+	start := time.Now()
+	checks := 0
+
+	return DoWithPolicyE(t, description, policy, func() (T, error) {
+		checks++
+		value, done, err := check()
+		if err != nil {
+			return value, err
+		}
+		if !done {
+			logger.Default.Logf(t, "%s: not done after %d checks in %s. Last value: %v", description, checks, time.Since(start).Round(time.Millisecond), value)
+			return value, ConditionNotMet{Description: description, Value: value}
+		}
+		return value, nil
+	})
+}
+
+// ConditionNotMet is an error that records a value for which the check of Eventually reported that it was not done.
+type ConditionNotMet struct {
+	Description string
+	Value       interface{}
+}
+
+func (err ConditionNotMet) Error() string {
+	return fmt.Sprintf("'%s' not done yet. Last value: %v", err.Description, err.Value)
+}
@@ -0,0 +1,72 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventuallyReturnsValueWhenDone(t *testing.T) {
+	t.Parallel()
+
+	statuses := []string{"PENDING", "CREATING", "ACTIVE"}
+	count := 0
+	status, err := EventuallyE(t, t.Name(), ConstantPolicy(5, 1*time.Millisecond), func() (string, bool, error) {
+		status := statuses[count]
+		count++
+		return status, status == "ACTIVE", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ACTIVE", status)
+	assert.Equal(t, 3, count)
+}
+
+func TestEventuallyRetriesErrors(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	value, err := EventuallyE(t, t.Name(), ConstantPolicy(5, 1*time.Millisecond), func() (int, bool, error) {
+		count++
+		if count < 3 {
+			return 0, false, fmt.Errorf("attempt %d failed", count)
+		}
+		return count, true, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, value)
+}
+
+func TestEventuallyStopsOnFatalError(t *testing.T) {
+	t.Parallel()
+
+	fatalErr := FatalError{Underlying: fmt.Errorf("resource was deleted")}
+	count := 0
+	_, err := EventuallyE(t, t.Name(), ConstantPolicy(5, 1*time.Millisecond), func() (string, bool, error) {
+		count++
+		return "DELETED", false, fatalErr
+	})
+	assert.Equal(t, fatalErr, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestEventuallyRecordsValuesWhenExhausted(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	status, err := EventuallyE(t, t.Name(), ConstantPolicy(2, 1*time.Millisecond), func() (string, bool, error) {
+		count++
+		return fmt.Sprintf("PENDING-%d", count), false, nil
+	})
+	assert.Equal(t, "PENDING-3", status)
+
+	var maxRetriesExceeded MaxRetriesExceeded
+	assert.True(t, errors.As(err, &maxRetriesExceeded))
+	assert.Equal(t, []error{
+		ConditionNotMet{Description: t.Name(), Value: "PENDING-1"},
+		ConditionNotMet{Description: t.Name(), Value: "PENDING-2"},
+		ConditionNotMet{Description: t.Name(), Value: "PENDING-3"},
+	}, maxRetriesExceeded.Errors)
+}
@@ -0,0 +1,241 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nholuongut/terratest/modules/logger"
+	"github.com/nholuongut/terratest/modules/testing"
+)
+
+// DefaultMultiplier is the factor by which the interval between retries grows after each retry if the Multiplier of a
+// Policy is not set.
+const DefaultMultiplier = 2.0
+
+// Policy configures how an action is retried: how often, how long to sleep between attempts, and for how long in
+// total. For example, to retry a call to a throttled API with exponential backoff and full jitter for up to 5 minutes:
+//
+//	policy := &retry.Policy{
+//		MaxRetries:      -1,
+//		InitialInterval: 1 * time.Second,
+//		MaxInterval:     30 * time.Second,
+//		Jitter:          true,
+//		MaxElapsedTime:  5 * time.Minute,
+//	}
+//	out := retry.DoWithPolicy(t, "Describe instances", policy, action)
+//
+// Use ConstantPolicy for the fixed number of retries and fixed sleep of DoWithRetry.
+type Policy struct {
+	// The maximum number of retries after the first attempt. Use a negative value for no limit, in which case
+	// MaxElapsedTime or a deadline on Context should be set.
+	MaxRetries int
+
+	// The interval to sleep for before the first retry.
+	InitialInterval time.Duration
+
+	// The factor by which the interval grows after each retry. Defaults to DefaultMultiplier if zero. Use 1 for a
+	// constant interval.
+	Multiplier float64
+
+	// The maximum interval to sleep for between retries. Zero means no limit.
+	MaxInterval time.Duration
+
+	// If true, the time to sleep before each retry is picked uniformly at random between zero and the interval ("full
+	// jitter"), so that concurrent tests don't retry in lockstep.
+	Jitter bool
+
+	// The maximum total time from the start of the first attempt after which no more attempts are started. Zero means no
+	// limit.
+	MaxElapsedTime time.Duration
+
+	// If set, no more attempts are started once the context is done, and sleeping between retries is interrupted.
+	Context context.Context `json:"-"`
+}
+
+// ConstantPolicy returns a Policy that retries up to maxRetries times, sleeping for sleepBetweenRetries between
+// retries, as DoWithRetry does.
+func ConstantPolicy(maxRetries int, sleepBetweenRetries time.Duration) *Policy {
+	return &Policy{MaxRetries: maxRetries, InitialInterval: sleepBetweenRetries, Multiplier: 1}
+}
+
+// Interval returns the interval to sleep for before the given retry (starting at 1), without jitter.
+func (policy *Policy) Interval(retry int) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier == 0 {
+		multiplier = DefaultMultiplier
+	}
+
+	interval := float64(policy.InitialInterval) * math.Pow(multiplier, float64(retry-1))
+	if policy.MaxInterval > 0 && interval > float64(policy.MaxInterval) {
+		return policy.MaxInterval
+	}
+	if interval > float64(math.MaxInt64) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(interval)
+}
+
+// sleepBefore returns the time to sleep for before the given retry, with jitter if configured.
+func (policy *Policy) sleepBefore(retry int) time.Duration {
+	interval := policy.Interval(retry)
+	if policy.Jitter && interval > 0 {
+		return time.Duration(rand.Int63n(int64(interval) + 1))
+	}
+	return interval
+}
+
+// DoWithPolicy runs the specified action. If it returns a value, return that value. If it returns a FatalError, fail
+// the test immediately. If it returns any other type of error, retry it as configured by the given policy. If the
+// policy is exhausted, fail the test.
+func DoWithPolicy[T any](t testing.TestingT, actionDescription string, policy *Policy, action func() (T, error)) T {
+	out, err := DoWithPolicyE(t, actionDescription, policy, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DoWithPolicyE runs the specified action. If it returns a value, return that value. If it returns a FatalError,
+// return that error immediately. If it returns any other type of error, retry it as configured by the given policy.
+// If Policy.MaxRetries is exceeded, return a MaxRetriesExceeded error, and if Policy.MaxElapsedTime is exceeded or
+// Policy.Context is done, return a RetryBudgetExceeded error. Both carry the errors of all attempts.
+func DoWithPolicyE[T any](t testing.TestingT, actionDescription string, policy *Policy, action func() (T, error)) (T, error) {
+	ctx := policy.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	start := time.Now()
+
+	var output T
+	errs := []error{}
+	for retry := 0; ; retry++ {
+		if retry > 0 {
+			sleep := policy.sleepBefore(retry)
+			if policy.MaxElapsedTime > 0 {
+				remaining := policy.MaxElapsedTime - time.Since(start)
+				if remaining <= 0 {
+					return output, exhausted(t, RetryBudgetExceeded{Description: actionDescription, Elapsed: time.Since(start), Errors: errs})
+				}
+				if sleep > remaining {
+					sleep = remaining
+				}
+			}
+
+			logger.Default.Debugf(t, "%s returned an error: %s. Sleeping for %s and will try again.", actionDescription, errs[len(errs)-1].Error(), sleep)
+			timer := time.NewTimer(sleep)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return output, exhausted(t, RetryBudgetExceeded{Description: actionDescription, Elapsed: time.Since(start), Cause: ctx.Err(), Errors: errs})
+			}
+		}
+
+		if ctx.Err() != nil {
+			return output, exhausted(t, RetryBudgetExceeded{Description: actionDescription, Elapsed: time.Since(start), Cause: ctx.Err(), Errors: errs})
+		}
+
+		logger.Default.Debugf(t, "%s", actionDescription)
+
+		var err error
+		output, err = action()
+		if err == nil {
+			return output, nil
+		}
+
+		if _, isFatalErr := err.(FatalError); isFatalErr {
+			logger.Default.Errorf(t, "Returning due to fatal error: %v", err)
+			return output, err
+		}
+
+		errs = append(errs, err)
+		if policy.MaxRetries >= 0 && retry >= policy.MaxRetries {
+			return output, exhausted(t, MaxRetriesExceeded{Description: actionDescription, MaxRetries: policy.MaxRetries, Errors: errs})
+		}
+	}
+}
+
+// exhausted logs that the retry policy was exhausted with the given error, and returns the error.
+func exhausted(t testing.TestingT, err error) error {
+	logger.Default.Warnf(t, "%v", err)
+	return err
+}
+
+// DoWithPolicyInterface runs the specified action. If it returns a value, return that value. If it returns a
+// FatalError, fail the test immediately. If it returns any other type of error, retry it as configured by the given
+// policy. If the policy is exhausted, fail the test. Prefer DoWithPolicy, which returns a typed value.
+func DoWithPolicyInterface(t testing.TestingT, actionDescription string, policy *Policy, action func() (interface{}, error)) interface{} {
+	return DoWithPolicy(t, actionDescription, policy, action)
+}
+
+// DoWithPolicyInterfaceE runs the specified action. If it returns a value, return that value. If it returns a
+// FatalError, return that error immediately. If it returns any other type of error, retry it as configured by the
+// given policy. See DoWithPolicyE for the errors returned if the policy is exhausted. Prefer DoWithPolicyE, which
+// returns a typed value.
+func DoWithPolicyInterfaceE(t testing.TestingT, actionDescription string, policy *Policy, action func() (interface{}, error)) (interface{}, error) {
+	return DoWithPolicyE(t, actionDescription, policy, action)
+}
+
+// DoWithRetryableErrorsAndPolicy runs the specified action. If it returns a value, return that value. If it returns an
+// error, check if error message or the string output from the action (which is often stdout/stderr from running some
+// command) matches any of the regular expressions in the specified retryableErrors map. If there is a match, retry the
+// action as configured by the given policy. If there is no match, fail the test immediately. If the policy is
+// exhausted, fail the test.
+func DoWithRetryableErrorsAndPolicy[T any](t testing.TestingT, actionDescription string, retryableErrors map[string]string, policy *Policy, action func() (T, error)) T {
+	out, err := DoWithRetryableErrorsAndPolicyE(t, actionDescription, retryableErrors, policy, action)
+	require.NoError(t, err)
+	return out
+}
+
+// DoWithRetryableErrorsAndPolicyE runs the specified action. If it returns a value, return that value. If it returns
+// an error, check if error message or the string output from the action (which is often stdout/stderr from running some
+// command) matches any of the regular expressions in the specified retryableErrors map. If there is a match, retry the
+// action as configured by the given policy. If there is no match, return that error immediately, wrapped in a
+// FatalError. See DoWithPolicyE for the errors returned if the policy is exhausted.
+func DoWithRetryableErrorsAndPolicyE[T any](t testing.TestingT, actionDescription string, retryableErrors map[string]string, policy *Policy, action func() (T, error)) (T, error) {
+	retryableAction, err := withRetryableErrors(t, actionDescription, retryableErrors, action)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return DoWithPolicyE(t, actionDescription, policy, retryableAction)
+}
+
+// RetryBudgetExceeded is an error that occurs when the MaxElapsedTime of a retry Policy is exceeded, or its Context is
+// done, before the action succeeded.
+type RetryBudgetExceeded struct {
+	Description string
+	Elapsed     time.Duration
+
+	// The error of the Context of the Policy if it is done (context.Canceled or context.DeadlineExceeded), nil if
+	// MaxElapsedTime was exceeded.
+	Cause error
+
+	// The errors returned by each attempt, in order.
+	Errors []error
+}
+
+func (err RetryBudgetExceeded) Error() string {
+	reason := "retry time budget exceeded"
+	if err.Cause != nil {
+		reason = err.Cause.Error()
+	}
+	msg := fmt.Sprintf("'%s' unsuccessful after %d attempts in %s: %s", err.Description, len(err.Errors), err.Elapsed.Round(time.Millisecond), reason)
+	if len(err.Errors) > 0 {
+		msg = fmt.Sprintf("%s. Last error: %v", msg, err.Errors[len(err.Errors)-1])
+	}
+	return msg
+}
+
+// Unwrap returns the cause and the errors of all attempts, so that they can be inspected with errors.Is and errors.As.
+func (err RetryBudgetExceeded) Unwrap() []error {
+	if err.Cause == nil {
+		return err.Errors
+	}
+	return append([]error{err.Cause}, err.Errors...)
+}
@@ -0,0 +1,143 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyInterval(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.Interval(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Interval(2))
+	assert.Equal(t, 800*time.Millisecond, policy.Interval(4))
+	assert.Equal(t, time.Second, policy.Interval(5))
+	assert.Equal(t, time.Second, policy.Interval(1000))
+
+	constant := ConstantPolicy(3, 50*time.Millisecond)
+	assert.Equal(t, 50*time.Millisecond, constant.Interval(1))
+	assert.Equal(t, 50*time.Millisecond, constant.Interval(10))
+
+	tripling := &Policy{InitialInterval: time.Millisecond, Multiplier: 3}
+	assert.Equal(t, 9*time.Millisecond, tripling.Interval(3))
+}
+
+func TestPolicyJitter(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{InitialInterval: 10 * time.Millisecond, Jitter: true}
+	for i := 0; i < 100; i++ {
+		sleep := policy.sleepBefore(2)
+		assert.GreaterOrEqual(t, sleep, time.Duration(0))
+		assert.LessOrEqual(t, sleep, 20*time.Millisecond)
+	}
+}
+
+func TestDoWithPolicy(t *testing.T) {
+	t.Parallel()
+
+	expectedOutput := "expected"
+	createActionThatFails := func(failures int) (func() (string, error), *int) {
+		count := 0
+		return func() (string, error) {
+			count++
+			if count > failures {
+				return expectedOutput, nil
+			}
+			return "", fmt.Errorf("error %d", count)
+		}, &count
+	}
+
+	t.Run("Return value after retries", func(t *testing.T) {
+		t.Parallel()
+
+		action, count := createActionThatFails(3)
+		out, err := DoWithPolicyE(t, "Return value after retries", &Policy{MaxRetries: 5, InitialInterval: time.Millisecond}, action)
+		require.NoError(t, err)
+		assert.Equal(t, expectedOutput, out)
+		assert.Equal(t, 4, *count)
+	})
+
+	t.Run("Record errors of all attempts", func(t *testing.T) {
+		t.Parallel()
+
+		action, count := createActionThatFails(10)
+		_, err := DoWithPolicyE(t, "Record errors of all attempts", &Policy{MaxRetries: 2, InitialInterval: time.Millisecond}, action)
+		assert.Equal(t, MaxRetriesExceeded{
+			Description: "Record errors of all attempts",
+			MaxRetries:  2,
+			Errors:      []error{fmt.Errorf("error 1"), fmt.Errorf("error 2"), fmt.Errorf("error 3")},
+		}, err)
+		assert.Equal(t, 3, *count)
+		assert.Contains(t, err.Error(), "Last error: error 3")
+	})
+
+	t.Run("Return fatal errors immediately", func(t *testing.T) {
+		t.Parallel()
+
+		count := 0
+		fatalErr := FatalError{Underlying: fmt.Errorf("fatal")}
+		_, err := DoWithPolicyE(t, "Return fatal errors immediately", &Policy{MaxRetries: 5}, func() (string, error) {
+			count++
+			return "", fatalErr
+		})
+		assert.Equal(t, fatalErr, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Stop after max elapsed time", func(t *testing.T) {
+		t.Parallel()
+
+		action, count := createActionThatFails(1000)
+		start := time.Now()
+		_, err := DoWithPolicyE(t, "Stop after max elapsed time", &Policy{MaxRetries: -1, InitialInterval: 10 * time.Millisecond, MaxElapsedTime: 100 * time.Millisecond}, action)
+		var budgetErr RetryBudgetExceeded
+		require.True(t, errors.As(err, &budgetErr))
+		assert.Nil(t, budgetErr.Cause)
+		assert.Len(t, budgetErr.Errors, *count)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Stop when context is canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		action, _ := createActionThatFails(1000)
+		start := time.Now()
+		_, err := DoWithPolicyE(t, "Stop when context is canceled", &Policy{MaxRetries: -1, InitialInterval: time.Hour, Context: ctx}, action)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, []error{fmt.Errorf("error 1")}, err.(RetryBudgetExceeded).Errors)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestDoWithRetryableErrorsAndPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{MaxRetries: 3, InitialInterval: time.Millisecond}
+
+	count := 0
+	_, err := DoWithRetryableErrorsAndPolicyE(t, "Retry expected errors", map[string]string{"throttl": "throttled"}, policy, func() (string, error) {
+		count++
+		return "", fmt.Errorf("request was throttled")
+	})
+	assert.IsType(t, MaxRetriesExceeded{}, err)
+	assert.Equal(t, 4, count)
+
+	count = 0
+	_, err = DoWithRetryableErrorsAndPolicyE(t, "Don't retry unexpected errors", map[string]string{"throttl": "throttled"}, policy, func() (string, error) {
+		count++
+		return "", fmt.Errorf("access denied")
+	})
+	assert.IsType(t, FatalError{}, err)
+	assert.Equal(t, 1, count)
+}
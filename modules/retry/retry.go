@@ -2,6 +2,7 @@
 package retry
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"time"
@@ -10,7 +11,6 @@ import (
 
 	"github.com/nholuongut/terratest/modules/logger"
 	"github.com/nholuongut/terratest/modules/testing"
-	"golang.org/x/net/context"
 )
 
 // Either contains a result and potentially an error.
@@ -20,8 +20,9 @@ type Either struct {
 }
 
 // DoWithTimeout runs the specified action and waits up to the specified timeout for it to complete. Return the output of the action if
-// it completes on time or fail the test otherwise.
-func DoWithTimeout(t testing.TestingT, actionDescription string, timeout time.Duration, action func() (string, error)) string {
+// it completes on time or fail the test otherwise. Note that the action keeps running in the background if the timeout is exceeded; use
+// DoWithTimeoutContext for an action that can be stopped.
+func DoWithTimeout[T any](t testing.TestingT, actionDescription string, timeout time.Duration, action func() (T, error)) T {
 	out, err := DoWithTimeoutE(t, actionDescription, timeout, action)
 	if err != nil {
 		t.Fatal(err)
@@ -30,81 +31,119 @@ func DoWithTimeout(t testing.TestingT, actionDescription string, timeout time.Du
 }
 
 // DoWithTimeoutE runs the specified action and waits up to the specified timeout for it to complete. Return the output of the action if
-// it completes on time or an error otherwise.
-func DoWithTimeoutE(t testing.TestingT, actionDescription string, timeout time.Duration, action func() (string, error)) (string, error) {
+// it completes on time or an error otherwise. Note that the action keeps running in the background if the timeout is exceeded; only
+// DoWithTimeoutContextE can actually stop the action, as it passes the action a context that is canceled on timeout.
+func DoWithTimeoutE[T any](t testing.TestingT, actionDescription string, timeout time.Duration, action func() (T, error)) (T, error) {
+	return DoWithTimeoutContextE(t, actionDescription, timeout, func(ctx context.Context) (T, error) { return action() })
+}
+
+// DoWithTimeoutContext runs the specified action and waits up to the specified timeout for it to complete. Return the output of the
+// action if it completes on time or fail the test otherwise. The context passed to the action is canceled when the timeout is exceeded,
+// so that the action can stop.
+func DoWithTimeoutContext[T any](t testing.TestingT, actionDescription string, timeout time.Duration, action func(ctx context.Context) (T, error)) T {
+	out, err := DoWithTimeoutContextE(t, actionDescription, timeout, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DoWithTimeoutContextE runs the specified action and waits up to the specified timeout for it to complete. Return the output of the
+// action if it completes on time or an error otherwise. The context passed to the action is canceled when the timeout is exceeded, so
+// that the action can stop, rather than keep running in the background.
+func DoWithTimeoutContextE[T any](t testing.TestingT, actionDescription string, timeout time.Duration, action func(ctx context.Context) (T, error)) (T, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	resultChannel := make(chan Either, 1)
+	type result struct {
+		out T
+		err error
+	}
+	resultChannel := make(chan result, 1)
 
 	go func() {
-		out, err := action()
-		resultChannel <- Either{Result: out, Error: err}
+		out, err := action(ctx)
+		resultChannel <- result{out: out, err: err}
 	}()
 
 	select {
-	case either := <-resultChannel:
-		return either.Result, either.Error
+	case result := <-resultChannel:
+		return result.out, result.err
 	case <-ctx.Done():
-		return "", TimeoutExceeded{Description: actionDescription, Timeout: timeout}
+		var zero T
+		return zero, TimeoutExceeded{Description: actionDescription, Timeout: timeout}
 	}
 }
 
-// DoWithRetry runs the specified action. If it returns a string, return that string. If it returns a FatalError, return that error
-// immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
-// maxRetries retries. If maxRetries is exceeded, fail the test.
-func DoWithRetry(t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) string {
-	out, err := DoWithRetryE(t, actionDescription, maxRetries, sleepBetweenRetries, action)
+// Do runs the specified action. If it returns a value, return that value. If it returns a FatalError, fail the test immediately. If
+// it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of maxRetries retries. If maxRetries
+// is exceeded, fail the test. Use DoWithPolicy for exponential backoff.
+func Do[T any](t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (T, error)) T {
+	out, err := DoE(t, actionDescription, maxRetries, sleepBetweenRetries, action)
 	if err != nil {
 		t.Fatal(err)
 	}
 	return out
 }
 
-// DoWithRetryE runs the specified action. If it returns a string, return that string. If it returns a FatalError, return that error
+// DoE runs the specified action. If it returns a value, return that value. If it returns a FatalError, return that error
 // immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
-// maxRetries retries. If maxRetries is exceeded, return a MaxRetriesExceeded error.
-func DoWithRetryE(t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) (string, error) {
-	out, err := DoWithRetryInterfaceE(t, actionDescription, maxRetries, sleepBetweenRetries, func() (interface{}, error) { return action() })
-	return out.(string), err
-}
-
-// DoWithRetryInterface runs the specified action. If it returns a value, return that value. If it returns a FatalError, return that error
-// immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
-// maxRetries retries. If maxRetries is exceeded, fail the test.
-func DoWithRetryInterface(t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (interface{}, error)) interface{} {
-	out, err := DoWithRetryInterfaceE(t, actionDescription, maxRetries, sleepBetweenRetries, action)
-	if err != nil {
-		t.Fatal(err)
-	}
-	return out
-}
-
-// DoWithRetryInterfaceE runs the specified action. If it returns a value, return that value. If it returns a FatalError, return that error
-// immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
-// maxRetries retries. If maxRetries is exceeded, return a MaxRetriesExceeded error.
-func DoWithRetryInterfaceE(t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (interface{}, error)) (interface{}, error) {
-	var output interface{}
-	var err error
+// maxRetries retries. If maxRetries is exceeded, return a MaxRetriesExceeded error, which carries the errors of all attempts.
+// Use DoWithPolicyE for exponential backoff.
+func DoE[T any](t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (T, error)) (T, error) {
+	var output T
+	errs := []error{}
 
 	for i := 0; i <= maxRetries; i++ {
-		logger.Default.Logf(t, "%s", actionDescription)
+		logger.Default.Debugf(t, "%s", actionDescription)
 
+		var err error
 		output, err = action()
 		if err == nil {
 			return output, nil
 		}
 
 		if _, isFatalErr := err.(FatalError); isFatalErr {
-			logger.Default.Logf(t, "Returning due to fatal error: %v", err)
+			logger.Default.Errorf(t, "Returning due to fatal error: %v", err)
 			return output, err
 		}
 
-		logger.Default.Logf(t, "%s returned an error: %s. Sleeping for %s and will try again.", actionDescription, err.Error(), sleepBetweenRetries)
+		errs = append(errs, err)
+		logger.Default.Debugf(t, "%s returned an error: %s. Sleeping for %s and will try again.", actionDescription, err.Error(), sleepBetweenRetries)
 		time.Sleep(sleepBetweenRetries)
 	}
 
-	return output, MaxRetriesExceeded{Description: actionDescription, MaxRetries: maxRetries}
+	exceeded := MaxRetriesExceeded{Description: actionDescription, MaxRetries: maxRetries, Errors: errs}
+	logger.Default.Warnf(t, "%v", exceeded)
+	return output, exceeded
+}
+
+// DoWithRetry runs the specified action. If it returns a string, return that string. If it returns a FatalError, return that error
+// immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
+// maxRetries retries. If maxRetries is exceeded, fail the test.
+func DoWithRetry(t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) string {
+	return Do(t, actionDescription, maxRetries, sleepBetweenRetries, action)
+}
+
+// DoWithRetryE runs the specified action. If it returns a string, return that string. If it returns a FatalError, return that error
+// immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
+// maxRetries retries. If maxRetries is exceeded, return a MaxRetriesExceeded error.
+func DoWithRetryE(t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) (string, error) {
+	return DoE(t, actionDescription, maxRetries, sleepBetweenRetries, action)
+}
+
+// DoWithRetryInterface runs the specified action. If it returns a value, return that value. If it returns a FatalError, return that error
+// immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
+// maxRetries retries. If maxRetries is exceeded, fail the test. Prefer Do, which returns a typed value.
+func DoWithRetryInterface(t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (interface{}, error)) interface{} {
+	return Do(t, actionDescription, maxRetries, sleepBetweenRetries, action)
+}
+
+// DoWithRetryInterfaceE runs the specified action. If it returns a value, return that value. If it returns a FatalError, return that error
+// immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
+// maxRetries retries. If maxRetries is exceeded, return a MaxRetriesExceeded error. Prefer DoE, which returns a typed value.
+func DoWithRetryInterfaceE(t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (interface{}, error)) (interface{}, error) {
+	return DoE(t, actionDescription, maxRetries, sleepBetweenRetries, action)
 }
 
 // DoWithRetryableErrors runs the specified action. If it returns a value, return that value. If it returns an error,
@@ -112,7 +151,7 @@ func DoWithRetryInterfaceE(t testing.TestingT, actionDescription string, maxRetr
 // matches any of the regular expressions in the specified retryableErrors map. If there is a match, sleep for
 // sleepBetweenRetries, and retry the specified action, up to a maximum of maxRetries retries. If there is no match,
 // return that error immediately, wrapped in a FatalError. If maxRetries is exceeded, return a MaxRetriesExceeded error.
-func DoWithRetryableErrors(t testing.TestingT, actionDescription string, retryableErrors map[string]string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) string {
+func DoWithRetryableErrors[T any](t testing.TestingT, actionDescription string, retryableErrors map[string]string, maxRetries int, sleepBetweenRetries time.Duration, action func() (T, error)) T {
 	out, err := DoWithRetryableErrorsE(t, actionDescription, retryableErrors, maxRetries, sleepBetweenRetries, action)
 	require.NoError(t, err)
 	return out
@@ -123,31 +162,44 @@ func DoWithRetryableErrors(t testing.TestingT, actionDescription string, retryab
 // matches any of the regular expressions in the specified retryableErrors map. If there is a match, sleep for
 // sleepBetweenRetries, and retry the specified action, up to a maximum of maxRetries retries. If there is no match,
 // return that error immediately, wrapped in a FatalError. If maxRetries is exceeded, return a MaxRetriesExceeded error.
-func DoWithRetryableErrorsE(t testing.TestingT, actionDescription string, retryableErrors map[string]string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) (string, error) {
+func DoWithRetryableErrorsE[T any](t testing.TestingT, actionDescription string, retryableErrors map[string]string, maxRetries int, sleepBetweenRetries time.Duration, action func() (T, error)) (T, error) {
+	retryableAction, err := withRetryableErrors(t, actionDescription, retryableErrors, action)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return DoE(t, actionDescription, maxRetries, sleepBetweenRetries, retryableAction)
+}
+
+// withRetryableErrors wraps the given action so that the errors it returns are wrapped in a FatalError, unless the error
+// message or the output of the action (if it is a string) matches any of the regular expressions in the given
+// retryableErrors map.
+func withRetryableErrors[T any](t testing.TestingT, actionDescription string, retryableErrors map[string]string, action func() (T, error)) (func() (T, error), error) {
 	retryableErrorsRegexp := map[*regexp.Regexp]string{}
 	for errorStr, errorMessage := range retryableErrors {
 		errorRegex, err := regexp.Compile(errorStr)
 		if err != nil {
-			return "", FatalError{Underlying: err}
+			return nil, FatalError{Underlying: err}
 		}
 		retryableErrorsRegexp[errorRegex] = errorMessage
 	}
 
-	return DoWithRetryE(t, actionDescription, maxRetries, sleepBetweenRetries, func() (string, error) {
+	return func() (T, error) {
 		output, err := action()
 		if err == nil {
 			return output, nil
 		}
 
+		outputStr, _ := any(output).(string)
 		for errorRegexp, errorMessage := range retryableErrorsRegexp {
-			if errorRegexp.MatchString(output) || errorRegexp.MatchString(err.Error()) {
-				logger.Default.Logf(t, "'%s' failed with the error '%s' but this error was expected and warrants a retry. Further details: %s\n", actionDescription, err.Error(), errorMessage)
+			if errorRegexp.MatchString(outputStr) || errorRegexp.MatchString(err.Error()) {
+				logger.Default.Debugf(t, "'%s' failed with the error '%s' but this error was expected and warrants a retry. Further details: %s\n", actionDescription, err.Error(), errorMessage)
 				return output, err
 			}
 		}
 
 		return output, FatalError{Underlying: err}
-	})
+	}, nil
 }
 
 // Done can be stopped.
@@ -167,11 +219,11 @@ func DoInBackgroundUntilStopped(t testing.TestingT, actionDescription string, sl
 
 	go func() {
 		for {
-			logger.Default.Logf(t, "Executing action '%s'", actionDescription)
+			logger.Default.Debugf(t, "Executing action '%s'", actionDescription)
 
 			action()
 
-			logger.Default.Logf(t, "Sleeping for %s before repeating action '%s'", sleepBetweenRepeats, actionDescription)
+			logger.Default.Debugf(t, "Sleeping for %s before repeating action '%s'", sleepBetweenRepeats, actionDescription)
 
 			select {
 			case <-time.After(sleepBetweenRepeats):
@@ -202,10 +254,22 @@ func (err TimeoutExceeded) Error() string {
 type MaxRetriesExceeded struct {
 	Description string
 	MaxRetries  int
+
+	// The errors returned by each attempt, in order.
+	Errors []error
 }
 
 func (err MaxRetriesExceeded) Error() string {
-	return fmt.Sprintf("'%s' unsuccessful after %d retries", err.Description, err.MaxRetries)
+	msg := fmt.Sprintf("'%s' unsuccessful after %d retries", err.Description, err.MaxRetries)
+	if len(err.Errors) > 0 {
+		msg = fmt.Sprintf("%s. Last error: %v", msg, err.Errors[len(err.Errors)-1])
+	}
+	return msg
+}
+
+// Unwrap returns the errors of all attempts, so that they can be inspected with errors.Is and errors.As.
+func (err MaxRetriesExceeded) Unwrap() []error {
+	return err.Errors
 }
 
 // FatalError is a marker interface for errors that should not be retried.
@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -49,7 +50,7 @@ func TestDoWithRetry(t *testing.T) {
 			actualOutput, err := DoWithRetryE(t, testCase.description, testCase.maxRetries, 1*time.Millisecond, testCase.action)
 			assert.Equal(t, expectedOutput, actualOutput)
 			if testCase.expectedError != nil {
-				assert.Equal(t, testCase.expectedError, err)
+				assert.Equal(t, testCase.expectedError, withoutAttemptErrors(t, testCase.maxRetries, err))
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, expectedOutput, actualOutput)
@@ -112,6 +113,62 @@ func TestDoWithTimeout(t *testing.T) {
 	}
 }
 
+func TestDoWithTimeoutReturnsTypedValue(t *testing.T) {
+	t.Parallel()
+
+	actualOutput, err := DoWithTimeoutE(t, t.Name(), 5*time.Second, func() (map[string]int, error) {
+		return map[string]int{"count": 3}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"count": 3}, actualOutput)
+}
+
+func TestDoWithTimeoutContextCancelsContextOnTimeout(t *testing.T) {
+	t.Parallel()
+
+	cancelled := make(chan struct{})
+	_, err := DoWithTimeoutContextE(t, t.Name(), 100*time.Millisecond, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return "", ctx.Err()
+	})
+	assert.Equal(t, TimeoutExceeded{Description: t.Name(), Timeout: 100 * time.Millisecond}, err)
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The context of the action was not cancelled after the timeout")
+	}
+}
+
+func TestDoReturnsTypedValue(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	actualOutput, err := DoE(t, t.Name(), 5, 1*time.Millisecond, func() (int, error) {
+		count++
+		if count < 3 {
+			return 0, fmt.Errorf("attempt %d failed", count)
+		}
+		return count, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, actualOutput)
+}
+
+func TestDoRecordsErrorsOfAllAttempts(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	_, err := DoE(t, t.Name(), 2, 1*time.Millisecond, func() (string, error) {
+		count++
+		return "", ErrorCounter(count)
+	})
+	assert.Equal(t, MaxRetriesExceeded{Description: t.Name(), MaxRetries: 2, Errors: []error{ErrorCounter(1), ErrorCounter(2), ErrorCounter(3)}}, err)
+	assert.ErrorIs(t, err, ErrorCounter(2))
+	assert.Contains(t, err.Error(), "Last error: 3")
+}
+
 func TestDoInBackgroundUntilStopped(t *testing.T) {
 	t.Parallel()
 
@@ -248,7 +305,7 @@ func TestDoWithRetryableErrors(t *testing.T) {
 			actualOutput, err := DoWithRetryableErrorsE(t, testCase.description, testCase.retryableErrors, testCase.maxRetries, 1*time.Millisecond, testCase.action)
 			assert.Equal(t, expectedOutput, actualOutput)
 			if testCase.expectedError != nil {
-				assert.Equal(t, testCase.expectedError, err)
+				assert.Equal(t, testCase.expectedError, withoutAttemptErrors(t, testCase.maxRetries, err))
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, expectedOutput, actualOutput)
@@ -257,8 +314,36 @@ func TestDoWithRetryableErrors(t *testing.T) {
 	}
 }
 
+// withoutAttemptErrors checks that the given error, if it is a MaxRetriesExceeded error, carries the errors of all the
+// attempts, and returns it without them, so that it can be compared to the expected error.
+func withoutAttemptErrors(t *testing.T, maxRetries int, err error) error {
+	exceeded, isMaxRetriesExceeded := err.(MaxRetriesExceeded)
+	if !isMaxRetriesExceeded {
+		return err
+	}
+	assert.Len(t, exceeded.Errors, maxRetries+1)
+	exceeded.Errors = nil
+	return exceeded
+}
+
 type ErrorCounter int
 
 func (count ErrorCounter) Error() string {
 	return fmt.Sprintf("%d", int(count))
 }
+
+func TestDoWithRetryableErrorsReturnsTypedValue(t *testing.T) {
+	t.Parallel()
+
+	expectedError := fmt.Errorf("expected error")
+	count := 0
+	actualOutput, err := DoWithRetryableErrorsE(t, t.Name(), map[string]string{"^expected": "expected error"}, 5, 1*time.Millisecond, func() ([]int, error) {
+		count++
+		if count < 3 {
+			return nil, expectedError
+		}
+		return []int{1, 2, 3}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, actualOutput)
+}
@@ -0,0 +1,343 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// BackgroundCommand is a command that was started in the background with StartCommand, such as a local server,
+// kubectl proxy or a mock API, and that keeps running while the test interacts with it.
+type BackgroundCommand struct {
+	Command Command
+
+	cmd    *exec.Cmd
+	output *output
+
+	// updated receives a value whenever a line of output is read, so that waiters can check the output again.
+	updated chan struct{}
+	// exited is closed once the command has exited, after which err and exitCode are set.
+	exited   chan struct{}
+	err      error
+	exitCode int
+}
+
+// StartCommand starts a shell command in the background and returns a handle to interact with it. The stdout and stderr
+// of the command are captured and logged with Command.Logger as they are written. If the TestingT supports it (e.g.,
+// *testing.T), the command is stopped with Stop automatically when the test completes. If there are any errors, fail
+// the test.
+func StartCommand(t testing.TestingT, command Command) *BackgroundCommand {
+	background, err := StartCommandE(t, command)
+	require.NoError(t, err)
+	return background
+}
+
+// StartCommandE starts a shell command in the background and returns a handle to interact with it. The stdout and
+// stderr of the command are captured and logged with Command.Logger as they are written. If the TestingT supports it
+// (e.g., *testing.T), the command is stopped with Stop automatically when the test completes. The Context and Timeout
+// of the command are honored as they are by RunCommand.
+func StartCommandE(t testing.TestingT, command Command) (*BackgroundCommand, error) {
+	command.Logger.Logf(t, "Starting command %s with args %s in the background", command.Command, command.Args)
+
+	var cancel context.CancelFunc = func() {}
+	if command.Timeout > 0 {
+		ctx := command.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		command.Context, cancel = context.WithTimeout(ctx, command.Timeout)
+	}
+
+	if command.Context != nil {
+		if err := command.Context.Err(); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	background := &BackgroundCommand{
+		Command: command,
+		output:  newOutput(),
+		updated: make(chan struct{}, 1),
+		exited:  make(chan struct{}),
+	}
+
+	// Notify waiters of every line, in addition to calling the callbacks of the command.
+	readCommand := command
+	readCommand.OnStdoutLine = background.notifyLine(command.OnStdoutLine)
+	readCommand.OnStderrLine = background.notifyLine(command.OnStderrLine)
+
+	// Always run the command in its own process group, so that Stop also stops any child processes it spawned.
+	cmd, stdout, stderr, err := startCommand(command, true)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	background.cmd = cmd
+
+	stopWatching := watchForCancellation(t, command, cmd)
+	go func() {
+		defer cancel()
+
+		err := readStdoutAndStderrInto(t, readCommand, background.output, stdout, stderr)
+		waitErr := cmd.Wait()
+		if err == nil {
+			err = waitErr
+		}
+		if cancelled := stopWatching(); cancelled && err != nil {
+			err = fmt.Errorf("%w: %w", command.Context.Err(), err)
+		}
+
+		background.exitCode = cmd.ProcessState.ExitCode()
+		if err != nil {
+			background.err = &ErrWithCmdOutput{err, background.output}
+		}
+		command.Logger.Logf(t, "Command %s exited with code %d", command.Command, background.exitCode)
+		close(background.exited)
+	}()
+
+	if cleaner, canCleanup := t.(interface{ Cleanup(func()) }); canCleanup {
+		cleaner.Cleanup(func() {
+			if err := background.StopE(t); err != nil {
+				command.Logger.Warnf(t, "Failed to stop command %s: %v", command.Command, err)
+			}
+		})
+	}
+
+	return background, nil
+}
+
+// notifyLine returns a line callback that calls the given callback, if any, and notifies waiters of the new line.
+func (background *BackgroundCommand) notifyLine(onLine func(string)) func(string) {
+	return func(line string) {
+		if onLine != nil {
+			onLine(line)
+		}
+		select {
+		case background.updated <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Pid returns the process id of the command.
+func (background *BackgroundCommand) Pid() int {
+	return background.cmd.Process.Pid
+}
+
+// Stdout returns the stdout the command has written so far.
+func (background *BackgroundCommand) Stdout() string {
+	return background.output.Stdout()
+}
+
+// Stderr returns the stderr the command has written so far.
+func (background *BackgroundCommand) Stderr() string {
+	return background.output.Stderr()
+}
+
+// Combined returns the stdout and stderr the command has written so far, interleaved in the order the lines were read.
+func (background *BackgroundCommand) Combined() string {
+	return background.output.Combined()
+}
+
+// Lines returns the lines of stdout and stderr the command has written so far, interleaved in the order they were read,
+// with the stream each line was written to.
+func (background *BackgroundCommand) Lines() []OutputLine {
+	return background.output.Lines()
+}
+
+// Exited returns a channel that is closed once the command has exited.
+func (background *BackgroundCommand) Exited() <-chan struct{} {
+	return background.exited
+}
+
+// ExitCode returns the exit code of the command, and true if the command has exited. If the command has not exited yet,
+// this returns -1 and false. The exit code is also -1 if the command was terminated by a signal.
+func (background *BackgroundCommand) ExitCode() (int, bool) {
+	select {
+	case <-background.exited:
+		return background.exitCode, true
+	default:
+		return -1, false
+	}
+}
+
+// Wait waits for the command to exit and returns its error, if any, of type ErrWithCmdOutput.
+func (background *BackgroundCommand) Wait() error {
+	<-background.exited
+	return background.err
+}
+
+// Signal sends the given signal to the command (e.g., syscall.SIGHUP to make a server reload its configuration).
+func (background *BackgroundCommand) Signal(sig os.Signal) error {
+	return background.cmd.Process.Signal(sig)
+}
+
+// Stop stops the command, if it is still running, by sending SIGINT to its process group, followed by SIGKILL if it is
+// still running after Command.CancelGracePeriod (DefaultCancelGracePeriod if not set). It is safe to call Stop more
+// than once. This will fail the test if the command can't be signalled.
+func (background *BackgroundCommand) Stop(t testing.TestingT) {
+	require.NoError(t, background.StopE(t))
+}
+
+// StopE stops the command, if it is still running, by sending SIGINT to its process group, followed by SIGKILL if it
+// is still running after Command.CancelGracePeriod (DefaultCancelGracePeriod if not set). It is safe to call StopE more
+// than once. This does not return the error of the command; use Wait for that.
+func (background *BackgroundCommand) StopE(t testing.TestingT) error {
+	if _, hasExited := background.ExitCode(); hasExited {
+		return nil
+	}
+
+	gracePeriod := background.Command.CancelGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultCancelGracePeriod
+	}
+
+	background.Command.Logger.Logf(t, "Stopping command %s. Sending interrupt signal.", background.Command.Command)
+	if err := interruptProcessGroup(background.cmd); err != nil {
+		if _, hasExited := background.ExitCode(); hasExited {
+			return nil
+		}
+		return err
+	}
+
+	select {
+	case <-background.exited:
+		return nil
+	case <-time.After(gracePeriod):
+	}
+
+	background.Command.Logger.Warnf(t, "Command %s did not exit within %s of being interrupted. Killing it.", background.Command.Command, gracePeriod)
+	if err := killProcessGroup(background.cmd); err != nil {
+		if _, hasExited := background.ExitCode(); hasExited {
+			return nil
+		}
+		return err
+	}
+	<-background.exited
+	return nil
+}
+
+// WaitForOutput waits up to the given timeout for the combined stdout and stderr of the command to match the given
+// regular expression, and returns the first match (e.g., "Listening on port (\d+)"). This will fail the test if the
+// output doesn't match before the timeout, or if the command exits first.
+func (background *BackgroundCommand) WaitForOutput(t testing.TestingT, regex string, timeout time.Duration) string {
+	match, err := background.WaitForOutputE(t, regex, timeout)
+	require.NoError(t, err)
+	return match
+}
+
+// WaitForOutputE waits up to the given timeout for the combined stdout and stderr of the command to match the given
+// regular expression, and returns the first match (e.g., "Listening on port (\d+)"). This returns an OutputNotMatched
+// error if the output doesn't match before the timeout, and a CommandExited error if the command exits first.
+func (background *BackgroundCommand) WaitForOutputE(t testing.TestingT, regex string, timeout time.Duration) (string, error) {
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return "", err
+	}
+
+	background.Command.Logger.Logf(t, "Waiting up to %s for the output of command %s to match %s", timeout, background.Command.Command, regex)
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		if match := re.FindString(background.Combined()); match != "" {
+			return match, nil
+		}
+
+		select {
+		case <-background.updated:
+		case <-background.exited:
+			// All the output has been read once the command has exited, so check it one last time.
+			if match := re.FindString(background.Combined()); match != "" {
+				return match, nil
+			}
+			return "", CommandExited{Command: background.Command.Command, ExitCode: background.exitCode, Err: background.err}
+		case <-deadline.C:
+			return "", OutputNotMatched{Command: background.Command.Command, Regex: regex, Timeout: timeout}
+		}
+	}
+}
+
+// WaitForPort waits up to the given timeout for a TCP connection to the given port on localhost to succeed, e.g., for
+// a server started in the background to be ready. This will fail the test if the port doesn't accept connections before
+// the timeout, or if the command exits first.
+func (background *BackgroundCommand) WaitForPort(t testing.TestingT, port int, timeout time.Duration) {
+	require.NoError(t, background.WaitForPortE(t, port, timeout))
+}
+
+// WaitForPortE waits up to the given timeout for a TCP connection to the given port on localhost to succeed, e.g., for
+// a server started in the background to be ready. This returns a PortNotOpen error if the port doesn't accept
+// connections before the timeout, and a CommandExited error if the command exits first.
+func (background *BackgroundCommand) WaitForPortE(t testing.TestingT, port int, timeout time.Duration) error {
+	address := net.JoinHostPort("localhost", strconv.Itoa(port))
+	background.Command.Logger.Logf(t, "Waiting up to %s for command %s to listen on %s", timeout, background.Command.Command, address)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err == nil {
+			return conn.Close()
+		}
+
+		if time.Now().After(deadline) {
+			return PortNotOpen{Command: background.Command.Command, Port: port, Timeout: timeout}
+		}
+
+		select {
+		case <-background.exited:
+			return CommandExited{Command: background.Command.Command, ExitCode: background.exitCode, Err: background.err}
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// OutputNotMatched is an error that occurs when the output of a background command doesn't match a regular expression
+// within a timeout.
+type OutputNotMatched struct {
+	Command string
+	Regex   string
+	Timeout time.Duration
+}
+
+func (err OutputNotMatched) Error() string {
+	return fmt.Sprintf("output of command %s did not match %s within %s", err.Command, err.Regex, err.Timeout)
+}
+
+// PortNotOpen is an error that occurs when a port doesn't accept connections within a timeout while waiting for a
+// background command.
+type PortNotOpen struct {
+	Command string
+	Port    int
+	Timeout time.Duration
+}
+
+func (err PortNotOpen) Error() string {
+	return fmt.Sprintf("port %d did not accept connections within %s while waiting for command %s", err.Port, err.Timeout, err.Command)
+}
+
+// CommandExited is an error that occurs when a background command exits while waiting for it.
+type CommandExited struct {
+	Command  string
+	ExitCode int
+	Err      error
+}
+
+func (err CommandExited) Error() string {
+	if err.Err != nil {
+		return fmt.Sprintf("command %s exited with code %d while waiting for it: %v", err.Command, err.ExitCode, err.Err)
+	}
+	return fmt.Sprintf("command %s exited with code %d while waiting for it", err.Command, err.ExitCode)
+}
+
+// Unwrap returns the error of the command, if any.
+func (err CommandExited) Unwrap() error {
+	return err.Err
+}
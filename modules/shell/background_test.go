@@ -0,0 +1,169 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nholuongut/terratest/modules/logger"
+)
+
+func TestStartCommandWaitForOutputAndStop(t *testing.T) {
+	t.Parallel()
+
+	background := StartCommand(t, Command{
+		Command:           "bash",
+		Args:              []string{"-c", `trap 'echo stopping; exit 0' INT; echo starting; sleep .1s; echo "ready on port 1234"; while true; do sleep .1s; done`},
+		Logger:            logger.Discard,
+		CancelGracePeriod: 5 * time.Second,
+	})
+
+	match := background.WaitForOutput(t, `ready on port \d+`, 10*time.Second)
+	assert.Equal(t, "ready on port 1234", match)
+
+	_, hasExited := background.ExitCode()
+	assert.False(t, hasExited)
+
+	background.Stop(t)
+	exitCode, hasExited := background.ExitCode()
+	assert.True(t, hasExited)
+	assert.Equal(t, 0, exitCode)
+	assert.NoError(t, background.Wait())
+	assert.Equal(t, "starting\nready on port 1234\nstopping", background.Combined())
+
+	// Stopping an exited command is a no-op.
+	background.Stop(t)
+}
+
+func TestStartCommandStopKillsProcessIgnoringInterrupt(t *testing.T) {
+	t.Parallel()
+
+	background := StartCommand(t, Command{
+		Command:           "bash",
+		Args:              []string{"-c", `trap '' INT; echo started; sleep 30`},
+		Logger:            logger.Discard,
+		CancelGracePeriod: 500 * time.Millisecond,
+	})
+	background.WaitForOutput(t, "started", 10*time.Second)
+
+	start := time.Now()
+	background.Stop(t)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	exitCode, hasExited := background.ExitCode()
+	assert.True(t, hasExited)
+	assert.Equal(t, -1, exitCode)
+}
+
+func TestStartCommandWaitForOutputFailsWhenCommandExits(t *testing.T) {
+	t.Parallel()
+
+	background := StartCommand(t, Command{
+		Command: "bash",
+		Args:    []string{"-c", `echo failing >&2; exit 2`},
+		Logger:  logger.Discard,
+	})
+
+	_, err := background.WaitForOutputE(t, "ready", 10*time.Second)
+	var exited CommandExited
+	require.True(t, errors.As(err, &exited))
+	assert.Equal(t, 2, exited.ExitCode)
+	assert.Equal(t, "failing", background.Stderr())
+}
+
+func TestStartCommandWaitForOutputTimesOut(t *testing.T) {
+	t.Parallel()
+
+	background := StartCommand(t, Command{
+		Command: "sleep",
+		Args:    []string{"30"},
+		Logger:  logger.Discard,
+	})
+
+	_, err := background.WaitForOutputE(t, "ready", 200*time.Millisecond)
+	assert.Equal(t, OutputNotMatched{Command: "sleep", Regex: "ready", Timeout: 200 * time.Millisecond}, err)
+}
+
+func TestStartCommandSignal(t *testing.T) {
+	t.Parallel()
+
+	background := StartCommand(t, Command{
+		Command: "bash",
+		Args:    []string{"-c", `trap 'echo reloaded' HUP; echo started; while true; do sleep .1s; done`},
+		Logger:  logger.Discard,
+	})
+	background.WaitForOutput(t, "started", 10*time.Second)
+
+	require.NoError(t, background.Signal(syscall.SIGHUP))
+	background.WaitForOutput(t, "reloaded", 10*time.Second)
+}
+
+func TestStartCommandWaitForPort(t *testing.T) {
+	t.Parallel()
+
+	background := StartCommand(t, Command{
+		Command: "sleep",
+		Args:    []string{"30"},
+		Logger:  logger.Discard,
+	})
+
+	// Stand in for a server started by the command, which starts listening after a while.
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	listeners := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+		if err == nil {
+			listeners <- listener
+		}
+	}()
+
+	background.WaitForPort(t, port, 10*time.Second)
+	require.NoError(t, (<-listeners).Close())
+}
+
+func TestStartCommandWaitForPortFailsWhenCommandExits(t *testing.T) {
+	t.Parallel()
+
+	background := StartCommand(t, Command{
+		Command: "bash",
+		Args:    []string{"-c", `exit 1`},
+		Logger:  logger.Discard,
+	})
+
+	// Nothing listens on the port of a closed listener.
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	err = background.WaitForPortE(t, port, 10*time.Second)
+	var exited CommandExited
+	require.True(t, errors.As(err, &exited))
+	assert.Equal(t, 1, exited.ExitCode)
+}
+
+func TestStartCommandIsStoppedOnCleanup(t *testing.T) {
+	t.Parallel()
+
+	var background *BackgroundCommand
+	t.Run("group", func(t *testing.T) {
+		background = StartCommand(t, Command{
+			Command: "sleep",
+			Args:    []string{"30"},
+			Logger:  logger.Discard,
+		})
+	})
+
+	_, hasExited := background.ExitCode()
+	assert.True(t, hasExited)
+}
@@ -2,14 +2,17 @@ package shell
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/nholuongut/terratest/modules/logger"
 	"github.com/nholuongut/terratest/modules/testing"
@@ -24,8 +27,30 @@ type Command struct {
 	Env        map[string]string // Additional environment variables to set
 	// Use the specified logger for the command's output. Use logger.Discard to not print the output while executing the command.
 	Logger *logger.Logger
+	// If set, the command is cancelled when the context is done (e.g., when its deadline expires). On cancellation, the
+	// process group of the command is sent SIGINT so that tools like terraform can shut down gracefully and release
+	// their locks, followed by SIGKILL if the process is still running after CancelGracePeriod.
+	Context context.Context
+	// The amount of time to wait after sending SIGINT on cancellation before the process group is killed. Defaults to
+	// DefaultCancelGracePeriod.
+	CancelGracePeriod time.Duration
+	// If set, the command is cancelled, as if its Context were done, when it runs for longer than the timeout.
+	Timeout time.Duration
+	// The stdin of the command. Defaults to the stdin of this Go program (os.Stdin).
+	Stdin io.Reader
+	// If set, these functions are called with each line of stdout and stderr of the command as soon as it is read (e.g.,
+	// to react to "Apply complete!" while terraform is still running). The lines of each stream are passed in order, but
+	// the two functions may be called concurrently.
+	OnStdoutLine func(line string)
+	OnStderrLine func(line string)
+	// If true, the command does not inherit the environment variables of this Go program, so that only Env is set.
+	DisableEnvInheritance bool
 }
 
+// DefaultCancelGracePeriod is the amount of time a cancelled command is given to exit after receiving SIGINT, before it
+// is forcibly killed.
+const DefaultCancelGracePeriod = 10 * time.Second
+
 // RunCommand runs a shell command and redirects its stdout and stderr to the stdout of the atomic script itself. If
 // there are any errors, fail the test.
 func RunCommand(t testing.TestingT, command Command) {
@@ -84,13 +109,73 @@ func RunCommandAndGetStdOutE(t testing.TestingT, command Command) (string, error
 	return output.Stdout(), nil
 }
 
+// CommandResult is the result of running a shell command.
+type CommandResult struct {
+	Stdout   string // The stdout of the command
+	Stderr   string // The stderr of the command
+	Combined string // The stdout and stderr of the command, interleaved in the order the lines were read
+	// The lines of stdout and stderr of the command, interleaved in the order they were read, with the stream each line
+	// was written to.
+	Lines    []OutputLine
+	ExitCode int           // The exit code of the command
+	Duration time.Duration // The time the command took to run
+}
+
+// OutputLine is a line of output of a shell command.
+type OutputLine struct {
+	Text   string
+	Stderr bool // True if the line was written to stderr, false if it was written to stdout
+}
+
+// RunCommandAndGetOutputStruct runs a shell command and returns its stdout, stderr, combined output, exit code and
+// duration. The stdout and stderr of that command will also be logged with Command.Log to make debugging easier. If
+// there are any errors, fail the test.
+func RunCommandAndGetOutputStruct(t testing.TestingT, command Command) *CommandResult {
+	result, err := RunCommandAndGetOutputStructE(t, command)
+	require.NoError(t, err)
+	return result
+}
+
+// RunCommandAndGetOutputStructE runs a shell command and returns its stdout, stderr, combined output, exit code and
+// duration. The stdout and stderr of that command will also be logged with Command.Log to make debugging easier. The
+// result is also returned if the command fails, e.g., to check its exit code. Any returned error will be of type
+// ErrWithCmdOutput, containing the output streams and the underlying error.
+func RunCommandAndGetOutputStructE(t testing.TestingT, command Command) (*CommandResult, error) {
+	start := time.Now()
+	output, err := runCommand(t, command)
+	result := &CommandResult{
+		Stdout:   output.Stdout(),
+		Stderr:   output.Stderr(),
+		Combined: output.Combined(),
+		Lines:    output.Lines(),
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		err = &ErrWithCmdOutput{err, output}
+		exitCode, exitCodeErr := GetExitCodeForRunCommandError(err)
+		if exitCodeErr == nil && exitCode == 0 {
+			// The command didn't exit with an exit code (e.g., it could not be started or it was killed).
+			exitCode = -1
+		}
+		result.ExitCode = exitCode
+		return result, err
+	}
+	return result, nil
+}
+
 type ErrWithCmdOutput struct {
 	Underlying error
 	Output     *output
 }
 
 func (e *ErrWithCmdOutput) Error() string {
-	return fmt.Sprintf("error while running command: %v; %s", e.Underlying, e.Output.Stderr())
+	return logger.Redact(fmt.Sprintf("error while running command: %v; %s", e.Underlying, e.Output.Stderr()))
+}
+
+// Unwrap returns the underlying error, so that errors.Is and errors.As can inspect it (e.g., to check whether the
+// command was cancelled via its Context).
+func (e *ErrWithCmdOutput) Unwrap() error {
+	return e.Underlying
 }
 
 // runCommand runs a shell command and stores each line from stdout and stderr in Output. Depending on the logger, the
@@ -99,66 +184,154 @@ func (e *ErrWithCmdOutput) Error() string {
 func runCommand(t testing.TestingT, command Command) (*output, error) {
 	command.Logger.Logf(t, "Running command %s with args %s", command.Command, command.Args)
 
+	if command.Timeout > 0 {
+		ctx := command.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var cancel context.CancelFunc
+		command.Context, cancel = context.WithTimeout(ctx, command.Timeout)
+		defer cancel()
+	}
+
+	if command.Context != nil {
+		if err := command.Context.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Run the command in its own process group if it can be cancelled, so that on cancellation we can signal any child
+	// processes it spawned as well (e.g., terraform provider plugins).
+	cmd, stdout, stderr, err := startCommand(command, command.Context != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	stopWatching := watchForCancellation(t, command, cmd)
+
+	output, err := readStdoutAndStderr(t, command, stdout, stderr)
+	if err == nil {
+		err = cmd.Wait()
+	}
+
+	if cancelled := stopWatching(); cancelled && err != nil {
+		return output, fmt.Errorf("%w: %w", command.Context.Err(), err)
+	}
+	return output, err
+}
+
+// startCommand starts the given command, optionally in its own process group, and returns the started cmd along with
+// pipes for its stdout and stderr.
+func startCommand(command Command, ownProcessGroup bool) (*exec.Cmd, io.ReadCloser, io.ReadCloser, error) {
 	cmd := exec.Command(command.Command, command.Args...)
 	cmd.Dir = command.WorkingDir
 	cmd.Stdin = os.Stdin
+	if command.Stdin != nil {
+		cmd.Stdin = command.Stdin
+	}
 	cmd.Env = formatEnvVars(command)
+	if ownProcessGroup {
+		setProcessGroup(cmd)
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	err = cmd.Start()
-	if err != nil {
-		return nil, err
+	if err := cmd.Start(); err != nil {
+		return nil, nil, nil, err
 	}
+	return cmd, stdout, stderr, nil
+}
 
-	output, err := readStdoutAndStderr(t, command.Logger, stdout, stderr)
-	if err != nil {
-		return output, err
+// watchForCancellation interrupts and then kills the process group of the given (already started) cmd when the
+// context of the command is done. The returned function must be called after the command has exited; it stops the
+// watch and reports whether the command was cancelled.
+func watchForCancellation(t testing.TestingT, command Command, cmd *exec.Cmd) func() bool {
+	if command.Context == nil {
+		return func() bool { return false }
 	}
 
-	return output, cmd.Wait()
+	gracePeriod := command.CancelGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultCancelGracePeriod
+	}
+
+	exited := make(chan struct{})
+	done := make(chan bool, 1)
+	go func() {
+		select {
+		case <-exited:
+			done <- false
+			return
+		case <-command.Context.Done():
+		}
+
+		command.Logger.Warnf(t, "Context of command %s is done (%v). Sending interrupt signal.", command.Command, command.Context.Err())
+		if err := interruptProcessGroup(cmd); err != nil {
+			command.Logger.Warnf(t, "Failed to interrupt command %s: %v", command.Command, err)
+		}
+
+		select {
+		case <-exited:
+		case <-time.After(gracePeriod):
+			command.Logger.Warnf(t, "Command %s did not exit within %s of being interrupted. Killing it.", command.Command, gracePeriod)
+			if err := killProcessGroup(cmd); err != nil {
+				command.Logger.Warnf(t, "Failed to kill command %s: %v", command.Command, err)
+			}
+		}
+		done <- true
+	}()
+
+	return func() bool {
+		close(exited)
+		return <-done
+	}
 }
 
 // This function captures stdout and stderr into the given variables while still printing it to the stdout and stderr
-// of this Go program
-func readStdoutAndStderr(t testing.TestingT, log *logger.Logger, stdout, stderr io.ReadCloser) (*output, error) {
+// of this Go program, and passes each line to the line callbacks of the command.
+func readStdoutAndStderr(t testing.TestingT, command Command, stdout, stderr io.ReadCloser) (*output, error) {
 	out := newOutput()
+	return out, readStdoutAndStderrInto(t, command, out, stdout, stderr)
+}
+
+// readStdoutAndStderrInto is like readStdoutAndStderr, but captures stdout and stderr into the given output, so that it
+// can be read while the command is still running.
+func readStdoutAndStderrInto(t testing.TestingT, command Command, out *output, stdout, stderr io.ReadCloser) error {
 	stdoutReader := bufio.NewReader(stdout)
 	stderrReader := bufio.NewReader(stderr)
 
+	// Tag the output with the name of the command (e.g., "terraform" or "helm"), for loggers that support components.
+	log := command.Logger.WithComponent(strings.TrimSuffix(filepath.Base(command.Command), filepath.Ext(command.Command)))
+
 	wg := &sync.WaitGroup{}
 
 	wg.Add(2)
 	var stdoutErr, stderrErr error
 	go func() {
 		defer wg.Done()
-		stdoutErr = readData(t, log, stdoutReader, out.stdout)
+		stdoutErr = readData(t, log, stdoutReader, out.stdout, command.OnStdoutLine)
 	}()
 	go func() {
 		defer wg.Done()
-		stderrErr = readData(t, log, stderrReader, out.stderr)
+		stderrErr = readData(t, log, stderrReader, out.stderr, command.OnStderrLine)
 	}()
 	wg.Wait()
 
 	if stdoutErr != nil {
-		return out, stdoutErr
+		return stdoutErr
 	}
-	if stderrErr != nil {
-		return out, stderrErr
-	}
-
-	return out, nil
+	return stderrErr
 }
 
-func readData(t testing.TestingT, log *logger.Logger, reader *bufio.Reader, writer io.StringWriter) error {
+func readData(t testing.TestingT, log *logger.Logger, reader *bufio.Reader, writer io.StringWriter, onLine func(string)) error {
 	var line string
 	var readErr error
 	for {
@@ -176,18 +349,22 @@ func readData(t testing.TestingT, log *logger.Logger, reader *bufio.Reader, writ
 			break
 		}
 
-		// logger.Logger has a Logf method, but not a Log method.
+		// logger.Logger has a Debugf method, but not a Debug method.
 		// We have to use the format string indirection to avoid
 		// interpreting any possible formatting characters in
 		// the line.
 		//
 		// See https://github.com/nholuongut/terratest/issues/982.
-		log.Logf(t, "%s", line)
+		log.Debugf(t, "%s", line)
 
 		if _, err := writer.WriteString(line); err != nil {
 			return err
 		}
 
+		if onLine != nil {
+			onLine(line)
+		}
+
 		if readErr != nil {
 			break
 		}
@@ -223,7 +400,10 @@ func GetExitCodeForRunCommandError(err error) (int, error) {
 }
 
 func formatEnvVars(command Command) []string {
-	env := os.Environ()
+	env := []string{}
+	if !command.DisableEnvInheritance {
+		env = os.Environ()
+	}
 	for key, value := range command.Env {
 		env = append(env, fmt.Sprintf("%s=%s", key, value))
 	}
@@ -2,10 +2,13 @@ package shell
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -183,3 +186,147 @@ func TestCommandOutputType(t *testing.T) {
 		assert.Len(t, o.Output.Combined(), len(stdout)+len(stderr)+1) // +1 for newline
 	}
 }
+
+func TestRunCommandWithContextInterruptsProcess(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	cmd := Command{
+		Command: "bash",
+		Args:    []string{"-c", `trap 'echo interrupted; exit 3' INT; echo started; sleep 30`},
+		Logger:  logger.Discard,
+		Context: ctx,
+	}
+
+	start := time.Now()
+	out, err := RunCommandAndGetOutputE(t, cmd)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, "started\ninterrupted", out)
+}
+
+func TestRunCommandWithContextKillsProcessIgnoringInterrupt(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	cmd := Command{
+		Command:           "bash",
+		Args:              []string{"-c", `trap '' INT; sleep 30`},
+		Logger:            logger.Discard,
+		Context:           ctx,
+		CancelGracePeriod: 500 * time.Millisecond,
+	}
+
+	start := time.Now()
+	err := RunCommandE(t, cmd)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestRunCommandWithCancelledContextDoesNotStart(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmd := Command{
+		Command: "echo",
+		Args:    []string{"should not run"},
+		Logger:  logger.Discard,
+		Context: ctx,
+	}
+
+	out, err := RunCommandAndGetOutputE(t, cmd)
+	assert.Equal(t, "", out)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestRunCommandWithStdin(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command: "cat",
+		Stdin:   strings.NewReader("hello\nworld\n"),
+		Logger:  logger.Discard,
+	}
+
+	out := RunCommandAndGetOutput(t, cmd)
+	assert.Equal(t, "hello\nworld", out)
+}
+
+func TestRunCommandCallsLineCallbacks(t *testing.T) {
+	t.Parallel()
+
+	stdoutLines := []string{}
+	stderrLines := []string{}
+	cmd := Command{
+		Command:      "bash",
+		Args:         []string{"-c", `echo one; echo two >&2; echo three`},
+		Logger:       logger.Discard,
+		OnStdoutLine: func(line string) { stdoutLines = append(stdoutLines, line) },
+		OnStderrLine: func(line string) { stderrLines = append(stderrLines, line) },
+	}
+
+	RunCommand(t, cmd)
+	assert.Equal(t, []string{"one", "three"}, stdoutLines)
+	assert.Equal(t, []string{"two"}, stderrLines)
+}
+
+func TestRunCommandWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	cmd := Command{
+		Command: "bash",
+		Args:    []string{"-c", `echo started; sleep 30`},
+		Logger:  logger.Discard,
+		Timeout: 500 * time.Millisecond,
+	}
+
+	start := time.Now()
+	out, err := RunCommandAndGetOutputE(t, cmd)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, "started", out)
+}
+
+func TestRunCommandWithoutEnvInheritance(t *testing.T) {
+	t.Setenv("TERRATEST_SHELL_TEST_INHERITED", "inherited")
+
+	cmd := Command{
+		Command:               "/usr/bin/env",
+		Env:                   map[string]string{"TERRATEST_SHELL_TEST_SET": "set"},
+		Logger:                logger.Discard,
+		DisableEnvInheritance: true,
+	}
+
+	out := RunCommandAndGetOutput(t, cmd)
+	assert.Equal(t, "TERRATEST_SHELL_TEST_SET=set", out)
+
+	cmd.DisableEnvInheritance = false
+	out = RunCommandAndGetOutput(t, cmd)
+	assert.Contains(t, out, "TERRATEST_SHELL_TEST_INHERITED=inherited")
+	assert.Contains(t, out, "TERRATEST_SHELL_TEST_SET=set")
+}
+
+func TestRunCommandAndGetOutputStruct(t *testing.T) {
+	t.Parallel()
+
+	result, err := RunCommandAndGetOutputStructE(t, Command{
+		Command: "bash",
+		Args:    []string{"-c", `echo out; sleep .01s; echo err >&2; sleep .01s; echo out2; exit 3`},
+		Logger:  logger.Discard,
+	})
+
+	_, isErrWithCmdOutput := err.(*ErrWithCmdOutput)
+	assert.True(t, isErrWithCmdOutput)
+	assert.Equal(t, "out\nout2", result.Stdout)
+	assert.Equal(t, "err", result.Stderr)
+	assert.Equal(t, "out\nerr\nout2", result.Combined)
+	assert.Equal(t, []OutputLine{{Text: "out"}, {Text: "err", Stderr: true}, {Text: "out2"}}, result.Lines)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Greater(t, result.Duration, time.Duration(0))
+}
@@ -22,6 +22,7 @@ func newOutput() *output {
 		},
 		stderr: &outputStream{
 			merged: m,
+			stderr: true,
 		},
 	}
 }
@@ -50,14 +51,30 @@ func (o *output) Combined() string {
 	return o.merged.String()
 }
 
+// Lines returns the lines of stdout and stderr, interleaved in the order they were read, with the stream of each line.
+func (o *output) Lines() []OutputLine {
+	if o == nil {
+		return nil
+	}
+
+	o.merged.Lock()
+	defer o.merged.Unlock()
+
+	return append([]OutputLine{}, o.merged.lines...)
+}
+
 type outputStream struct {
 	Lines []string
 	*merged
+	stderr bool
 }
 
 func (st *outputStream) WriteString(s string) (n int, err error) {
+	st.merged.Lock()
+	defer st.merged.Unlock()
+
 	st.Lines = append(st.Lines, string(s))
-	return st.merged.WriteString(s)
+	return st.merged.writeLine(s, st.stderr)
 }
 
 func (st *outputStream) String() string {
@@ -65,13 +82,19 @@ func (st *outputStream) String() string {
 		return ""
 	}
 
+	// The output of a background command is read while it is still being written.
+	st.merged.Lock()
+	defer st.merged.Unlock()
+
 	return strings.Join(st.Lines, "\n")
 }
 
 type merged struct {
-	// ensure that there are no parallel writes
+	// ensure that there are no parallel writes, and no reads during writes
 	sync.Mutex
 	Lines []string
+	// lines contains the same lines as Lines, along with the stream each line was written to.
+	lines []OutputLine
 }
 
 func (m *merged) String() string {
@@ -79,6 +102,9 @@ func (m *merged) String() string {
 		return ""
 	}
 
+	m.Lock()
+	defer m.Unlock()
+
 	return strings.Join(m.Lines, "\n")
 }
 
@@ -86,7 +112,13 @@ func (m *merged) WriteString(s string) (n int, err error) {
 	m.Lock()
 	defer m.Unlock()
 
+	return m.writeLine(s, false)
+}
+
+// writeLine appends the given line. The caller must hold the lock.
+func (m *merged) writeLine(s string, stderr bool) (n int, err error) {
 	m.Lines = append(m.Lines, string(s))
+	m.lines = append(m.lines, OutputLine{Text: s, Stderr: stderr})
 
 	return len(s), nil
 }
@@ -0,0 +1,21 @@
+//go:build windows
+
+package shell
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows, which has no process groups in the POSIX sense.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// interruptProcessGroup kills the process of the given started cmd. Windows does not support sending an interrupt to
+// another process, so there is no graceful shutdown.
+func interruptProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killProcessGroup kills the process of the given started cmd.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -52,7 +52,7 @@ func ScpFileTo(t testing.TestingT, host Host, mode os.FileMode, remotePath, cont
 
 // ScpFileToE uploads the contents using SCP to the given host and return an error if the process fails.
 func ScpFileToE(t testing.TestingT, host Host, mode os.FileMode, remotePath, contents string) error {
-	authMethods, err := createAuthMethodsForHost(host)
+	authMethods, err := createAuthMethodsForHost(t, host)
 	if err != nil {
 		return err
 	}
@@ -91,7 +91,7 @@ func ScpFileFrom(t testing.TestingT, host Host, remotePath string, localDestinat
 
 // ScpFileFromE downloads the file from remotePath on the given host using SCP and returns an error if the process fails.
 func ScpFileFromE(t testing.TestingT, host Host, remotePath string, localDestination *os.File, useSudo bool) error {
-	authMethods, err := createAuthMethodsForHost(host)
+	authMethods, err := createAuthMethodsForHost(t, host)
 
 	if err != nil {
 		return err
@@ -131,7 +131,7 @@ func ScpDirFrom(t testing.TestingT, options ScpDownloadOptions, useSudo bool) {
 // be downloaded. This function will not recursively download subdirectories or follow
 // symlinks.
 func ScpDirFromE(t testing.TestingT, options ScpDownloadOptions, useSudo bool) error {
-	authMethods, err := createAuthMethodsForHost(options.RemoteHost)
+	authMethods, err := createAuthMethodsForHost(t, options.RemoteHost)
 	if err != nil {
 		return err
 	}
@@ -238,7 +238,7 @@ func CheckSshCommand(t testing.TestingT, host Host, command string) string {
 
 // CheckSshCommandE checks that you can connect via SSH to the given host and run the given command. Returns the stdout/stderr.
 func CheckSshCommandE(t testing.TestingT, host Host, command string) (string, error) {
-	authMethods, err := createAuthMethodsForHost(host)
+	authMethods, err := createAuthMethodsForHost(t, host)
 	if err != nil {
 		return "", err
 	}
@@ -302,7 +302,7 @@ func CheckPrivateSshConnection(t testing.TestingT, publicHost Host, privateHost
 // separate publicHost (which is addressable from the Internet) and then executes "command" on privateHost and returns
 // its output. It is useful for checking that it's possible to SSH from a Bastion Host to a private instance.
 func CheckPrivateSshConnectionE(t testing.TestingT, publicHost Host, privateHost Host, command string) (string, error) {
-	jumpHostAuthMethods, err := createAuthMethodsForHost(publicHost)
+	jumpHostAuthMethods, err := createAuthMethodsForHost(t, publicHost)
 	if err != nil {
 		return "", err
 	}
@@ -314,7 +314,7 @@ func CheckPrivateSshConnectionE(t testing.TestingT, publicHost Host, privateHost
 		AuthMethods: jumpHostAuthMethods,
 	}
 
-	hostAuthMethods, err := createAuthMethodsForHost(privateHost)
+	hostAuthMethods, err := createAuthMethodsForHost(t, privateHost)
 	if err != nil {
 		return "", err
 	}
@@ -571,8 +571,9 @@ func NoOpHostKeyCallback(hostname string, remote net.Addr, key ssh.PublicKey) er
 	return nil
 }
 
-// Returns an array of authentication methods
-func createAuthMethodsForHost(host Host) ([]ssh.AuthMethod, error) {
+// Returns an array of authentication methods. The password and private key of the host are registered as secrets, so
+// that they are redacted from the logs.
+func createAuthMethodsForHost(t testing.TestingT, host Host) ([]ssh.AuthMethod, error) {
 	var methods []ssh.AuthMethod
 
 	// override local ssh agent with given sshAgent instance
@@ -600,6 +601,7 @@ func createAuthMethodsForHost(host Host) ([]ssh.AuthMethod, error) {
 
 	// use provided ssh key pair
 	if host.SshKeyPair != nil {
+		logger.RegisterSecret(t, host.SshKeyPair.PrivateKey)
 		signer, err := ssh.ParsePrivateKey([]byte(host.SshKeyPair.PrivateKey))
 		if err != nil {
 			return methods, err
@@ -609,6 +611,7 @@ func createAuthMethodsForHost(host Host) ([]ssh.AuthMethod, error) {
 
 	// Use given password
 	if len(host.Password) > 0 {
+		logger.RegisterSecret(t, host.Password)
 		methods = append(methods, []ssh.AuthMethod{ssh.Password(host.Password)}...)
 	}
 
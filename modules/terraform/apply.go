@@ -52,7 +52,7 @@ func ApplyE(t testing.TestingT, options *Options) (string, error) {
 // TgApplyAllE runs terragrunt apply-all with the given options and return stdout/stderr. Note that this method does NOT call destroy and
 // assumes the caller is responsible for cleaning up any resources created by running apply.
 func TgApplyAllE(t testing.TestingT, options *Options) (string, error) {
-	if options.TerraformBinary != "terragrunt" {
+	if !isTerragrunt(options) {
 		return "", TgInvalidBinary(options.TerraformBinary)
 	}
 
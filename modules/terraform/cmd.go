@@ -1,12 +1,14 @@
 package terraform
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
 	"strings"
 
 	"github.com/nholuongut/terratest/modules/collections"
+	"github.com/nholuongut/terratest/modules/logger"
 	"github.com/nholuongut/terratest/modules/retry"
 	"github.com/nholuongut/terratest/modules/shell"
 	"github.com/nholuongut/terratest/modules/testing"
@@ -19,6 +21,7 @@ func generateCommand(options *Options, args ...string) shell.Command {
 		WorkingDir: options.TerraformDir,
 		Env:        options.EnvVars,
 		Logger:     options.Logger,
+		Context:    options.Context,
 	}
 	return cmd
 }
@@ -39,18 +42,24 @@ const (
 
 	// TerraformDefaultPath to run terraform
 	TerraformDefaultPath = "terraform"
+
+	// TerragruntDefaultPath to run terragrunt
+	TerragruntDefaultPath = "terragrunt"
 )
 
 var DefaultExecutable = defaultTerraformExecutable()
 
 // GetCommonOptions extracts commons terraform options
 func GetCommonOptions(options *Options, args ...string) (*Options, []string) {
+	if options.TerraformBinary == "" && options.Terragrunt != nil {
+		options.TerraformBinary = TerragruntDefaultPath
+	}
 	if options.TerraformBinary == "" {
 		options.TerraformBinary = DefaultExecutable
 	}
 
-	if options.TerraformBinary == "terragrunt" {
-		args = append(args, "--terragrunt-non-interactive")
+	if isTerragrunt(options) {
+		args = append(args, FormatTerragruntArgs(options.Terragrunt)...)
 	}
 
 	if options.Parallelism > 0 && len(args) > 0 && collections.ListContains(commandsWithParallelism, args[0]) {
@@ -84,12 +93,12 @@ func RunTerraformCommandE(t testing.TestingT, additionalOptions *Options, additi
 	cmd := generateCommand(options, args...)
 	description := fmt.Sprintf("%s %v", options.TerraformBinary, args)
 
-	return retry.DoWithRetryableErrorsE(t, description, options.RetryableTerraformErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
+	return retryCommandE(t, options, args, description, func() (string, error) {
 		s, err := shell.RunCommandAndGetOutputE(t, cmd)
 		if err != nil {
-			return s, err
+			return s, fatalIfCancelled(options, withDiagnostics(options, args, s, err))
 		}
-		if err := hasWarning(additionalOptions, s); err != nil {
+		if err := hasWarning(additionalOptions, args, s); err != nil {
 			return s, err
 		}
 		return s, err
@@ -97,6 +106,82 @@ func RunTerraformCommandE(t testing.TestingT, additionalOptions *Options, additi
 
 }
 
+// retryCommandE runs the given action, retrying errors matching options.RetryableTerraformErrors as configured by
+// options.RetryPolicy, or by options.MaxRetries and options.TimeBetweenRetries if no policy is set. If the command runs
+// with the machine readable UI, the retryable errors are matched against the summary and detail of the diagnostics
+// terraform reported, rather than against its text output.
+func retryCommandE(t testing.TestingT, options *Options, args []string, description string, action func() (string, error)) (string, error) {
+	if usesJsonUI(options, args) {
+		retryableAction, err := withRetryableDiagnostics(t, description, options.RetryableTerraformErrors, action)
+		if err != nil {
+			return "", err
+		}
+		if options.RetryPolicy == nil {
+			return retry.DoE(t, description, options.MaxRetries, options.TimeBetweenRetries, retryableAction)
+		}
+		return retry.DoWithPolicyE(t, description, retryPolicy(options), retryableAction)
+	}
+
+	if options.RetryPolicy == nil {
+		return retry.DoWithRetryableErrorsE(t, description, options.RetryableTerraformErrors, options.MaxRetries, options.TimeBetweenRetries, action)
+	}
+	return retry.DoWithRetryableErrorsAndPolicyE(t, description, options.RetryableTerraformErrors, retryPolicy(options), action)
+}
+
+// retryPolicy returns a copy of options.RetryPolicy that stops retrying when options.Context is done, unless the policy
+// has a context of its own.
+func retryPolicy(options *Options) *retry.Policy {
+	policy := *options.RetryPolicy
+	if policy.Context == nil {
+		policy.Context = options.Context
+	}
+	return &policy
+}
+
+// withRetryableDiagnostics wraps the given action of a command run with the machine readable UI, so that the errors it
+// returns are wrapped in a retry.FatalError, unless the summary or detail of one of the diagnostics of the error
+// matches any of the regular expressions in the given retryableErrors map. Errors without diagnostics are matched
+// against the error message and the output of the action instead.
+func withRetryableDiagnostics(t testing.TestingT, description string, retryableErrors map[string]string, action func() (string, error)) (func() (string, error), error) {
+	retryableErrorsRegexp := map[*regexp.Regexp]string{}
+	for errorStr, errorMessage := range retryableErrors {
+		errorRegex, err := regexp.Compile(errorStr)
+		if err != nil {
+			return nil, retry.FatalError{Underlying: err}
+		}
+		retryableErrorsRegexp[errorRegex] = errorMessage
+	}
+
+	return func() (string, error) {
+		out, err := action()
+		if err == nil {
+			return out, nil
+		}
+		if _, isFatalErr := err.(retry.FatalError); isFatalErr {
+			return out, err
+		}
+
+		texts := []string{out, err.Error()}
+		var diagErr DiagnosticsError
+		if errors.As(err, &diagErr) {
+			texts = []string{}
+			for _, diag := range diagErr.Diagnostics {
+				texts = append(texts, diag.Summary, diag.Detail)
+			}
+		}
+
+		for errorRegexp, errorMessage := range retryableErrorsRegexp {
+			for _, text := range texts {
+				if errorRegexp.MatchString(text) {
+					logger.Default.Debugf(t, "'%s' failed with the error '%s' but this error was expected and warrants a retry. Further details: %s\n", description, err.Error(), errorMessage)
+					return out, err
+				}
+			}
+		}
+		return out, retry.FatalError{Underlying: err}
+	}, nil
+}
+
 // RunTerraformCommandAndGetStdoutE runs terraform with the given arguments and options and returns solely its stdout
 // (but not stderr).
 func RunTerraformCommandAndGetStdoutE(t testing.TestingT, additionalOptions *Options, additionalArgs ...string) (string, error) {
@@ -104,12 +189,12 @@ func RunTerraformCommandAndGetStdoutE(t testing.TestingT, additionalOptions *Opt
 
 	cmd := generateCommand(options, args...)
 	description := fmt.Sprintf("%s %v", options.TerraformBinary, args)
-	return retry.DoWithRetryableErrorsE(t, description, options.RetryableTerraformErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
+	return retryCommandE(t, options, args, description, func() (string, error) {
 		s, err := shell.RunCommandAndGetOutputE(t, cmd)
 		if err != nil {
-			return s, err
+			return s, fatalIfCancelled(options, withDiagnostics(options, args, s, err))
 		}
-		if err := hasWarning(additionalOptions, s); err != nil {
+		if err := hasWarning(additionalOptions, args, s); err != nil {
 			return s, err
 		}
 		return s, err
@@ -156,7 +241,33 @@ func defaultTerraformExecutable() string {
 	return TofuDefaultPath
 }
 
-func hasWarning(opts *Options, out string) error {
+// fatalIfCancelled wraps the given error in a retry.FatalError if the context of the options is done, so that a
+// cancelled command is not retried, even if its output matches one of the retryable errors.
+func fatalIfCancelled(options *Options, err error) error {
+	if options.Context != nil && options.Context.Err() != nil {
+		return retry.FatalError{Underlying: err}
+	}
+	return err
+}
+
+// usesJsonUI returns whether the given terraform command runs with the machine readable UI, i.e. whether FormatArgs
+// adds -json to it because options.JsonUI is set and the command is one of TerraformCommandsWithJsonUISupport.
+func usesJsonUI(options *Options, args []string) bool {
+	if !options.JsonUI || len(args) == 0 {
+		return false
+	}
+	commandType := args[0]
+	if commandType == runAllCmd && len(args) > 1 {
+		commandType = args[1]
+	}
+	return collections.ListContains(TerraformCommandsWithJsonUISupport, commandType)
+}
+
+func hasWarning(opts *Options, args []string, out string) error {
+	if usesJsonUI(opts, args) {
+		return hasWarningDiagnostic(opts, out)
+	}
+
 	for k, v := range opts.WarningsAsErrors {
 		str := fmt.Sprintf("\nWarning: %s[^\n]*\n", k)
 		re, err := regexp.Compile(str)
@@ -171,3 +282,33 @@ func hasWarning(opts *Options, out string) error {
 	}
 	return nil
 }
+
+// hasWarningDiagnostic is the equivalent of hasWarning for the output of commands run with the machine readable UI,
+// which matches the WarningsAsErrors against the summary of the warning diagnostics instead of scraping the text.
+func hasWarningDiagnostic(opts *Options, out string) error {
+	if len(opts.WarningsAsErrors) == 0 {
+		return nil
+	}
+	events, err := ParseUIEvents(out)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range opts.WarningsAsErrors {
+		re, err := regexp.Compile(k)
+		if err != nil {
+			return fmt.Errorf("cannot compile regex for warning detection: %w", err)
+		}
+		matched := []Diagnostic{}
+		for _, diag := range events.Warnings() {
+			if re.MatchString(diag.Summary) {
+				matched = append(matched, diag)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		return fmt.Errorf("warning(s) were found: %s: %w", v, DiagnosticsError{Diagnostics: matched})
+	}
+	return nil
+}
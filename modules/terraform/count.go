@@ -38,7 +38,15 @@ func GetResourceCount(t testing.TestingT, cmdout string) *ResourceCount {
 }
 
 // GetResourceCountE parses stdout/stderr of apply/plan/destroy commands and returns number of affected resources.
+// Both the human readable output and the machine readable UI (-json) output are supported.
 func GetResourceCountE(t testing.TestingT, cmdout string) (*ResourceCount, error) {
+	// Output of commands run with the machine readable UI (see Options.JsonUI) contains a structured change summary.
+	if events, err := ParseUIEvents(cmdout); err == nil {
+		if summary := events.ChangeSummary(); summary != nil {
+			return &ResourceCount{Add: summary.Add, Change: summary.Change, Destroy: summary.Remove}, nil
+		}
+	}
+
 	cnt := ResourceCount{}
 
 	terraformCommandPatterns := []struct {
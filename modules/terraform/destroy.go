@@ -1,10 +1,16 @@
 package terraform
 
 import (
+	"context"
+	"time"
+
 	"github.com/nholuongut/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
 
+// DefaultCleanupTimeout is how long destroy may run by default if Options.Context is set. See Options.CleanupTimeout.
+const DefaultCleanupTimeout = 30 * time.Minute
+
 // Destroy runs terraform destroy with the given options and return stdout/stderr.
 func Destroy(t testing.TestingT, options *Options) string {
 	out, err := DestroyE(t, options)
@@ -21,14 +27,39 @@ func TgDestroyAll(t testing.TestingT, options *Options) string {
 
 // DestroyE runs terraform destroy with the given options and return stdout/stderr.
 func DestroyE(t testing.TestingT, options *Options) (string, error) {
+	options, cancel := withCleanupContext(options)
+	defer cancel()
+
 	return RunTerraformCommandE(t, options, FormatArgs(options, "destroy", "-auto-approve", "-input=false")...)
 }
 
 // TgDestroyAllE runs terragrunt destroy with the given options and return stdout.
 func TgDestroyAllE(t testing.TestingT, options *Options) (string, error) {
-	if options.TerraformBinary != "terragrunt" {
+	if !isTerragrunt(options) {
 		return "", TgInvalidBinary(options.TerraformBinary)
 	}
 
+	options, cancel := withCleanupContext(options)
+	defer cancel()
+
 	return RunTerraformCommandE(t, options, FormatArgs(options, "run-all", "destroy", "-auto-approve", "-input=false")...)
 }
+
+// withCleanupContext returns the options to destroy with. If the given options have a context, it is replaced by one
+// that is not done when that context is done, but when options.CleanupTimeout elapses, so that the resources of a test
+// are still cleaned up if its context expired (e.g., because it was bounded by t.Deadline()). The returned function
+// releases the resources of that context.
+func withCleanupContext(options *Options) (*Options, context.CancelFunc) {
+	if options.Context == nil {
+		return options, func() {}
+	}
+
+	timeout := options.CleanupTimeout
+	if timeout <= 0 {
+		timeout = DefaultCleanupTimeout
+	}
+	cleanupOptions := *options
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(options.Context), timeout)
+	cleanupOptions.Context = ctx
+	return &cleanupOptions, cancel
+}
@@ -0,0 +1,44 @@
+package terraform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestroyRunsAfterContextIsDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// echo stands in for terraform, so that this only tests that the command is run at all.
+	options := &Options{TerraformBinary: "echo", TerraformDir: t.TempDir(), Context: ctx}
+	out, err := DestroyE(t, options)
+	require.NoError(t, err)
+	assert.Contains(t, out, "destroy -auto-approve -input=false")
+	assert.Equal(t, ctx, options.Context)
+}
+
+func TestWithCleanupContext(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{}
+	cleanupOptions, cancel := withCleanupContext(options)
+	cancel()
+	assert.Same(t, options, cleanupOptions)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	cancelCtx()
+	options = &Options{Context: ctx, CleanupTimeout: time.Minute}
+	cleanupOptions, cancel = withCleanupContext(options)
+	defer cancel()
+
+	assert.NoError(t, cleanupOptions.Context.Err())
+	deadline, hasDeadline := cleanupOptions.Context.Deadline()
+	require.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 10*time.Second)
+}
@@ -0,0 +1,46 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diagnostic is a warning or error reported by Terraform in its machine readable (-json) output. See
+// https://developer.hashicorp.com/terraform/internals/machine-readable-ui#diagnostic for details.
+type Diagnostic struct {
+	Severity string           `json:"severity"`
+	Summary  string           `json:"summary"`
+	Detail   string           `json:"detail"`
+	Address  string           `json:"address,omitempty"`
+	Range    *DiagnosticRange `json:"range,omitempty"`
+}
+
+// DiagnosticRange is the source code location a Diagnostic refers to.
+type DiagnosticRange struct {
+	Filename string        `json:"filename"`
+	Start    DiagnosticPos `json:"start"`
+	End      DiagnosticPos `json:"end"`
+}
+
+// DiagnosticPos is a position in a source file.
+type DiagnosticPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+// Error implements the error interface, so that error diagnostics can be returned as errors.
+func (diag Diagnostic) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s", diag.Severity, diag.Summary)
+	if diag.Address != "" {
+		fmt.Fprintf(&sb, " (%s)", diag.Address)
+	}
+	if diag.Range != nil {
+		fmt.Fprintf(&sb, " at %s:%d,%d", diag.Range.Filename, diag.Range.Start.Line, diag.Range.Start.Column)
+	}
+	if diag.Detail != "" {
+		fmt.Fprintf(&sb, ": %s", diag.Detail)
+	}
+	return sb.String()
+}
@@ -3,14 +3,15 @@ package terraform
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // TgInvalidBinary occurs when a terragrunt function is called and the TerraformBinary is
-// set to a value other than terragrunt
+// set to a value other than terragrunt, and no TerragruntOptions are set
 type TgInvalidBinary string
 
 func (err TgInvalidBinary) Error() string {
-	return fmt.Sprintf("terragrunt must be set as TerraformBinary to use this function. [ TerraformBinary : %s ]", string(err))
+	return fmt.Sprintf("terragrunt must be set as TerraformBinary (or Terragrunt options must be set) to use this function. [ TerraformBinary : %s ]", string(err))
 }
 
 // OutputKeyNotFound occurs when terraform output does not contain a value for the key
@@ -101,3 +102,77 @@ type WorkspaceDoesNotExist string
 func (err WorkspaceDoesNotExist) Error() string {
 	return fmt.Sprintf("The workspace %q does not exist.", string(err))
 }
+
+// ResourceNotFoundInState is returned when the state does not contain a resource with the given address
+type ResourceNotFoundInState string
+
+func (err ResourceNotFoundInState) Error() string {
+	return fmt.Sprintf("state doesn't contain a resource with the address %q", string(err))
+}
+
+// AttributeNotFound is returned when a resource does not have an attribute at the given path
+type AttributeNotFound struct {
+	Address string
+	Path    string
+}
+
+func (err AttributeNotFound) Error() string {
+	return fmt.Sprintf("resource %q doesn't have an attribute at path %q", err.Address, err.Path)
+}
+
+// ResourceChangeNotFound is returned when the plan does not contain a change for a resource with the given address
+type ResourceChangeNotFound string
+
+func (err ResourceChangeNotFound) Error() string {
+	return fmt.Sprintf("plan doesn't contain a change for a resource with the address %q", string(err))
+}
+
+// MockPlanFailed is returned when planning a module with mocked providers did not produce a plan
+type MockPlanFailed struct {
+	Run         *TestRunResult
+	Diagnostics []Diagnostic
+}
+
+func (err MockPlanFailed) Error() string {
+	diags := append([]Diagnostic{}, err.Diagnostics...)
+	status := "not run"
+	if err.Run != nil {
+		diags = append(diags, err.Run.Diagnostics...)
+		status = err.Run.Status
+	}
+
+	messages := []string{}
+	for _, diag := range diags {
+		messages = append(messages, diag.Error())
+	}
+	return fmt.Sprintf("planning with mocked providers failed (status: %s): %s", status, strings.Join(messages, "; "))
+}
+
+// DiagnosticsError occurs when a terraform command run with the machine readable UI (see Options.JsonUI) fails with
+// error diagnostics, or when warning diagnostics match Options.WarningsAsErrors
+type DiagnosticsError struct {
+	Diagnostics []Diagnostic
+	Underlying  error
+}
+
+func (err DiagnosticsError) Error() string {
+	messages := []string{}
+	for _, diag := range err.Diagnostics {
+		messages = append(messages, diag.Error())
+	}
+	if err.Underlying == nil {
+		return strings.Join(messages, "; ")
+	}
+	return fmt.Sprintf("%s: %s", err.Underlying, strings.Join(messages, "; "))
+}
+
+func (err DiagnosticsError) Unwrap() error {
+	return err.Underlying
+}
+
+// ModuleNotFound occurs when LoadModule is called on a folder that doesn't contain any terraform files
+type ModuleNotFound string
+
+func (err ModuleNotFound) Error() string {
+	return fmt.Sprintf("no terraform module found in folder %s", string(err))
+}
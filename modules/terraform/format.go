@@ -36,6 +36,14 @@ var TerraformCommandsWithPlanFileSupport = []string{
 	"graph",
 }
 
+// TerraformCommandsWithJsonUISupport is a list of all the Terraform commands that can stream their output in the
+// machine readable UI (-json).
+var TerraformCommandsWithJsonUISupport = []string{
+	"plan",
+	"apply",
+	"destroy",
+}
+
 // FormatArgs converts the inputs to a format palatable to terraform. This includes converting the given vars to the
 // format the Terraform CLI expects (-var key=value).
 func FormatArgs(options *Options, args ...string) []string {
@@ -49,6 +57,7 @@ func FormatArgs(options *Options, args ...string) []string {
 	}
 	lockSupported := collections.ListContains(TerraformCommandsWithLockSupport, commandType)
 	planFileSupported := collections.ListContains(TerraformCommandsWithPlanFileSupport, commandType)
+	jsonUISupported := collections.ListContains(TerraformCommandsWithJsonUISupport, commandType)
 
 	// Include -var and -var-file flags unless we're running 'apply' with a plan file
 	includeVars := !(commandType == "apply" && len(options.PlanFilePath) > 0)
@@ -71,6 +80,10 @@ func FormatArgs(options *Options, args ...string) []string {
 		terraformArgs = append(terraformArgs, "-no-color")
 	}
 
+	if options.JsonUI && jsonUISupported {
+		terraformArgs = append(terraformArgs, "-json")
+	}
+
 	if lockSupported {
 		// If command supports locking, handle lock arguments
 		terraformArgs = append(terraformArgs, FormatTerraformLockAsArgs(options.Lock, options.LockTimeout)...)
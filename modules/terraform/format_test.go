@@ -312,3 +312,22 @@ func TestFormatSetVarsAfterVarFilesFormatsCorrectly(t *testing.T) {
 		assert.Equal(t, testCase.expected[len(testCase.expected)-1], result[len(result)-1])
 	}
 }
+
+func TestFormatArgsAppliesJsonUICorrectly(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		command  []string
+		expected []string
+	}{
+		{[]string{"plan"}, []string{"plan", "-json", "-lock=false"}},
+		{[]string{"apply"}, []string{"apply", "-json", "-lock=false"}},
+		{[]string{"destroy"}, []string{"destroy", "-json", "-lock=false"}},
+		{[]string{"validate"}, []string{"validate"}},
+		{[]string{"run-all", "apply"}, []string{"run-all", "apply", "-json", "-lock=false"}},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, FormatArgs(&Options{JsonUI: true}, testCase.command...))
+	}
+}
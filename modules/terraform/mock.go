@@ -0,0 +1,147 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// MockTestFileName is the name of the test file generated by WriteMockTestFile.
+const MockTestFileName = "terratest_mocks.tftest.hcl"
+
+// mockPlanRunName is the name of the run block in the generated mock test file that plans the module.
+const mockPlanRunName = "terratest_mock_plan"
+
+// MockOptions configure how the providers and resources of a module are mocked, so that the module can be planned
+// without credentials for (or network access to) the real cloud APIs.
+type MockOptions struct {
+	// The providers to replace with mock providers (e.g., "aws"). Providers of the types in Resources and DataSources
+	// (e.g., "aws" for "aws_instance") are always mocked, so this is only needed for providers without mocked values.
+	Providers []string
+
+	// Mocked attribute values for all resources of a given type, keyed by resource type (e.g., "aws_instance").
+	// Computed attributes that are not set here are filled in with generated values by terraform.
+	Resources map[string]map[string]interface{}
+
+	// Mocked attribute values for all data sources of a given type, keyed by data source type (e.g., "aws_ami").
+	DataSources map[string]map[string]interface{}
+
+	// Attribute values for specific resources, keyed by resource address (e.g., "module.vpc.aws_vpc.main"). These take
+	// precedence over the values in Resources.
+	ResourceOverrides map[string]map[string]interface{}
+}
+
+// WriteMockTestFile writes a terraform test file to the given terraform module folder (which should be a copy made
+// with test_structure.CopyTerraformFolderToTemp or files.CopyTerraformFolderToTemp) that replaces the providers of the
+// module with mock_provider blocks and the given resources with override_resource blocks, and contains a single run
+// block that plans the module. Returns the path to the file. This will fail the test if the file cannot be written.
+//
+// Note that mock_provider and override_resource blocks are only supported in test files, and require Terraform 1.7 or
+// newer (or OpenTofu 1.8 or newer).
+func WriteMockTestFile(t testing.TestingT, terraformDir string, mocks *MockOptions) string {
+	path, err := WriteMockTestFileE(t, terraformDir, mocks)
+	require.NoError(t, err)
+	return path
+}
+
+// WriteMockTestFileE writes a terraform test file to the given terraform module folder that replaces the providers of
+// the module with mock_provider blocks and the given resources with override_resource blocks, and contains a single
+// run block that plans the module. Returns the path to the file.
+func WriteMockTestFileE(t testing.TestingT, terraformDir string, mocks *MockOptions) (string, error) {
+	path := filepath.Join(terraformDir, MockTestFileName)
+	if err := os.WriteFile(path, []byte(formatMockTestFile(mocks)), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// InitAndPlanWithMocks writes a test file with the given mocks to options.TerraformDir (see WriteMockTestFile), runs
+// terraform init, and then plans the module with mocked providers by running terraform test, and parses the plan into
+// a go struct. This will fail the test if there is an error in the commands or if the plan fails.
+func InitAndPlanWithMocks(t testing.TestingT, options *Options, mocks *MockOptions) *PlanStruct {
+	plan, err := InitAndPlanWithMocksE(t, options, mocks)
+	require.NoError(t, err)
+	return plan
+}
+
+// InitAndPlanWithMocksE writes a test file with the given mocks to options.TerraformDir (see WriteMockTestFile), runs
+// terraform init, and then plans the module with mocked providers by running terraform test, and parses the plan into
+// a go struct.
+func InitAndPlanWithMocksE(t testing.TestingT, options *Options, mocks *MockOptions) (*PlanStruct, error) {
+	if _, err := WriteMockTestFileE(t, options.TerraformDir, mocks); err != nil {
+		return nil, err
+	}
+	if _, err := InitE(t, options); err != nil {
+		return nil, err
+	}
+
+	results, err := runTestE(t, options, true, MockTestFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	run := results.Run(MockTestFileName, mockPlanRunName)
+	if run == nil || run.Status != TestStatusPass || run.Plan == nil {
+		return nil, MockPlanFailed{Run: run, Diagnostics: results.Diagnostics}
+	}
+	return run.Plan, nil
+}
+
+// formatMockTestFile renders the contents of the terraform test file for the given mocks.
+func formatMockTestFile(mocks *MockOptions) string {
+	providers := map[string]bool{}
+	for _, provider := range mocks.Providers {
+		providers[provider] = true
+	}
+	for resourceType := range mocks.Resources {
+		providers[providerForType(resourceType)] = true
+	}
+	for dataSourceType := range mocks.DataSources {
+		providers[providerForType(dataSourceType)] = true
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# This file was generated by terratest to plan the module with mocked providers.\n")
+
+	for _, provider := range sortedKeys(providers) {
+		fmt.Fprintf(&sb, "\nmock_provider %q {\n", provider)
+		for _, resourceType := range sortedKeys(mocks.Resources) {
+			if providerForType(resourceType) == provider {
+				fmt.Fprintf(&sb, "  mock_resource %q {\n    defaults = %s\n  }\n", resourceType, toHclString(mocks.Resources[resourceType], true))
+			}
+		}
+		for _, dataSourceType := range sortedKeys(mocks.DataSources) {
+			if providerForType(dataSourceType) == provider {
+				fmt.Fprintf(&sb, "  mock_data %q {\n    defaults = %s\n  }\n", dataSourceType, toHclString(mocks.DataSources[dataSourceType], true))
+			}
+		}
+		sb.WriteString("}\n")
+	}
+
+	for _, address := range sortedKeys(mocks.ResourceOverrides) {
+		fmt.Fprintf(&sb, "\noverride_resource {\n  target = %s\n  values = %s\n}\n", address, toHclString(mocks.ResourceOverrides[address], true))
+	}
+
+	fmt.Fprintf(&sb, "\nrun %q {\n  command = plan\n}\n", mockPlanRunName)
+	return sb.String()
+}
+
+// providerForType returns the (local) name of the provider of the given resource or data source type, which by
+// convention is the prefix of the type up to the first underscore (e.g., "aws" for "aws_instance").
+func providerForType(resourceType string) string {
+	return strings.SplitN(resourceType, "_", 2)[0]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
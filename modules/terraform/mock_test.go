@@ -0,0 +1,85 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatMockTestFile(t *testing.T) {
+	t.Parallel()
+
+	mocks := &MockOptions{
+		Providers: []string{"random"},
+		Resources: map[string]map[string]interface{}{
+			"aws_instance": {"arn": "arn:aws:ec2:us-east-1:123456789012:instance/i-123"},
+		},
+		DataSources: map[string]map[string]interface{}{
+			"aws_ami": {"id": "ami-123"},
+		},
+		ResourceOverrides: map[string]map[string]interface{}{
+			`module.app["blue"].aws_instance.this`: {"id": "i-456"},
+		},
+	}
+
+	expected := `# This file was generated by terratest to plan the module with mocked providers.
+
+mock_provider "aws" {
+  mock_resource "aws_instance" {
+    defaults = {"arn" = "arn:aws:ec2:us-east-1:123456789012:instance/i-123"}
+  }
+  mock_data "aws_ami" {
+    defaults = {"id" = "ami-123"}
+  }
+}
+
+mock_provider "random" {
+}
+
+override_resource {
+  target = module.app["blue"].aws_instance.this
+  values = {"id" = "i-456"}
+}
+
+run "terratest_mock_plan" {
+  command = plan
+}
+`
+	assert.Equal(t, expected, formatMockTestFile(mocks))
+}
+
+func TestParseTestJSONOutputWithPlan(t *testing.T) {
+	t.Parallel()
+
+	out := `{"@testfile":"terratest_mocks.tftest.hcl","@testrun":"terratest_mock_plan","test_plan":{"format_version":"1.2","resource_changes":[{"address":"aws_instance.example","change":{"actions":["create"],"after":{"instance_type":"t3.micro"}}}]},"type":"test_plan"}
+{"@testfile":"terratest_mocks.tftest.hcl","@testrun":"terratest_mock_plan","test_run":{"path":"terratest_mocks.tftest.hcl","run":"terratest_mock_plan","status":"pass"},"type":"test_run"}`
+
+	results, err := ParseTestJSONOutput(out)
+	require.NoError(t, err)
+
+	run := results.Run(MockTestFileName, mockPlanRunName)
+	require.NotNil(t, run)
+	require.NotNil(t, run.Plan)
+	RequireResourceChangesMapKeyExists(t, run.Plan, "aws_instance.example")
+}
+
+func TestInitAndPlanWithMocks(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-mock-provider", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	plan := InitAndPlanWithMocks(t, options, &MockOptions{
+		DataSources: map[string]map[string]interface{}{
+			"aws_ami": {"id": "ami-12345678"},
+		},
+	})
+
+	RequireResourceChangesMapKeyExists(t, plan, "aws_instance.example")
+	RequireAttributeChange(t, plan, "aws_instance.example", "ami", nil, "ami-12345678")
+}
@@ -0,0 +1,213 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Module is the static representation of a terraform module, as declared in its source code. It is loaded by parsing
+// the .tf and .tf.json files of the module, without running terraform init, so it can be used to write offline policy
+// tests, such as "every variable has a description" or "module sources are pinned".
+type Module struct {
+	// The folder the module was loaded from.
+	Path string
+
+	// The declared variables of the module, keyed by name.
+	Variables map[string]*ModuleVariable
+
+	// The declared outputs of the module, keyed by name.
+	Outputs map[string]*tfconfig.Output
+
+	// The managed resources of the module, keyed by address (e.g., "aws_instance.web").
+	ManagedResources map[string]*tfconfig.Resource
+
+	// The data sources of the module, keyed by address (e.g., "data.aws_ami.ubuntu").
+	DataResources map[string]*tfconfig.Resource
+
+	// The module calls of the module, keyed by name, with their sources and versions.
+	ModuleCalls map[string]*tfconfig.ModuleCall
+
+	// The providers in the required_providers blocks of the module, keyed by local name.
+	RequiredProviders map[string]*tfconfig.ProviderRequirement
+
+	// The version constraints in the required_version settings of the module.
+	RequiredCore []string
+
+	// The provider blocks of the module, keyed by name, or by "<name>.<alias>" for aliased providers.
+	ProviderConfigs map[string]*tfconfig.ProviderConfig
+}
+
+// ModuleVariable is a declared variable of a terraform module.
+type ModuleVariable struct {
+	tfconfig.Variable
+
+	// False if the variable sets nullable = false, i.e., if null can't be passed as its value.
+	Nullable bool
+}
+
+// LoadModule parses the terraform files in the given folder and returns the variables, outputs, resources, module calls
+// and providers declared in them. This does not load the modules called by the module, and does not require terraform
+// init. This will fail the test if the files can't be parsed.
+func LoadModule(t testing.TestingT, dir string) *Module {
+	module, err := LoadModuleE(t, dir)
+	require.NoError(t, err)
+	return module
+}
+
+// LoadModuleE parses the terraform files in the given folder and returns the variables, outputs, resources, module
+// calls and providers declared in them. This does not load the modules called by the module, and does not require
+// terraform init.
+func LoadModuleE(t testing.TestingT, dir string) (*Module, error) {
+	if !tfconfig.IsModuleDir(dir) {
+		return nil, ModuleNotFound(dir)
+	}
+
+	config, diags := tfconfig.LoadModule(dir)
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+
+	nullable, err := parseNullableVariables(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	module := &Module{
+		Path:              config.Path,
+		Variables:         map[string]*ModuleVariable{},
+		Outputs:           config.Outputs,
+		ManagedResources:  config.ManagedResources,
+		DataResources:     config.DataResources,
+		ModuleCalls:       config.ModuleCalls,
+		RequiredProviders: config.RequiredProviders,
+		RequiredCore:      config.RequiredCore,
+		ProviderConfigs:   config.ProviderConfigs,
+	}
+	for name, variable := range config.Variables {
+		isNullable, isSet := nullable[name]
+		module.Variables[name] = &ModuleVariable{Variable: *variable, Nullable: !isSet || isNullable}
+	}
+	return module, nil
+}
+
+// VariablesWithoutDescription returns the names of the variables of the module that have no description, sorted.
+func (module *Module) VariablesWithoutDescription() []string {
+	names := []string{}
+	for _, name := range sortedKeys(module.Variables) {
+		if strings.TrimSpace(module.Variables[name].Description) == "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// OutputsWithoutDescription returns the names of the outputs of the module that have no description, sorted.
+func (module *Module) OutputsWithoutDescription() []string {
+	names := []string{}
+	for _, name := range sortedKeys(module.Outputs) {
+		if strings.TrimSpace(module.Outputs[name].Description) == "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// UnpinnedModuleCalls returns the names of the module calls of the module whose source is not pinned to a version,
+// sorted. Registry sources are pinned if they set a version, and git and mercurial sources are pinned if they set a
+// ref. Local paths are always considered pinned, and other sources (e.g., archives over http or in a bucket) are
+// considered pinned, as their version is typically part of the url.
+func (module *Module) UnpinnedModuleCalls() []string {
+	names := []string{}
+	for _, name := range sortedKeys(module.ModuleCalls) {
+		if !isPinnedModuleSource(module.ModuleCalls[name]) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isPinnedModuleSource returns true if the source of the given module call is pinned to a version.
+func isPinnedModuleSource(call *tfconfig.ModuleCall) bool {
+	source := call.Source
+	switch {
+	case strings.HasPrefix(source, "./"), strings.HasPrefix(source, "../"):
+		return true
+	case strings.HasPrefix(source, "git::"), strings.HasPrefix(source, "hg::"),
+		strings.HasPrefix(source, "github.com/"), strings.HasPrefix(source, "bitbucket.org/"),
+		strings.HasPrefix(source, "git@"):
+		return strings.Contains(source, "?ref=") || strings.Contains(source, "&ref=") || strings.Contains(source, "?rev=")
+	case strings.Contains(source, "::"), strings.Contains(source, "://"):
+		return true
+	}
+
+	// Anything else is a registry source, such as "hashicorp/consul/aws" or "app.terraform.io/org/consul/aws".
+	return call.Version != ""
+}
+
+// parseNullableVariables parses the terraform files in the given folder and returns a map that maps the names of the
+// variables that set nullable to its value. terraform-config-inspect doesn't support the nullable argument, so it is
+// parsed separately.
+func parseNullableVariables(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := hclparse.NewParser()
+	nullable := map[string]bool{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		var file *hcl.File
+		var diags hcl.Diagnostics
+		switch {
+		case strings.HasSuffix(entry.Name(), ".tf"):
+			file, diags = parser.ParseHCLFile(path)
+		case strings.HasSuffix(entry.Name(), ".tf.json"):
+			file, diags = parser.ParseJSONFile(path)
+		default:
+			continue
+		}
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		content, _, diags := file.Body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{{Type: "variable", LabelNames: []string{"name"}}},
+		})
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		for _, block := range content.Blocks {
+			attributes, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+				Attributes: []hcl.AttributeSchema{{Name: "nullable"}},
+			})
+			if diags.HasErrors() {
+				return nil, diags
+			}
+			attribute, hasNullable := attributes.Attributes["nullable"]
+			if !hasNullable {
+				continue
+			}
+			value, diags := attribute.Expr.Value(nil)
+			if diags.HasErrors() {
+				return nil, diags
+			}
+			if value.Type() == cty.Bool && value.IsKnown() && !value.IsNull() {
+				nullable[block.Labels[0]] = value.True()
+			}
+		}
+	}
+	return nullable, nil
+}
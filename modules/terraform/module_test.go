@@ -0,0 +1,83 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadModule(t *testing.T) {
+	t.Parallel()
+
+	module := LoadModule(t, "../../test/fixtures/terraform-module-inspect")
+
+	require.Equal(t, []string{"db_password", "instance_type", "name", "tags"}, sortedKeys(module.Variables))
+	assert.True(t, module.Variables["name"].Required)
+	assert.Equal(t, "string", module.Variables["name"].Type)
+	assert.True(t, module.Variables["name"].Nullable)
+	assert.Equal(t, "t3.micro", module.Variables["instance_type"].Default)
+	assert.False(t, module.Variables["instance_type"].Nullable)
+	assert.True(t, module.Variables["db_password"].Sensitive)
+
+	assert.Equal(t, []string{"instance_id", "public_ip"}, sortedKeys(module.Outputs))
+	assert.Equal(t, []string{"aws_instance.web"}, sortedKeys(module.ManagedResources))
+	assert.Equal(t, []string{"data.aws_ami.ubuntu"}, sortedKeys(module.DataResources))
+
+	require.Contains(t, module.ModuleCalls, "vpc")
+	assert.Equal(t, "terraform-aws-modules/vpc/aws", module.ModuleCalls["vpc"].Source)
+	assert.Equal(t, "5.1.0", module.ModuleCalls["vpc"].Version)
+
+	require.Contains(t, module.RequiredProviders, "aws")
+	assert.Equal(t, "hashicorp/aws", module.RequiredProviders["aws"].Source)
+	assert.Equal(t, []string{"~> 5.0"}, module.RequiredProviders["aws"].VersionConstraints)
+	assert.Equal(t, []string{">= 1.0"}, module.RequiredCore)
+	assert.Equal(t, []string{"aws"}, sortedKeys(module.ProviderConfigs))
+}
+
+func TestLoadModulePolicies(t *testing.T) {
+	t.Parallel()
+
+	module := LoadModule(t, "../../test/fixtures/terraform-module-inspect")
+
+	assert.Equal(t, []string{"tags"}, module.VariablesWithoutDescription())
+	assert.Equal(t, []string{"public_ip"}, module.OutputsWithoutDescription())
+	assert.Equal(t, []string{"unpinned_git", "unpinned_registry"}, module.UnpinnedModuleCalls())
+}
+
+func TestLoadModuleNotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := LoadModuleE(t, t.TempDir())
+	assert.IsType(t, ModuleNotFound(""), err)
+}
+
+func TestIsPinnedModuleSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source   string
+		version  string
+		expected bool
+	}{
+		{"./modules/vpc", "", true},
+		{"../vpc", "", true},
+		{"hashicorp/consul/aws", "", false},
+		{"hashicorp/consul/aws", "0.1.0", true},
+		{"app.terraform.io/example-corp/k8s-cluster/azurerm", "1.1.0", true},
+		{"github.com/hashicorp/example", "", false},
+		{"github.com/hashicorp/example?ref=v1.2.0", "", true},
+		{"git@github.com:hashicorp/example.git", "", false},
+		{"git::https://example.com/vpc.git?ref=v1.2.0", "", true},
+		{"git::https://example.com/network.git//modules/vpc?depth=1&ref=v1.2.0", "", true},
+		{"hg::http://example.com/vpc.hg?rev=v1.2.0", "", true},
+		{"https://example.com/vpc-module.zip", "", true},
+		{"s3::https://s3-eu-west-1.amazonaws.com/examplecorp-terraform-modules/vpc.zip", "", true},
+	}
+
+	for _, testCase := range testCases {
+		call := &tfconfig.ModuleCall{Source: testCase.source, Version: testCase.version}
+		assert.Equal(t, testCase.expected, isPinnedModuleSource(call), testCase.source)
+	}
+}
@@ -1,9 +1,11 @@
 package terraform
 
 import (
+	"context"
 	"time"
 
 	"github.com/nholuongut/terratest/modules/logger"
+	"github.com/nholuongut/terratest/modules/retry"
 	"github.com/nholuongut/terratest/modules/ssh"
 	"github.com/nholuongut/terratest/modules/testing"
 	"github.com/jinzhu/copier"
@@ -59,6 +61,7 @@ type Options struct {
 	RetryableTerraformErrors map[string]string      // If Terraform apply fails with one of these (transient) errors, retry. The keys are a regexp to match against the error and the message is what to display to a user if that error is matched.
 	MaxRetries               int                    // Maximum number of times to retry errors matching RetryableTerraformErrors
 	TimeBetweenRetries       time.Duration          // The amount of time to wait between retries
+	RetryPolicy              *retry.Policy          // If set, retry errors matching RetryableTerraformErrors as configured by this policy (e.g., with exponential backoff) instead of MaxRetries and TimeBetweenRetries
 	Upgrade                  bool                   // Whether the -upgrade flag of the terraform init command should be set to true or not
 	Reconfigure              bool                   // Set the -reconfigure flag to the terraform init command
 	MigrateState             bool                   // Set the -migrate-state and -force-copy (suppress 'yes' answer prompt) flag to the terraform init command
@@ -72,6 +75,21 @@ type Options struct {
 	PluginDir                string                 // The path of downloaded plugins to pass to the terraform init command (-plugin-dir)
 	SetVarsAfterVarFiles     bool                   // Pass -var options after -var-file options to Terraform commands
 	WarningsAsErrors         map[string]string      // Terraform warning messages that should be treated as errors. The keys are a regexp to match against the warning and the value is what to display to a user if that warning is matched.
+	JsonUI                   bool                   // Run plan, apply and destroy with -json, so that Terraform streams its machine readable UI. Errors then carry the structured diagnostics (see DiagnosticsError), WarningsAsErrors is matched against the summary of warning diagnostics, and RetryableTerraformErrors against the summary and detail of error diagnostics. Other commands, such as init, are unaffected.
+	Context                  context.Context        `json:"-"` // If set, running Terraform commands are interrupted (and eventually killed) when the context is done. Use context.WithDeadline to bound the run time of a test, e.g., by t.Deadline(). Destroy is not interrupted when it is done, so that a deferred Destroy still cleans up, but bounded by CleanupTimeout instead.
+	CleanupTimeout           time.Duration          // How long destroy may run if Context is set, regardless of whether Context is done. Defaults to DefaultCleanupTimeout.
+	Terragrunt               *TerragruntOptions     // If set, commands are run with terragrunt (TerraformBinary defaults to "terragrunt") and these terragrunt specific options.
+}
+
+// TerragruntOptions are the options for running terragrunt, in addition to the terraform options in Options. Setting
+// Options.Terragrunt (even to an empty struct) marks the Options as terragrunt options, which is required by the Tg*
+// functions.
+type TerragruntOptions struct {
+	IncludeDirs            []string // Only run the units in these directories (glob patterns are supported) in run-all commands (--terragrunt-include-dir)
+	ExcludeDirs            []string // Skip the units in these directories (glob patterns are supported) in run-all commands (--terragrunt-exclude-dir)
+	IgnoreDependencyErrors bool     // Continue running the units of a run-all command even if some of their dependencies failed (--terragrunt-ignore-dependency-errors)
+	Parallelism            int      // The maximum number of units run concurrently by run-all commands (--terragrunt-parallelism)
+	LogLevel               string   // The log level of terragrunt itself, e.g., "debug" or "error" (--terragrunt-log-level)
 }
 
 // Clone makes a deep copy of most fields on the Options object and returns it.
@@ -104,6 +122,16 @@ func (options *Options) Clone() (*Options, error) {
 	for key, val := range options.WarningsAsErrors {
 		newOptions.WarningsAsErrors[key] = val
 	}
+	if options.RetryPolicy != nil {
+		policy := *options.RetryPolicy
+		newOptions.RetryPolicy = &policy
+	}
+	if options.Terragrunt != nil {
+		terragrunt := *options.Terragrunt
+		terragrunt.IncludeDirs = append([]string(nil), options.Terragrunt.IncludeDirs...)
+		terragrunt.ExcludeDirs = append([]string(nil), options.Terragrunt.ExcludeDirs...)
+		newOptions.Terragrunt = &terragrunt
+	}
 
 	return newOptions, nil
 }
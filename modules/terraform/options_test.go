@@ -1,9 +1,12 @@
 package terraform
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/nholuongut/terratest/modules/random"
+	"github.com/nholuongut/terratest/modules/retry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -41,3 +44,59 @@ func TestOptionsCloneDeepClonesVars(t *testing.T) {
 	assert.Equal(t, unique, original.Vars["unique"])
 	assert.Equal(t, unique, copied.Vars["original"])
 }
+
+func TestOptionsCloneRetainsContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	original := Options{Context: ctx}
+	copied, err := original.Clone()
+	require.NoError(t, err)
+	assert.Equal(t, ctx, copied.Context)
+}
+
+func TestOptionsCloneDeepClonesTerragruntOptions(t *testing.T) {
+	t.Parallel()
+
+	original := Options{
+		Terragrunt: &TerragruntOptions{
+			IncludeDirs: []string{"app"},
+			LogLevel:    "info",
+		},
+	}
+	copied, err := original.Clone()
+	require.NoError(t, err)
+	copied.Terragrunt.IncludeDirs[0] = "nullified"
+	copied.Terragrunt.LogLevel = "debug"
+	assert.Equal(t, []string{"app"}, original.Terragrunt.IncludeDirs)
+	assert.Equal(t, "info", original.Terragrunt.LogLevel)
+}
+
+func TestOptionsCloneCopiesRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	original := Options{RetryPolicy: &retry.Policy{MaxRetries: 3, InitialInterval: time.Second}}
+	copied, err := original.Clone()
+	require.NoError(t, err)
+	copied.RetryPolicy.MaxRetries = 10
+	assert.Equal(t, 3, original.RetryPolicy.MaxRetries)
+	assert.Equal(t, time.Second, copied.RetryPolicy.InitialInterval)
+}
+
+func TestRunTerraformCommandWithRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		TerraformBinary:          "terraform-binary-that-does-not-exist",
+		RetryableTerraformErrors: map[string]string{"not found": "binary not found"},
+		RetryPolicy:              &retry.Policy{MaxRetries: 2, InitialInterval: time.Millisecond},
+	}
+	_, err := RunTerraformCommandE(t, options, "version")
+
+	var retryErr retry.MaxRetriesExceeded
+	require.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 2, retryErr.MaxRetries)
+	assert.Len(t, retryErr.Errors, 3)
+}
@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -273,7 +274,35 @@ func OutputJson(t testing.TestingT, options *Options, key string) string {
 // OutputJsonE calls terraform output for the given variable and returns the
 // result as the json string.
 // If key is an empty string, it will return all the output variables.
+// The values of sensitive outputs are registered as secrets (see logger.RegisterSecret) before the output is logged. As
+// the output of a single key doesn't say whether it is sensitive, this always runs terraform output for all the outputs,
+// and picks the value of the key from that, in the same (compact) format as terraform output -json <key> prints it. If
+// there is no output for the key, terraform output -json <key> is run to return the error of terraform.
 func OutputJsonE(t testing.TestingT, options *Options, key string) (string, error) {
+	out, outputs, err := outputJsonAndValuesE(t, options)
+	if err != nil {
+		return "", err
+	}
+
+	if key != "" {
+		output, exists := outputs[key]
+		if !exists {
+			return outputJsonE(t, options, key)
+		}
+		value := bytes.Buffer{}
+		if err := json.Compact(&value, output.Value); err != nil {
+			return "", err
+		}
+		out = value.String()
+	}
+
+	options.Logger.Logf(t, "%s", out)
+	return out, nil
+}
+
+// outputJsonE calls terraform output for the given variable and returns the result as the json string, without
+// registering the values of sensitive outputs as secrets.
+func outputJsonE(t testing.TestingT, options *Options, key string) (string, error) {
 	args := []string{"output", "-no-color", "-json"}
 	if key != "" {
 		args = append(args, key)
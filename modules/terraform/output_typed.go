@@ -0,0 +1,375 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nholuongut/terratest/modules/logger"
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// OutputValue is a single output of a terraform module, as returned by `terraform output -json`.
+type OutputValue struct {
+	// Whether the output is marked as sensitive. The values of sensitive outputs are registered as secrets (see
+	// logger.RegisterSecret), so that they are redacted from the logs.
+	Sensitive bool `json:"sensitive"`
+
+	// The declared type of the output, in terraform's json representation of types (e.g., "string" or
+	// ["list","string"]). See TypeString for a human readable representation.
+	Type json.RawMessage `json:"type"`
+
+	// The json representation of the value of the output.
+	Value json.RawMessage `json:"value"`
+}
+
+// TypeString returns the declared type of the output in terraform's type constraint syntax (e.g., "list(string)").
+func (output OutputValue) TypeString() string {
+	var ctyType interface{}
+	if err := json.Unmarshal(output.Type, &ctyType); err != nil {
+		return string(output.Type)
+	}
+	return formatCtyType(ctyType)
+}
+
+// OutputValues runs terraform output once and returns all the outputs of the module, including their declared types
+// and whether they are sensitive. Sensitive values are masked in the logs. Use DecodeOutput to decode the values into
+// go types. This will fail the test if there is an error in the command.
+func OutputValues(t testing.TestingT, options *Options) map[string]OutputValue {
+	outputs, err := OutputValuesE(t, options)
+	require.NoError(t, err)
+	return outputs
+}
+
+// OutputValuesE runs terraform output once and returns all the outputs of the module, including their declared types
+// and whether they are sensitive. Sensitive values are masked in the logs. Use DecodeOutput to decode the values into
+// go types.
+func OutputValuesE(t testing.TestingT, options *Options) (map[string]OutputValue, error) {
+	outputs, err := outputValuesE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedKeys(outputs) {
+		output := outputs[name]
+		value := string(output.Value)
+		if output.Sensitive {
+			value = "(sensitive value)"
+		}
+		options.Logger.Logf(t, "Output %s (%s) = %s", name, output.TypeString(), value)
+	}
+	return outputs, nil
+}
+
+// outputValuesE runs terraform output without logging its output, registers the values of sensitive outputs as secrets
+// (see logger.RegisterSecret) and returns all the outputs of the module.
+func outputValuesE(t testing.TestingT, options *Options) (map[string]OutputValue, error) {
+	_, outputs, err := outputJsonAndValuesE(t, options)
+	return outputs, err
+}
+
+// outputJsonAndValuesE runs terraform output without logging its output, registers the values of sensitive outputs as
+// secrets and returns both the raw json output and the outputs parsed from it.
+func outputJsonAndValuesE(t testing.TestingT, options *Options) (string, map[string]OutputValue, error) {
+	// The raw output contains the values of sensitive outputs, so it must not be logged before they are registered.
+	quietOptions := *options
+	quietOptions.Logger = logger.Discard
+	out, err := outputJsonE(t, &quietOptions, "")
+	if err != nil {
+		return "", nil, err
+	}
+
+	outputs := map[string]OutputValue{}
+	if err := json.Unmarshal([]byte(out), &outputs); err != nil {
+		return "", nil, err
+	}
+
+	registerSensitiveOutputs(t, outputs)
+	return out, outputs, nil
+}
+
+// registerSensitiveOutputs registers the strings in the values of sensitive outputs as secrets, so that they are
+// redacted from the logs.
+func registerSensitiveOutputs(t testing.TestingT, outputs map[string]OutputValue) {
+	for _, output := range outputs {
+		if !output.Sensitive {
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(output.Value, &value); err != nil {
+			continue
+		}
+		for _, secret := range stringLeaves(value) {
+			logger.RegisterSecret(t, secret)
+		}
+	}
+}
+
+// stringLeaves returns all the strings in the given decoded json value, including those nested in lists and objects.
+func stringLeaves(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		leaves := []string{}
+		for _, element := range value {
+			leaves = append(leaves, stringLeaves(element)...)
+		}
+		return leaves
+	case map[string]interface{}:
+		leaves := []string{}
+		for _, element := range value {
+			leaves = append(leaves, stringLeaves(element)...)
+		}
+		return leaves
+	default:
+		return nil
+	}
+}
+
+// OutputAs runs terraform output and decodes the value of the output with the given key into a value of type T (e.g.,
+// a struct with json tags for an object output). This will fail the test if there is an error in the command, if there
+// is no such output, or if its declared type does not match T.
+func OutputAs[T any](t testing.TestingT, options *Options, key string) T {
+	value, err := OutputAsE[T](t, options, key)
+	require.NoError(t, err)
+	return value
+}
+
+// OutputAsE runs terraform output and decodes the value of the output with the given key into a value of type T (e.g.,
+// a struct with json tags for an object output). Returns OutputKeyNotFound if there is no such output, and
+// UnexpectedOutputType if its declared type does not match T.
+func OutputAsE[T any](t testing.TestingT, options *Options, key string) (T, error) {
+	outputs, err := OutputValuesE(t, options)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return DecodeOutput[T](outputs, key)
+}
+
+// OutputAllAs runs terraform output once and decodes the values of all outputs into a value of type T, which should be
+// a struct whose fields (matched by json tag or name) correspond to outputs, or a map. This will fail the test if there
+// is an error in the command, or if the declared type of an output does not match the corresponding field of T.
+func OutputAllAs[T any](t testing.TestingT, options *Options) T {
+	value, err := OutputAllAsE[T](t, options)
+	require.NoError(t, err)
+	return value
+}
+
+// OutputAllAsE runs terraform output once and decodes the values of all outputs into a value of type T, which should
+// be a struct whose fields (matched by json tag or name) correspond to outputs, or a map. Returns UnexpectedOutputType
+// if the declared type of an output does not match the corresponding field of T. Outputs without a corresponding
+// field are ignored.
+func OutputAllAsE[T any](t testing.TestingT, options *Options) (T, error) {
+	var result T
+
+	outputs, err := OutputValuesE(t, options)
+	if err != nil {
+		return result, err
+	}
+
+	values := map[string]json.RawMessage{}
+	for name, output := range outputs {
+		fieldType, hasField := outputFieldType(reflect.TypeOf(result), name)
+		if !hasField {
+			continue
+		}
+		if err := checkOutputType(name, output, fieldType); err != nil {
+			return result, err
+		}
+		values[name] = output.Value
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// DecodeOutput decodes the value of the output with the given key, as returned by OutputValues, into a value of type T.
+// Use this to decode multiple outputs of a single run of terraform output. Returns OutputKeyNotFound if there is no such
+// output, and UnexpectedOutputType if its declared type does not match T.
+func DecodeOutput[T any](outputs map[string]OutputValue, key string) (T, error) {
+	var result T
+
+	output, hasKey := outputs[key]
+	if !hasKey {
+		return result, OutputKeyNotFound(key)
+	}
+	if err := checkOutputType(key, output, reflect.TypeOf(&result).Elem()); err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(output.Value, &result); err != nil {
+		return result, UnexpectedOutputType{Key: key, ExpectedType: reflect.TypeOf(&result).Elem().String(), ActualType: output.TypeString()}
+	}
+	return result, nil
+}
+
+// outputFieldType returns the type of the field of the given struct type that the output with the given name is
+// decoded into (following the rules of encoding/json), or the element type if the given type is a map.
+func outputFieldType(goType reflect.Type, name string) (reflect.Type, bool) {
+	for goType != nil && goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	if goType == nil {
+		return nil, false
+	}
+
+	switch goType.Kind() {
+	case reflect.Map:
+		return goType.Elem(), true
+	case reflect.Struct:
+		for i := 0; i < goType.NumField(); i++ {
+			field := goType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldName := field.Name
+			if tag, hasTag := field.Tag.Lookup("json"); hasTag {
+				tagName := strings.Split(tag, ",")[0]
+				if tagName == "-" {
+					continue
+				}
+				if tagName != "" {
+					fieldName = tagName
+				}
+			}
+			if strings.EqualFold(fieldName, name) {
+				return field.Type, true
+			}
+		}
+	case reflect.Interface:
+		return goType, true
+	}
+	return nil, false
+}
+
+// checkOutputType checks that the declared type of the given output can be decoded into the given go type.
+func checkOutputType(key string, output OutputValue, goType reflect.Type) error {
+	var ctyType interface{}
+	if err := json.Unmarshal(output.Type, &ctyType); err != nil {
+		return err
+	}
+	if !ctyTypeDecodesInto(ctyType, goType) {
+		return UnexpectedOutputType{Key: key, ExpectedType: goType.String(), ActualType: formatCtyType(ctyType)}
+	}
+	return nil
+}
+
+var (
+	jsonNumberType      = reflect.TypeOf(json.Number(""))
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// ctyTypeDecodesInto returns true if values of the given terraform type (in its json representation) can be decoded
+// into the given go type.
+func ctyTypeDecodesInto(ctyType interface{}, goType reflect.Type) bool {
+	for goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+	if goType.Kind() == reflect.Interface || reflect.PointerTo(goType).Implements(jsonUnmarshalerType) {
+		return true
+	}
+
+	if primitive, isPrimitive := ctyType.(string); isPrimitive {
+		switch primitive {
+		case "string":
+			return goType.Kind() == reflect.String && goType != jsonNumberType
+		case "number":
+			switch goType.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				return true
+			}
+			return goType == jsonNumberType
+		case "bool":
+			return goType.Kind() == reflect.Bool
+		case "dynamic":
+			return true
+		}
+		return false
+	}
+
+	complexType, isComplex := ctyType.([]interface{})
+	if !isComplex || len(complexType) != 2 {
+		return false
+	}
+	switch complexType[0] {
+	case "list", "set":
+		return (goType.Kind() == reflect.Slice || goType.Kind() == reflect.Array) && ctyTypeDecodesInto(complexType[1], goType.Elem())
+	case "tuple":
+		if goType.Kind() != reflect.Slice && goType.Kind() != reflect.Array {
+			return false
+		}
+		elements, _ := complexType[1].([]interface{})
+		for _, element := range elements {
+			if !ctyTypeDecodesInto(element, goType.Elem()) {
+				return false
+			}
+		}
+		return true
+	case "map":
+		if goType.Kind() == reflect.Struct {
+			return true
+		}
+		return goType.Kind() == reflect.Map && ctyTypeDecodesInto(complexType[1], goType.Elem())
+	case "object":
+		attributes, _ := complexType[1].(map[string]interface{})
+		if goType.Kind() != reflect.Struct && goType.Kind() != reflect.Map {
+			return false
+		}
+		for name, attributeType := range attributes {
+			fieldType, hasField := outputFieldType(goType, name)
+			if hasField && !ctyTypeDecodesInto(attributeType, fieldType) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// formatCtyType formats the given terraform type (in its json representation) in terraform's type constraint syntax.
+func formatCtyType(ctyType interface{}) string {
+	if primitive, isPrimitive := ctyType.(string); isPrimitive {
+		return primitive
+	}
+
+	complexType, isComplex := ctyType.([]interface{})
+	if !isComplex || len(complexType) != 2 {
+		return fmt.Sprintf("%v", ctyType)
+	}
+	switch complexType[0] {
+	case "object":
+		attributes, _ := complexType[1].(map[string]interface{})
+		names := make([]string, 0, len(attributes))
+		for name := range attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		formatted := []string{}
+		for _, name := range names {
+			formatted = append(formatted, fmt.Sprintf("%s=%s", name, formatCtyType(attributes[name])))
+		}
+		return fmt.Sprintf("object({%s})", strings.Join(formatted, ", "))
+	case "tuple":
+		elements, _ := complexType[1].([]interface{})
+		formatted := []string{}
+		for _, element := range elements {
+			formatted = append(formatted, formatCtyType(element))
+		}
+		return fmt.Sprintf("tuple([%s])", strings.Join(formatted, ", "))
+	default:
+		return fmt.Sprintf("%v(%s)", complexType[0], formatCtyType(complexType[1]))
+	}
+}
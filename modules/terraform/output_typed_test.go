@@ -0,0 +1,136 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/nholuongut/terratest/modules/logger"
+	"github.com/nholuongut/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const typedOutputsJSON = `{
+  "name": {"sensitive": false, "type": "string", "value": "web"},
+  "port": {"sensitive": false, "type": "number", "value": 8080},
+  "password": {"sensitive": true, "type": "string", "value": "hunter2"},
+  "zones": {"sensitive": false, "type": ["list", "string"], "value": ["a", "b"]},
+  "tags": {"sensitive": false, "type": ["map", "string"], "value": {"Owner": "ops"}},
+  "server": {"sensitive": false, "type": ["object", {"id": "string", "ports": ["tuple", ["number", "number"]]}], "value": {"id": "i-123", "ports": [80, 443]}}
+}`
+
+type typedServer struct {
+	ID    string `json:"id"`
+	Ports []int  `json:"ports"`
+}
+
+func parseTypedOutputs(t *testing.T) map[string]OutputValue {
+	outputs := map[string]OutputValue{}
+	require.NoError(t, json.Unmarshal([]byte(typedOutputsJSON), &outputs))
+	return outputs
+}
+
+func TestDecodeOutput(t *testing.T) {
+	t.Parallel()
+
+	outputs := parseTypedOutputs(t)
+
+	name, err := DecodeOutput[string](outputs, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "web", name)
+
+	port, err := DecodeOutput[int](outputs, "port")
+	require.NoError(t, err)
+	assert.Equal(t, 8080, port)
+
+	zones, err := DecodeOutput[[]string](outputs, "zones")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, zones)
+
+	server, err := DecodeOutput[*typedServer](outputs, "server")
+	require.NoError(t, err)
+	assert.Equal(t, &typedServer{ID: "i-123", Ports: []int{80, 443}}, server)
+
+	anything, err := DecodeOutput[interface{}](outputs, "tags")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Owner": "ops"}, anything)
+
+	assert.True(t, outputs["password"].Sensitive)
+}
+
+func TestDecodeOutputErrors(t *testing.T) {
+	t.Parallel()
+
+	outputs := parseTypedOutputs(t)
+
+	_, err := DecodeOutput[string](outputs, "missing")
+	assert.Equal(t, OutputKeyNotFound("missing"), err)
+
+	_, err = DecodeOutput[string](outputs, "port")
+	assert.Equal(t, UnexpectedOutputType{Key: "port", ExpectedType: "string", ActualType: "number"}, err)
+
+	_, err = DecodeOutput[[]int](outputs, "zones")
+	assert.Equal(t, UnexpectedOutputType{Key: "zones", ExpectedType: "[]int", ActualType: "list(string)"}, err)
+
+	_, err = DecodeOutput[map[string]string](outputs, "server")
+	assert.Equal(t, UnexpectedOutputType{Key: "server", ExpectedType: "map[string]string", ActualType: "object({id=string, ports=tuple([number, number])})"}, err)
+}
+
+func TestFormatCtyType(t *testing.T) {
+	t.Parallel()
+
+	outputs := parseTypedOutputs(t)
+	assert.Equal(t, "string", outputs["name"].TypeString())
+	assert.Equal(t, "list(string)", outputs["zones"].TypeString())
+	assert.Equal(t, "map(string)", outputs["tags"].TypeString())
+	assert.Equal(t, "object({id=string, ports=tuple([number, number])})", outputs["server"].TypeString())
+}
+
+func TestOutputAllAs(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-output-all", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	InitAndApply(t, options)
+
+	type stellarOutputs struct {
+		Stars          []string           `json:"stars"`
+		OurStar        string             `json:"our_star"`
+		Constellations map[string]string  `json:"constellations"`
+		Magnitudes     map[string]float64 `json:"magnitudes"`
+	}
+	outputs := OutputAllAs[stellarOutputs](t, options)
+	assert.Equal(t, []string{"Sirius", "Rigel", "Betelgeuse"}, outputs.Stars)
+	assert.Equal(t, "Sun", outputs.OurStar)
+	assert.Equal(t, "Antares", outputs.Constellations["Scorpio"])
+	assert.Equal(t, -1.46, outputs.Magnitudes["Sirius"])
+
+	assert.Equal(t, "Sun", OutputAs[string](t, options, "our_star"))
+
+	_, err = OutputAllAsE[struct {
+		OurStar int `json:"our_star"`
+	}](t, options)
+	assert.Equal(t, UnexpectedOutputType{Key: "our_star", ExpectedType: "int", ActualType: "string"}, err)
+}
+
+func TestRegisterSensitiveOutputs(t *testing.T) {
+	t.Parallel()
+
+	password := "password-" + random.UniqueId()
+	token := "token-" + random.UniqueId()
+	public := "public-" + random.UniqueId()
+	outputs := map[string]OutputValue{
+		"password":    {Sensitive: true, Type: json.RawMessage(`"string"`), Value: json.RawMessage(`"` + password + `"`)},
+		"credentials": {Sensitive: true, Type: json.RawMessage(`["object",{"tokens":["list","string"],"port":"number"}]`), Value: json.RawMessage(`{"tokens": ["` + token + `"], "port": 8080}`)},
+		"name":        {Sensitive: false, Type: json.RawMessage(`"string"`), Value: json.RawMessage(`"` + public + `"`)},
+	}
+
+	registerSensitiveOutputs(t, outputs)
+
+	assert.Equal(t, "[REDACTED] [REDACTED] "+public, logger.Redact(password+" "+token+" "+public))
+}
@@ -136,8 +136,8 @@ func TgPlanAllExitCode(t testing.TestingT, options *Options) int {
 
 // TgPlanAllExitCodeE runs terragrunt plan-all with the given options and returns the detailed exitcode.
 func TgPlanAllExitCodeE(t testing.TestingT, options *Options) (int, error) {
-	if options.TerraformBinary != "terragrunt" {
-		return 1, fmt.Errorf("terragrunt must be set as TerraformBinary to use this method")
+	if !isTerragrunt(options) {
+		return 1, TgInvalidBinary(options.TerraformBinary)
 	}
 
 	return GetExitCodeForTerraformCommandE(t, options, FormatArgs(options, "run-all", "plan", "--input=false",
@@ -0,0 +1,274 @@
+package terraform
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ChangeAction is the action terraform plans to take on a resource. Unlike tfjson.Actions, replacements (which
+// terraform represents as a delete and a create) are collapsed into a single ChangeActionReplace.
+type ChangeAction string
+
+const (
+	ChangeActionNoOp    ChangeAction = "no-op"
+	ChangeActionCreate  ChangeAction = "create"
+	ChangeActionRead    ChangeAction = "read"
+	ChangeActionUpdate  ChangeAction = "update"
+	ChangeActionDelete  ChangeAction = "delete"
+	ChangeActionReplace ChangeAction = "replace"
+)
+
+// unknownAfterApply is the type of UnknownAfterApply.
+type unknownAfterApply struct{}
+
+func (unknownAfterApply) String() string {
+	return "(known after apply)"
+}
+
+// UnknownAfterApply can be passed as the expected value after the change to AssertAttributeChange and
+// RequireAttributeChange, to check that the value of the attribute will only be known after apply.
+var UnknownAfterApply = unknownAfterApply{}
+
+// AttributeChange is a change to a single (possibly nested) attribute of a resource.
+type AttributeChange struct {
+	// The dot separated path to the attribute, where list elements are addressed by their index (e.g., "tags.Name" or
+	// "ebs_block_device.0.volume_size").
+	Path string
+
+	// The value of the attribute before the change. Nil if the attribute did not exist.
+	Before interface{}
+
+	// The value of the attribute after the change. Nil if the attribute will be removed or if the value is unknown
+	// until after apply.
+	After interface{}
+
+	// True if the value of the attribute will only be known after apply.
+	AfterUnknown bool
+}
+
+// GetChangeAction returns the action terraform plans to take on the resource of the given resource change.
+func GetChangeAction(change *tfjson.ResourceChange) ChangeAction {
+	if change.Change == nil {
+		return ChangeActionNoOp
+	}
+
+	actions := change.Change.Actions
+	switch {
+	case actions.Replace():
+		return ChangeActionReplace
+	case actions.Create():
+		return ChangeActionCreate
+	case actions.Read():
+		return ChangeActionRead
+	case actions.Update():
+		return ChangeActionUpdate
+	case actions.Delete():
+		return ChangeActionDelete
+	default:
+		return ChangeActionNoOp
+	}
+}
+
+// ResourceChangesByAction returns the resource changes of the plan for which terraform plans to take any of the given
+// actions, sorted by resource address.
+func (plan *PlanStruct) ResourceChangesByAction(actions ...ChangeAction) []*tfjson.ResourceChange {
+	changes := []*tfjson.ResourceChange{}
+	for _, change := range plan.ResourceChangesMap {
+		if containsChangeAction(actions, GetChangeAction(change)) {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Address < changes[j].Address })
+	return changes
+}
+
+// AttributeChanges returns the changes to the attributes of the resource with the given full address, sorted by
+// attribute path. Returns an error if the plan contains no change for the given resource.
+func (plan *PlanStruct) AttributeChanges(address string) ([]AttributeChange, error) {
+	change, hasKey := plan.ResourceChangesMap[address]
+	if !hasKey {
+		return nil, ResourceChangeNotFound(address)
+	}
+	return GetAttributeChanges(change), nil
+}
+
+// GetAttributeChanges compares the values before and after the given resource change and returns the changes to the
+// attributes, including nested attributes and attributes whose value will only be known after apply, sorted by
+// attribute path.
+func GetAttributeChanges(change *tfjson.ResourceChange) []AttributeChange {
+	if change.Change == nil {
+		return []AttributeChange{}
+	}
+
+	changes := diffAttributeValues("", change.Change.Before, change.Change.After, change.Change.AfterUnknown)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// diffAttributeValues recursively compares the given before and after values at the given path, and returns the
+// changes of the leaf values. afterUnknown is the corresponding part of the after_unknown structure of the plan.
+func diffAttributeValues(path string, before interface{}, after interface{}, afterUnknown interface{}) []AttributeChange {
+	if unknown, isBool := afterUnknown.(bool); isBool && unknown {
+		return []AttributeChange{{Path: path, Before: before, AfterUnknown: true}}
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	unknownMap, _ := afterUnknown.(map[string]interface{})
+	if (beforeIsMap || before == nil) && (afterIsMap || after == nil) && (beforeIsMap || afterIsMap || unknownMap != nil) {
+		keys := map[string]bool{}
+		for _, m := range []map[string]interface{}{beforeMap, afterMap, unknownMap} {
+			for key := range m {
+				keys[key] = true
+			}
+		}
+
+		changes := []AttributeChange{}
+		for key := range keys {
+			changes = append(changes, diffAttributeValues(joinAttributePath(path, key), beforeMap[key], afterMap[key], unknownMap[key])...)
+		}
+		return changes
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	unknownList, _ := afterUnknown.([]interface{})
+	if (beforeIsList || before == nil) && (afterIsList || after == nil) && (beforeIsList || afterIsList || unknownList != nil) {
+		length := max(len(beforeList), len(afterList), len(unknownList))
+
+		changes := []AttributeChange{}
+		for i := 0; i < length; i++ {
+			changes = append(changes, diffAttributeValues(joinAttributePath(path, strconv.Itoa(i)), listElement(beforeList, i), listElement(afterList, i), listElement(unknownList, i))...)
+		}
+		return changes
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return []AttributeChange{}
+	}
+	return []AttributeChange{{Path: path, Before: before, After: after}}
+}
+
+// joinAttributePath appends the given attribute name or list index to the given path.
+func joinAttributePath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// listElement returns the element of the given list at the given index, or nil if the list is too short.
+func listElement(list []interface{}, index int) interface{} {
+	if index < len(list) {
+		return list[index]
+	}
+	return nil
+}
+
+func containsChangeAction(actions []ChangeAction, action ChangeAction) bool {
+	for _, candidate := range actions {
+		if candidate == action {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertNoDestroys checks that the plan does not delete or replace any resource, failing the test if it does.
+func AssertNoDestroys(t testing.TestingT, plan *PlanStruct) {
+	assert.NoError(t, checkNoDestroys(plan))
+}
+
+// RequireNoDestroys checks that the plan does not delete or replace any resource, failing and halting the test if it
+// does.
+func RequireNoDestroys(t testing.TestingT, plan *PlanStruct) {
+	require.NoError(t, checkNoDestroys(plan))
+}
+
+func checkNoDestroys(plan *PlanStruct) error {
+	destroyed := []string{}
+	for _, change := range plan.ResourceChangesByAction(ChangeActionDelete, ChangeActionReplace) {
+		destroyed = append(destroyed, fmt.Sprintf("%s (%s)", change.Address, GetChangeAction(change)))
+	}
+	if len(destroyed) > 0 {
+		return fmt.Errorf("plan destroys resources: %s", strings.Join(destroyed, ", "))
+	}
+	return nil
+}
+
+// AssertOnlyActions checks that terraform plans to take only the given actions on the resources whose address matches
+// the given regular expression, failing the test if it does not. No-op changes are always allowed.
+func AssertOnlyActions(t testing.TestingT, plan *PlanStruct, addressPattern string, actions ...ChangeAction) {
+	assert.NoError(t, checkOnlyActions(plan, addressPattern, actions))
+}
+
+// RequireOnlyActions checks that terraform plans to take only the given actions on the resources whose address
+// matches the given regular expression, failing and halting the test if it does not. No-op changes are always allowed.
+func RequireOnlyActions(t testing.TestingT, plan *PlanStruct, addressPattern string, actions ...ChangeAction) {
+	require.NoError(t, checkOnlyActions(plan, addressPattern, actions))
+}
+
+func checkOnlyActions(plan *PlanStruct, addressPattern string, actions []ChangeAction) error {
+	addressRegexp, err := regexp.Compile(addressPattern)
+	if err != nil {
+		return err
+	}
+
+	unexpected := []string{}
+	for _, change := range plan.ResourceChangesByAction(ChangeActionCreate, ChangeActionRead, ChangeActionUpdate, ChangeActionDelete, ChangeActionReplace) {
+		action := GetChangeAction(change)
+		if addressRegexp.MatchString(change.Address) && !containsChangeAction(actions, action) {
+			unexpected = append(unexpected, fmt.Sprintf("%s (%s)", change.Address, action))
+		}
+	}
+	if len(unexpected) > 0 {
+		return fmt.Errorf("plan takes unexpected actions on resources matching %q (expected only %v): %s", addressPattern, actions, strings.Join(unexpected, ", "))
+	}
+	return nil
+}
+
+// AssertAttributeChange checks that the plan changes the attribute at the given path of the resource with the given
+// full address from the given value to the given value, failing the test if it does not. Pass UnknownAfterApply if the
+// new value will only be known after apply.
+func AssertAttributeChange(t testing.TestingT, plan *PlanStruct, address string, attributePath string, from interface{}, to interface{}) {
+	assert.NoError(t, checkAttributeChange(plan, address, attributePath, from, to))
+}
+
+// RequireAttributeChange checks that the plan changes the attribute at the given path of the resource with the given
+// full address from the given value to the given value, failing and halting the test if it does not. Pass
+// UnknownAfterApply if the new value will only be known after apply.
+func RequireAttributeChange(t testing.TestingT, plan *PlanStruct, address string, attributePath string, from interface{}, to interface{}) {
+	require.NoError(t, checkAttributeChange(plan, address, attributePath, from, to))
+}
+
+func checkAttributeChange(plan *PlanStruct, address string, attributePath string, from interface{}, to interface{}) error {
+	changes, err := plan.AttributeChanges(address)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		if change.Path != attributePath {
+			continue
+		}
+
+		var actualAfter interface{} = change.After
+		if change.AfterUnknown {
+			actualAfter = UnknownAfterApply
+		}
+		if !assert.ObjectsAreEqualValues(from, change.Before) || !assert.ObjectsAreEqualValues(to, actualAfter) {
+			return fmt.Errorf("expected attribute %q of %s to change from %v to %v, but it changes from %v to %v", attributePath, address, from, to, change.Before, actualAfter)
+		}
+		return nil
+	}
+	return fmt.Errorf("expected attribute %q of %s to change from %v to %v, but it does not change", attributePath, address, from, to)
+}
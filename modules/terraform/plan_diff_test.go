@@ -0,0 +1,132 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const planJSONWithAllActions = `{
+  "format_version": "1.1",
+  "resource_changes": [
+    {
+      "address": "null_resource.created",
+      "type": "null_resource",
+      "name": "created",
+      "change": {
+        "actions": ["create"],
+        "before": null,
+        "after": {"triggers": {"name": "new"}},
+        "after_unknown": {"id": true, "triggers": {}}
+      }
+    },
+    {
+      "address": "aws_instance.updated",
+      "type": "aws_instance",
+      "name": "updated",
+      "change": {
+        "actions": ["update"],
+        "before": {"id": "i-123", "instance_type": "t3.micro", "tags": {"Name": "old", "Env": "dev"}, "ebs_block_device": [{"volume_size": 8}]},
+        "after": {"id": "i-123", "instance_type": "t3.small", "tags": {"Name": "new", "Env": "dev"}, "ebs_block_device": [{"volume_size": 16}]},
+        "after_unknown": {"tags": {}, "ebs_block_device": [{}], "public_ip": true}
+      }
+    },
+    {
+      "address": "module.foo.aws_instance.replaced[0]",
+      "type": "aws_instance",
+      "name": "replaced",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": {"id": "i-456", "ami": "ami-old"},
+        "after": {"ami": "ami-new"},
+        "after_unknown": {"id": true}
+      }
+    },
+    {
+      "address": "null_resource.deleted",
+      "type": "null_resource",
+      "name": "deleted",
+      "change": {"actions": ["delete"], "before": {"id": "1"}, "after": null}
+    },
+    {
+      "address": "null_resource.unchanged",
+      "type": "null_resource",
+      "name": "unchanged",
+      "change": {"actions": ["no-op"], "before": {"id": "2"}, "after": {"id": "2"}}
+    }
+  ]
+}`
+
+func TestResourceChangesByAction(t *testing.T) {
+	t.Parallel()
+
+	plan, err := ParsePlanJSON(planJSONWithAllActions)
+	require.NoError(t, err)
+
+	addresses := func(actions ...ChangeAction) []string {
+		out := []string{}
+		for _, change := range plan.ResourceChangesByAction(actions...) {
+			out = append(out, change.Address)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"null_resource.created"}, addresses(ChangeActionCreate))
+	assert.Equal(t, []string{"aws_instance.updated"}, addresses(ChangeActionUpdate))
+	assert.Equal(t, []string{"module.foo.aws_instance.replaced[0]"}, addresses(ChangeActionReplace))
+	assert.Equal(t, []string{"module.foo.aws_instance.replaced[0]", "null_resource.deleted"}, addresses(ChangeActionDelete, ChangeActionReplace))
+	assert.Equal(t, []string{"null_resource.unchanged"}, addresses(ChangeActionNoOp))
+}
+
+func TestAttributeChanges(t *testing.T) {
+	t.Parallel()
+
+	plan, err := ParsePlanJSON(planJSONWithAllActions)
+	require.NoError(t, err)
+
+	changes, err := plan.AttributeChanges("aws_instance.updated")
+	require.NoError(t, err)
+	assert.Equal(t, []AttributeChange{
+		{Path: "ebs_block_device.0.volume_size", Before: float64(8), After: float64(16)},
+		{Path: "instance_type", Before: "t3.micro", After: "t3.small"},
+		{Path: "public_ip", AfterUnknown: true},
+		{Path: "tags.Name", Before: "old", After: "new"},
+	}, changes)
+
+	changes, err = plan.AttributeChanges("null_resource.created")
+	require.NoError(t, err)
+	assert.Equal(t, []AttributeChange{
+		{Path: "id", AfterUnknown: true},
+		{Path: "triggers.name", After: "new"},
+	}, changes)
+
+	_, err = plan.AttributeChanges("null_resource.missing")
+	assert.Equal(t, ResourceChangeNotFound("null_resource.missing"), err)
+}
+
+func TestPlanDiffAssertions(t *testing.T) {
+	t.Parallel()
+
+	plan, err := ParsePlanJSON(planJSONWithAllActions)
+	require.NoError(t, err)
+
+	RequireOnlyActions(t, plan, `^aws_instance\.`, ChangeActionUpdate)
+	RequireOnlyActions(t, plan, `^module\.foo\.`, ChangeActionReplace)
+	RequireAttributeChange(t, plan, "aws_instance.updated", "instance_type", "t3.micro", "t3.small")
+	RequireAttributeChange(t, plan, "aws_instance.updated", "ebs_block_device.0.volume_size", 8, 16)
+	RequireAttributeChange(t, plan, "module.foo.aws_instance.replaced[0]", "id", "i-456", UnknownAfterApply)
+
+	assert.Error(t, checkNoDestroys(plan))
+	assert.Error(t, checkOnlyActions(plan, `^null_resource\.`, []ChangeAction{ChangeActionCreate}))
+	assert.Error(t, checkAttributeChange(plan, "aws_instance.updated", "instance_type", "t3.micro", "t3.large"))
+	assert.Error(t, checkAttributeChange(plan, "aws_instance.updated", "id", "i-123", "i-123"))
+}
+
+func TestRequireNoDestroys(t *testing.T) {
+	t.Parallel()
+
+	plan, err := ParsePlanJSON(`{"format_version": "1.1", "resource_changes": [{"address": "null_resource.created", "change": {"actions": ["create"], "after": {}}}]}`)
+	require.NoError(t, err)
+	RequireNoDestroys(t, plan)
+}
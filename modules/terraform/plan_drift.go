@@ -0,0 +1,153 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// PlanRefreshOnlyWithStruct runs terraform plan -refresh-only with the given options, and then terraform show on the
+// resulting plan file, and parses the json result into a go struct. The changes made to the resources outside of
+// terraform are in PlanStruct.ResourceDriftMap. If options.PlanFilePath is not set, a temporary plan file is used.
+// This will fail the test if there is an error in the commands.
+func PlanRefreshOnlyWithStruct(t testing.TestingT, options *Options) *PlanStruct {
+	plan, err := PlanRefreshOnlyWithStructE(t, options)
+	require.NoError(t, err)
+	return plan
+}
+
+// PlanRefreshOnlyWithStructE runs terraform plan -refresh-only with the given options, and then terraform show on the
+// resulting plan file, and parses the json result into a go struct. The changes made to the resources outside of
+// terraform are in PlanStruct.ResourceDriftMap. If options.PlanFilePath is not set, a temporary plan file is used.
+func PlanRefreshOnlyWithStructE(t testing.TestingT, options *Options) (*PlanStruct, error) {
+	planOptions := *options
+	if planOptions.PlanFilePath == "" {
+		tmpFile, err := os.CreateTemp("", "terratest-plan-file-")
+		if err != nil {
+			return nil, err
+		}
+		if err := tmpFile.Close(); err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmpFile.Name())
+		planOptions.PlanFilePath = tmpFile.Name()
+	}
+
+	if _, err := RunTerraformCommandE(t, &planOptions, FormatArgs(&planOptions, "plan", "-refresh-only", "-input=false", "-lock=false")...); err != nil {
+		return nil, err
+	}
+
+	jsonOut, err := ShowE(t, &planOptions)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlanJSON(jsonOut)
+}
+
+// DetectDrift runs terraform init and apply with the given options, then calls mutate to change the deployed
+// resources behind terraform's back (e.g., through the cloud provider's API), and finally runs terraform plan
+// -refresh-only and returns the parsed plan, whose ResourceDriftMap contains the drift terraform detected. Note that
+// this method does NOT call destroy and assumes the caller is responsible for cleaning up the resources. This will fail
+// the test if there is an error in the commands or in mutate.
+func DetectDrift(t testing.TestingT, options *Options, mutate func() error) *PlanStruct {
+	plan, err := DetectDriftE(t, options, mutate)
+	require.NoError(t, err)
+	return plan
+}
+
+// DetectDriftE runs terraform init and apply with the given options, then calls mutate to change the deployed
+// resources behind terraform's back (e.g., through the cloud provider's API), and finally runs terraform plan
+// -refresh-only and returns the parsed plan, whose ResourceDriftMap contains the drift terraform detected. Note that
+// this method does NOT call destroy and assumes the caller is responsible for cleaning up the resources.
+func DetectDriftE(t testing.TestingT, options *Options, mutate func() error) (*PlanStruct, error) {
+	if _, err := InitAndApplyE(t, options); err != nil {
+		return nil, err
+	}
+	if err := mutate(); err != nil {
+		return nil, err
+	}
+	return PlanRefreshOnlyWithStructE(t, options)
+}
+
+// DriftedResources returns the addresses of the resources that were changed outside of terraform, sorted.
+func (plan *PlanStruct) DriftedResources() []string {
+	addresses := []string{}
+	for address, change := range plan.ResourceDriftMap {
+		if GetChangeAction(change) != ChangeActionNoOp {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// DriftedAttributes returns the changes made outside of terraform to the attributes of the resource with the given
+// full address, sorted by attribute path. Returns an empty list if the resource did not drift.
+func (plan *PlanStruct) DriftedAttributes(address string) []AttributeChange {
+	change, hasKey := plan.ResourceDriftMap[address]
+	if !hasKey {
+		return []AttributeChange{}
+	}
+	return GetAttributeChanges(change)
+}
+
+// AssertNoDrift checks that terraform detected no changes made to the resources outside of terraform, failing the test
+// if it did.
+func AssertNoDrift(t testing.TestingT, plan *PlanStruct) {
+	assert.NoError(t, checkNoDrift(plan))
+}
+
+// RequireNoDrift checks that terraform detected no changes made to the resources outside of terraform, failing and
+// halting the test if it did.
+func RequireNoDrift(t testing.TestingT, plan *PlanStruct) {
+	require.NoError(t, checkNoDrift(plan))
+}
+
+func checkNoDrift(plan *PlanStruct) error {
+	drifted := []string{}
+	for _, address := range plan.DriftedResources() {
+		drifted = append(drifted, fmt.Sprintf("%s (%s)", address, GetChangeAction(plan.ResourceDriftMap[address])))
+	}
+	if len(drifted) > 0 {
+		return fmt.Errorf("resources were changed outside of terraform: %s", strings.Join(drifted, ", "))
+	}
+	return nil
+}
+
+// AssertDriftOn checks that terraform detected a change made outside of terraform to the attribute at the given path
+// of the resource with the given full address, failing the test if it did not. If attributePath is empty, any drift of
+// the resource (including its deletion) is accepted.
+func AssertDriftOn(t testing.TestingT, plan *PlanStruct, address string, attributePath string) {
+	assert.NoError(t, checkDriftOn(plan, address, attributePath))
+}
+
+// RequireDriftOn checks that terraform detected a change made outside of terraform to the attribute at the given path
+// of the resource with the given full address, failing and halting the test if it did not. If attributePath is empty,
+// any drift of the resource (including its deletion) is accepted.
+func RequireDriftOn(t testing.TestingT, plan *PlanStruct, address string, attributePath string) {
+	require.NoError(t, checkDriftOn(plan, address, attributePath))
+}
+
+func checkDriftOn(plan *PlanStruct, address string, attributePath string) error {
+	change, hasKey := plan.ResourceDriftMap[address]
+	if !hasKey || GetChangeAction(change) == ChangeActionNoOp {
+		return fmt.Errorf("expected %s to have been changed outside of terraform, but no drift was detected (drifted resources: %v)", address, plan.DriftedResources())
+	}
+	if attributePath == "" {
+		return nil
+	}
+
+	paths := []string{}
+	for _, attributeChange := range plan.DriftedAttributes(address) {
+		if attributeChange.Path == attributePath || strings.HasPrefix(attributeChange.Path, attributePath+".") {
+			return nil
+		}
+		paths = append(paths, attributeChange.Path)
+	}
+	return fmt.Errorf("expected attribute %q of %s to have been changed outside of terraform, but only %v drifted", attributePath, address, paths)
+}
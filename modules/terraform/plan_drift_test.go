@@ -0,0 +1,91 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const refreshOnlyPlanJSON = `{
+  "format_version": "1.2",
+  "resource_drift": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {
+        "actions": ["update"],
+        "before": {"instance_type": "t3.micro", "tags": {"Name": "web"}},
+        "after": {"instance_type": "t3.micro", "tags": {"Name": "renamed", "Owner": "ops"}}
+      }
+    },
+    {
+      "address": "aws_s3_bucket.logs",
+      "mode": "managed",
+      "type": "aws_s3_bucket",
+      "name": "logs",
+      "change": {
+        "actions": ["delete"],
+        "before": {"bucket": "logs"},
+        "after": null
+      }
+    }
+  ]
+}`
+
+func TestParsePlanJSONResourceDrift(t *testing.T) {
+	t.Parallel()
+
+	plan, err := ParsePlanJSON(refreshOnlyPlanJSON)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"aws_instance.web", "aws_s3_bucket.logs"}, plan.DriftedResources())
+	assert.Equal(t, []AttributeChange{
+		{Path: "tags.Name", Before: "web", After: "renamed"},
+		{Path: "tags.Owner", After: "ops"},
+	}, plan.DriftedAttributes("aws_instance.web"))
+	assert.Empty(t, plan.DriftedAttributes("aws_instance.db"))
+}
+
+func TestCheckDrift(t *testing.T) {
+	t.Parallel()
+
+	plan, err := ParsePlanJSON(refreshOnlyPlanJSON)
+	require.NoError(t, err)
+
+	assert.Error(t, checkNoDrift(plan))
+	assert.NoError(t, checkDriftOn(plan, "aws_instance.web", ""))
+	assert.NoError(t, checkDriftOn(plan, "aws_instance.web", "tags"))
+	assert.NoError(t, checkDriftOn(plan, "aws_instance.web", "tags.Name"))
+	assert.Error(t, checkDriftOn(plan, "aws_instance.web", "instance_type"))
+	assert.NoError(t, checkDriftOn(plan, "aws_s3_bucket.logs", ""))
+	assert.Error(t, checkDriftOn(plan, "aws_instance.db", ""))
+
+	noDrift, err := ParsePlanJSON(`{"format_version": "1.2"}`)
+	require.NoError(t, err)
+	assert.NoError(t, checkNoDrift(noDrift))
+}
+
+func TestDetectDrift(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-drift", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	defer Destroy(t, options)
+
+	RequireNoDrift(t, DetectDrift(t, options, func() error { return nil }))
+
+	plan := DetectDrift(t, options, func() error {
+		return os.Remove(filepath.Join(testFolder, "example.txt"))
+	})
+	RequireDriftOn(t, plan, "local_file.example", "")
+}
@@ -25,6 +25,16 @@ type PlanStruct struct {
 	// A map that maps full resource addresses (e.g., module.foo.null_resource.test) to the planned actions terraform
 	// will take on that resource.
 	ResourceChangesMap map[string]*tfjson.ResourceChange
+
+	// A map that maps full resource addresses (e.g., module.foo.null_resource.test) to the changes terraform detected
+	// were made to that resource outside of terraform (resource_drift). Note that in a normal plan, terraform only
+	// reports the drift that is relevant to the planned changes. Use PlanRefreshOnlyWithStruct to get all drift.
+	ResourceDriftMap map[string]*tfjson.ResourceChange
+}
+
+// planResourceDrift is the part of the plan representation that is not (yet) supported by terraform-json.
+type planResourceDrift struct {
+	ResourceDrift []*tfjson.ResourceChange `json:"resource_drift"`
 }
 
 // ParsePlanJSON takes in the json string representation of the terraform plan and returns a go struct representation
@@ -38,6 +48,15 @@ func ParsePlanJSON(jsonStr string) (*PlanStruct, error) {
 
 	plan.ResourcePlannedValuesMap = parsePlannedValues(plan)
 	plan.ResourceChangesMap = parseResourceChanges(plan)
+
+	drift := planResourceDrift{}
+	if err := json.Unmarshal([]byte(jsonStr), &drift); err != nil {
+		return nil, err
+	}
+	plan.ResourceDriftMap = map[string]*tfjson.ResourceChange{}
+	for _, change := range drift.ResourceDrift {
+		plan.ResourceDriftMap[change.Address] = change
+	}
 	return plan, nil
 }
 
@@ -0,0 +1,292 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// Stack is a set of terraform root modules (e.g., network, cluster and app) that are applied in dependency order, and
+// destroyed in reverse order. Roots that don't depend on each other are applied (and destroyed) concurrently. For
+// example:
+//
+//	stack := &terraform.Stack{
+//		Roots: []*terraform.StackRoot{
+//			{Name: "network", Options: networkOptions},
+//			{Name: "cluster", Options: clusterOptions, Inputs: map[string]string{"vpc_id": "network.vpc_id"}},
+//			{Name: "app", Options: appOptions, DependsOn: []string{"cluster"}},
+//		},
+//	}
+//	terraform.ApplyStack(t, stack)
+//
+// Use test_structure.ApplyStack to persist the options of each root, so that the stages of the test can be skipped.
+type Stack struct {
+	Roots []*StackRoot
+
+	// If set, called after each root was applied successfully (e.g., to record which roots were applied). If it returns
+	// an error, the root counts as failed. Note that it is called from the goroutine that applied the root, not the
+	// goroutine of the test, so it must not call t.FailNow (or anything that does, such as t.Fatal or require).
+	AfterApply func(t testing.TestingT, root *StackRoot) error
+
+	mutex     sync.Mutex
+	attempted map[string]bool
+}
+
+// StackRoot is a single terraform root module of a Stack.
+type StackRoot struct {
+	// The unique name of the root within the stack.
+	Name string
+
+	// The options for running terraform in the root.
+	Options *Options
+
+	// The names of the roots that must be applied before this root (and destroyed after it). The roots referenced in
+	// Inputs are implicitly added.
+	DependsOn []string
+
+	// A map that maps the names of variables of this root to outputs of upstream roots, in the format
+	// "<root name>.<output name>". The values of the outputs are passed to this root through Options.Vars.
+	Inputs map[string]string
+
+	// The outputs of the root, set after the root was applied.
+	Outputs map[string]interface{}
+}
+
+// Root returns the root with the given name, or nil if the stack has no such root.
+func (stack *Stack) Root(name string) *StackRoot {
+	for _, root := range stack.Roots {
+		if root.Name == name {
+			return root
+		}
+	}
+	return nil
+}
+
+// ApplyStack runs terraform init and apply on the roots of the given stack in dependency order, passing the outputs of
+// upstream roots into the Vars of downstream roots (see StackRoot.Inputs). If t supports it (as *testing.T does), the
+// destroy of the stack is registered with t.Cleanup before anything is applied, so that the roots that were applied are
+// destroyed in reverse order even if a root fails to apply. Otherwise, the caller is responsible for calling
+// DestroyStack. This will fail the test if any root fails to apply.
+func ApplyStack(t testing.TestingT, stack *Stack) {
+	if cleaner, canCleanup := t.(interface{ Cleanup(func()) }); canCleanup {
+		cleaner.Cleanup(func() {
+			DestroyStack(t, stack)
+		})
+	}
+	require.NoError(t, ApplyStackE(t, stack))
+}
+
+// ApplyStackE runs terraform init and apply on the roots of the given stack in dependency order, passing the outputs
+// of upstream roots into the Vars of downstream roots (see StackRoot.Inputs). Roots whose upstream roots failed to
+// apply are skipped. Note that this method does NOT destroy the stack, and assumes the caller is responsible for
+// calling DestroyStack, even if an error is returned.
+func ApplyStackE(t testing.TestingT, stack *Stack) error {
+	dependencies, err := stack.dependencies()
+	if err != nil {
+		return err
+	}
+
+	return runStackRoots(stack.Roots, dependencies, true, func(root *StackRoot) error {
+		stack.mutex.Lock()
+		if stack.attempted == nil {
+			stack.attempted = map[string]bool{}
+		}
+		stack.attempted[root.Name] = true
+		stack.mutex.Unlock()
+
+		if err := stack.passInputs(root); err != nil {
+			return err
+		}
+		if _, err := InitAndApplyE(t, root.Options); err != nil {
+			return err
+		}
+
+		outputs, err := OutputAllE(t, root.Options)
+		if err != nil {
+			return err
+		}
+		root.Outputs = outputs
+
+		if stack.AfterApply != nil {
+			return stack.AfterApply(t, root)
+		}
+		return nil
+	})
+}
+
+// DestroyStack runs terraform destroy on the roots of the given stack in reverse dependency order. See DestroyStackE
+// for details. This will fail the test if any root fails to destroy.
+func DestroyStack(t testing.TestingT, stack *Stack) {
+	require.NoError(t, DestroyStackE(t, stack))
+}
+
+// DestroyStackE runs terraform destroy on the roots of the given stack in reverse dependency order. If ApplyStackE was
+// called on the stack, only the roots it attempted to apply are destroyed. Otherwise (e.g., because the stack was
+// applied in an earlier stage of the test), all roots are destroyed. A root is destroyed even if destroying one of its
+// downstream roots failed, so that as much as possible is cleaned up.
+func DestroyStackE(t testing.TestingT, stack *Stack) error {
+	dependencies, err := stack.dependencies()
+	if err != nil {
+		return err
+	}
+
+	stack.mutex.Lock()
+	roots := []*StackRoot{}
+	for _, root := range stack.Roots {
+		if stack.attempted == nil || stack.attempted[root.Name] {
+			roots = append(roots, root)
+		}
+	}
+	stack.mutex.Unlock()
+
+	dependents := map[string][]string{}
+	for _, root := range roots {
+		for _, dependency := range dependencies[root.Name] {
+			dependents[dependency] = append(dependents[dependency], root.Name)
+		}
+	}
+
+	return runStackRoots(roots, dependents, false, func(root *StackRoot) error {
+		_, err := DestroyE(t, root.Options)
+		return err
+	})
+}
+
+// dependencies returns a map that maps the name of each root of the stack to the names of the roots it depends on,
+// either explicitly or through its inputs. Returns an error if the roots are not a valid dependency graph.
+func (stack *Stack) dependencies() (map[string][]string, error) {
+	names := map[string]bool{}
+	for _, root := range stack.Roots {
+		if names[root.Name] {
+			return nil, fmt.Errorf("stack has multiple roots named %q", root.Name)
+		}
+		names[root.Name] = true
+	}
+
+	dependencies := map[string][]string{}
+	for _, root := range stack.Roots {
+		rootDependencies := map[string]bool{}
+		for _, dependency := range root.DependsOn {
+			rootDependencies[dependency] = true
+		}
+		for variable, input := range root.Inputs {
+			upstream, _, err := parseStackInput(input)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input for variable %q of root %q: %w", variable, root.Name, err)
+			}
+			rootDependencies[upstream] = true
+		}
+		for dependency := range rootDependencies {
+			if !names[dependency] {
+				return nil, fmt.Errorf("root %q depends on unknown root %q", root.Name, dependency)
+			}
+		}
+		dependencies[root.Name] = sortedKeys(rootDependencies)
+	}
+
+	if _, err := dependencyOrder(sortedKeys(names), dependencies); err != nil {
+		return nil, fmt.Errorf("invalid stack: %w", err)
+	}
+	return dependencies, nil
+}
+
+// passInputs sets the Vars of the given root to the outputs of the upstream roots referenced in its Inputs.
+func (stack *Stack) passInputs(root *StackRoot) error {
+	if len(root.Inputs) == 0 {
+		return nil
+	}
+	if root.Options.Vars == nil {
+		root.Options.Vars = map[string]interface{}{}
+	}
+
+	for variable, input := range root.Inputs {
+		upstream, output, err := parseStackInput(input)
+		if err != nil {
+			return err
+		}
+		value, hasOutput := stack.Root(upstream).Outputs[output]
+		if !hasOutput {
+			return fmt.Errorf("root %q has no output %q for variable %q of root %q", upstream, output, variable, root.Name)
+		}
+		root.Options.Vars[variable] = value
+	}
+	return nil
+}
+
+// parseStackInput splits the given input of a StackRoot into the name of the upstream root and the name of its output.
+func parseStackInput(input string) (string, string, error) {
+	parts := strings.SplitN(input, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("expected an input in the format <root name>.<output name>, but got %q", input)
+	}
+	return parts[0], parts[1], nil
+}
+
+// runStackRoots runs the given action on each of the given roots concurrently, but only after it finished on all the
+// roots the root waits for (as given by waitFor, which maps root names to the names of the roots to wait for; roots
+// that are not in roots are ignored). If skipOnFailure is set, the action is skipped on the roots that wait for a root
+// on which it failed. Returns the errors of all the roots.
+func runStackRoots(roots []*StackRoot, waitFor map[string][]string, skipOnFailure bool, action func(root *StackRoot) error) error {
+	done := map[string]chan struct{}{}
+	for _, root := range roots {
+		done[root.Name] = make(chan struct{})
+	}
+
+	var mutex sync.Mutex
+	failed := map[string]error{}
+
+	var wg sync.WaitGroup
+	for _, root := range roots {
+		root := root
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[root.Name])
+
+			skipped := []string{}
+			for _, name := range waitFor[root.Name] {
+				if _, isRunning := done[name]; !isRunning {
+					continue
+				}
+				<-done[name]
+
+				mutex.Lock()
+				_, hasFailed := failed[name]
+				mutex.Unlock()
+				if hasFailed {
+					skipped = append(skipped, name)
+				}
+			}
+
+			var err error
+			if skipOnFailure && len(skipped) > 0 {
+				err = fmt.Errorf("root %q was skipped, because %v failed", root.Name, skipped)
+			} else if actionErr := action(root); actionErr != nil {
+				err = fmt.Errorf("root %q failed: %w", root.Name, actionErr)
+			}
+
+			if err != nil {
+				mutex.Lock()
+				failed[root.Name] = err
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	errs := []error{}
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, failed[name])
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,140 @@
+package terraform
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStackDependencies(t *testing.T) {
+	t.Parallel()
+
+	stack := &Stack{
+		Roots: []*StackRoot{
+			{Name: "network"},
+			{Name: "cluster", Inputs: map[string]string{"vpc_id": "network.vpc_id"}},
+			{Name: "app", DependsOn: []string{"cluster"}, Inputs: map[string]string{"subnet_id": "network.subnet_id"}},
+		},
+	}
+	dependencies, err := stack.dependencies()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"network": {}, "cluster": {"network"}, "app": {"cluster", "network"}}, dependencies)
+}
+
+func TestStackDependenciesInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		roots []*StackRoot
+	}{
+		{"duplicate", []*StackRoot{{Name: "a"}, {Name: "a"}}},
+		{"unknown", []*StackRoot{{Name: "a", DependsOn: []string{"b"}}}},
+		{"cycle", []*StackRoot{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", Inputs: map[string]string{"x": "a.x"}}}},
+		{"input", []*StackRoot{{Name: "a"}, {Name: "b", Inputs: map[string]string{"x": "a"}}}},
+	}
+
+	for _, testCase := range testCases {
+		_, err := (&Stack{Roots: testCase.roots}).dependencies()
+		assert.Error(t, err, testCase.name)
+	}
+}
+
+func TestStackPassInputs(t *testing.T) {
+	t.Parallel()
+
+	app := &StackRoot{Name: "app", Options: &Options{}, Inputs: map[string]string{"vpc_id": "network.vpc_id"}}
+	stack := &Stack{
+		Roots: []*StackRoot{
+			{Name: "network", Outputs: map[string]interface{}{"vpc_id": "vpc-123"}},
+			app,
+		},
+	}
+	require.NoError(t, stack.passInputs(app))
+	assert.Equal(t, map[string]interface{}{"vpc_id": "vpc-123"}, app.Options.Vars)
+
+	app.Inputs["subnet_id"] = "network.subnet_id"
+	assert.Error(t, stack.passInputs(app))
+}
+
+func TestRunStackRoots(t *testing.T) {
+	t.Parallel()
+
+	roots := []*StackRoot{{Name: "network"}, {Name: "dns"}, {Name: "cluster"}, {Name: "app"}}
+	waitFor := map[string][]string{"cluster": {"network"}, "app": {"cluster", "dns"}}
+
+	var mutex sync.Mutex
+	order := []string{}
+	err := runStackRoots(roots, waitFor, true, func(root *StackRoot) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		order = append(order, root.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, order, 4)
+	assert.Less(t, indexOf(order, "network"), indexOf(order, "cluster"))
+	assert.Less(t, indexOf(order, "cluster"), indexOf(order, "app"))
+	assert.Less(t, indexOf(order, "dns"), indexOf(order, "app"))
+}
+
+func TestRunStackRootsSkipsOnFailure(t *testing.T) {
+	t.Parallel()
+
+	roots := []*StackRoot{{Name: "network"}, {Name: "cluster"}, {Name: "app"}, {Name: "dns"}}
+	waitFor := map[string][]string{"cluster": {"network"}, "app": {"cluster"}}
+
+	var mutex sync.Mutex
+	ran := map[string]bool{}
+	action := func(root *StackRoot) error {
+		mutex.Lock()
+		ran[root.Name] = true
+		mutex.Unlock()
+		if root.Name == "cluster" {
+			return errors.New("apply failed")
+		}
+		return nil
+	}
+
+	err := runStackRoots(roots, waitFor, true, action)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `root "cluster" failed: apply failed`)
+	assert.Contains(t, err.Error(), `root "app" was skipped`)
+	assert.Equal(t, map[string]bool{"network": true, "cluster": true, "dns": true}, ran)
+
+	ran = map[string]bool{}
+	require.Error(t, runStackRoots(roots, waitFor, false, action))
+	assert.Len(t, ran, 4)
+}
+
+func TestApplyStack(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-stack", t.Name())
+	require.NoError(t, err)
+
+	stack := &Stack{
+		Roots: []*StackRoot{
+			{Name: "network", Options: &Options{TerraformDir: filepath.Join(testFolder, "network")}},
+			{Name: "app", Options: &Options{TerraformDir: filepath.Join(testFolder, "app")}, Inputs: map[string]string{"vpc_id": "network.vpc_id"}},
+		},
+	}
+	ApplyStack(t, stack)
+
+	assert.Equal(t, "vpc-network", stack.Root("app").Options.Vars["vpc_id"])
+	assert.Equal(t, "https://app.vpc-network.example.com", stack.Root("app").Outputs["endpoint"])
+}
+
+func indexOf(list []string, value string) int {
+	for i, candidate := range list {
+		if candidate == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,182 @@
+package terraform
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// StateFile is a Go struct representation of the raw Terraform state (format version 4), as returned by `terraform
+// state pull`. Note that this is the internal state format, which differs from the representation returned by
+// `terraform show -json` (see StateStruct).
+type StateFile struct {
+	Version          int                        `json:"version"`
+	TerraformVersion string                     `json:"terraform_version"`
+	Serial           int64                      `json:"serial"`
+	Lineage          string                     `json:"lineage"`
+	Outputs          map[string]StateFileOutput `json:"outputs"`
+	Resources        []StateFileResource        `json:"resources"`
+}
+
+// StateFileOutput is an output value stored in the raw Terraform state.
+type StateFileOutput struct {
+	Value     interface{}     `json:"value"`
+	Type      json.RawMessage `json:"type"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+}
+
+// StateFileResource is a resource (with all of its instances) stored in the raw Terraform state.
+type StateFileResource struct {
+	Module    string                      `json:"module,omitempty"`
+	Mode      string                      `json:"mode"`
+	Type      string                      `json:"type"`
+	Name      string                      `json:"name"`
+	EachMode  string                      `json:"each,omitempty"`
+	Provider  string                      `json:"provider"`
+	Instances []StateFileResourceInstance `json:"instances"`
+}
+
+// StateFileResourceInstance is a single instance (e.g., one element of a resource using count or for_each) of a
+// resource stored in the raw Terraform state.
+type StateFileResourceInstance struct {
+	IndexKey      interface{}            `json:"index_key,omitempty"`
+	SchemaVersion uint64                 `json:"schema_version"`
+	Attributes    map[string]interface{} `json:"attributes"`
+	Dependencies  []string               `json:"dependencies,omitempty"`
+}
+
+// StateList calls terraform state list and returns the addresses of all the resources in the state. If addresses are
+// given, only the resources matching those addresses are returned. This will fail the test if there is an error in
+// the command.
+func StateList(t testing.TestingT, options *Options, addresses ...string) []string {
+	out, err := StateListE(t, options, addresses...)
+	require.NoError(t, err)
+	return out
+}
+
+// StateListE calls terraform state list and returns the addresses of all the resources in the state. If addresses are
+// given, only the resources matching those addresses are returned.
+func StateListE(t testing.TestingT, options *Options, addresses ...string) ([]string, error) {
+	args := append([]string{"state", "list"}, addresses...)
+	out, err := RunTerraformCommandAndGetStdoutE(t, options, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			resources = append(resources, line)
+		}
+	}
+	return resources, nil
+}
+
+// StateMv calls terraform state mv to move the resource at source to destination in the state, and returns
+// stdout/stderr. This will fail the test if there is an error in the command.
+func StateMv(t testing.TestingT, options *Options, source string, destination string) string {
+	out, err := StateMvE(t, options, source, destination)
+	require.NoError(t, err)
+	return out
+}
+
+// StateMvE calls terraform state mv to move the resource at source to destination in the state, and returns
+// stdout/stderr.
+func StateMvE(t testing.TestingT, options *Options, source string, destination string) (string, error) {
+	args := []string{"state", "mv"}
+	args = append(args, FormatTerraformLockAsArgs(options.Lock, options.LockTimeout)...)
+	args = append(args, source, destination)
+	return RunTerraformCommandE(t, options, args...)
+}
+
+// StateRm calls terraform state rm to remove the resources at the given addresses from the state (without destroying
+// them), and returns stdout/stderr. This will fail the test if there is an error in the command.
+func StateRm(t testing.TestingT, options *Options, addresses ...string) string {
+	out, err := StateRmE(t, options, addresses...)
+	require.NoError(t, err)
+	return out
+}
+
+// StateRmE calls terraform state rm to remove the resources at the given addresses from the state (without destroying
+// them), and returns stdout/stderr.
+func StateRmE(t testing.TestingT, options *Options, addresses ...string) (string, error) {
+	args := []string{"state", "rm"}
+	args = append(args, FormatTerraformLockAsArgs(options.Lock, options.LockTimeout)...)
+	args = append(args, addresses...)
+	return RunTerraformCommandE(t, options, args...)
+}
+
+// StatePull calls terraform state pull and parses the raw state into a go struct. This will fail the test if there is
+// an error in the command.
+func StatePull(t testing.TestingT, options *Options) *StateFile {
+	state, err := StatePullE(t, options)
+	require.NoError(t, err)
+	return state
+}
+
+// StatePullE calls terraform state pull and parses the raw state into a go struct.
+func StatePullE(t testing.TestingT, options *Options) (*StateFile, error) {
+	out, err := StatePullJsonE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &StateFile{}
+	if err := json.Unmarshal([]byte(out), state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// StatePullJson calls terraform state pull and returns the raw state as a json string. This will fail the test if
+// there is an error in the command.
+func StatePullJson(t testing.TestingT, options *Options) string {
+	out, err := StatePullJsonE(t, options)
+	require.NoError(t, err)
+	return out
+}
+
+// StatePullJsonE calls terraform state pull and returns the raw state as a json string.
+func StatePullJsonE(t testing.TestingT, options *Options) (string, error) {
+	return RunTerraformCommandAndGetStdoutE(t, options, "state", "pull")
+}
+
+// StatePush calls terraform state push to overwrite the state with the state file at the given path, and returns
+// stdout/stderr. This will fail the test if there is an error in the command.
+func StatePush(t testing.TestingT, options *Options, stateFilePath string) string {
+	out, err := StatePushE(t, options, stateFilePath)
+	require.NoError(t, err)
+	return out
+}
+
+// StatePushE calls terraform state push to overwrite the state with the state file at the given path, and returns
+// stdout/stderr.
+func StatePushE(t testing.TestingT, options *Options, stateFilePath string) (string, error) {
+	args := []string{"state", "push"}
+	args = append(args, FormatTerraformLockAsArgs(options.Lock, options.LockTimeout)...)
+	args = append(args, stateFilePath)
+	return RunTerraformCommandE(t, options, args...)
+}
+
+// Import calls terraform import to import the existing infrastructure object with the given ID into the state at the
+// given resource address, and returns stdout/stderr. This will fail the test if there is an error in the command.
+func Import(t testing.TestingT, options *Options, address string, id string) string {
+	out, err := ImportE(t, options, address, id)
+	require.NoError(t, err)
+	return out
+}
+
+// ImportE calls terraform import to import the existing infrastructure object with the given ID into the state at the
+// given resource address, and returns stdout/stderr. Note that options.Targets is ignored, as import does not support
+// the -target flag.
+func ImportE(t testing.TestingT, options *Options, address string, id string) (string, error) {
+	importOptions := *options
+	importOptions.Targets = nil
+
+	// Flags must come before the positional args, so we append the address and ID after formatting.
+	args := FormatArgs(&importOptions, "import", "-input=false")
+	args = append(args, address, id)
+	return RunTerraformCommandE(t, options, args...)
+}
@@ -0,0 +1,147 @@
+package terraform
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// StateStruct is a Go Struct representation of the state returned from Terraform (after running `terraform show`
+// without a plan file). Unlike the raw state representation returned by terraform-json, this struct provides a map
+// that maps the resource addresses to the resources in the state to make it easier to navigate the raw state struct.
+type StateStruct struct {
+	// The raw representation of the state. See
+	// https://developer.hashicorp.com/terraform/internals/json-format#state-representation for details on the
+	// structure of the state output.
+	RawState tfjson.State
+
+	// A map that maps full resource addresses (e.g., module.foo.null_resource.test, null_resource.test["key"] or
+	// module.foo[0].null_resource.test) to the resource in the state.
+	ResourcesMap map[string]*tfjson.StateResource
+}
+
+// ParseStateJSON takes in the json string representation of the terraform state and returns a go struct
+// representation for easy introspection.
+func ParseStateJSON(jsonStr string) (*StateStruct, error) {
+	state := &StateStruct{}
+
+	if err := json.Unmarshal([]byte(jsonStr), &state.RawState); err != nil {
+		return nil, err
+	}
+
+	state.ResourcesMap = parseStateResources(state)
+	return state, nil
+}
+
+// parseStateResources takes a state and walks through the modules to return a map that maps the full resource
+// addresses to the resources. If the state is empty, this returns an empty map instead of erroring.
+func parseStateResources(state *StateStruct) map[string]*tfjson.StateResource {
+	values := state.RawState.Values
+	if values == nil || values.RootModule == nil {
+		// Nothing has been applied yet, so return empty map.
+		return map[string]*tfjson.StateResource{}
+	}
+	return parseModulePlannedValues(values.RootModule)
+}
+
+// ShowState calls terraform show in json mode with the given options and returns the json representation of the
+// current state of the terraform module at options.TerraformDir. Unlike Show, this ignores options.PlanFilePath. This
+// will fail the test if there is an error in the command.
+func ShowState(t testing.TestingT, options *Options) string {
+	out, err := ShowStateE(t, options)
+	require.NoError(t, err)
+	return out
+}
+
+// ShowStateE calls terraform show in json mode with the given options and returns the json representation of the
+// current state of the terraform module at options.TerraformDir. Unlike ShowE, this ignores options.PlanFilePath.
+func ShowStateE(t testing.TestingT, options *Options) (string, error) {
+	return RunTerraformCommandAndGetStdoutE(t, options, "show", "-no-color", "-json")
+}
+
+// ShowStateWithStruct calls terraform show in json mode with the given options and parses the current state into a go
+// struct. This will fail the test if there is an error in the command.
+func ShowStateWithStruct(t testing.TestingT, options *Options) *StateStruct {
+	state, err := ShowStateWithStructE(t, options)
+	require.NoError(t, err)
+	return state
+}
+
+// ShowStateWithStructE calls terraform show in json mode with the given options and parses the current state into a
+// go struct.
+func ShowStateWithStructE(t testing.TestingT, options *Options) (*StateStruct, error) {
+	jsonOut, err := ShowStateE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStateJSON(jsonOut)
+}
+
+// AssertResourceExists checks if a resource with the given full address exists in the state, failing the test if it
+// does not.
+func AssertResourceExists(t testing.TestingT, state *StateStruct, address string) {
+	_, hasKey := state.ResourcesMap[address]
+	assert.Truef(t, hasKey, "Given state does not have resource %s", address)
+}
+
+// RequireResourceExists checks if a resource with the given full address exists in the state, failing and halting
+// the test if it does not.
+func RequireResourceExists(t testing.TestingT, state *StateStruct, address string) {
+	_, hasKey := state.ResourcesMap[address]
+	require.Truef(t, hasKey, "Given state does not have resource %s", address)
+}
+
+// GetResourceAttribute returns the value of the attribute at the given path of the resource with the given full
+// address. The path is a dot separated list of attribute names and list indexes (e.g., "triggers.name" or
+// "ebs_block_device.0.volume_size"). This will fail the test if the resource or attribute does not exist.
+func GetResourceAttribute(t testing.TestingT, state *StateStruct, address string, attributePath string) interface{} {
+	value, err := GetResourceAttributeE(t, state, address, attributePath)
+	require.NoError(t, err)
+	return value
+}
+
+// GetResourceAttributeE returns the value of the attribute at the given path of the resource with the given full
+// address. The path is a dot separated list of attribute names and list indexes (e.g., "triggers.name" or
+// "ebs_block_device.0.volume_size").
+func GetResourceAttributeE(t testing.TestingT, state *StateStruct, address string, attributePath string) (interface{}, error) {
+	resource, hasKey := state.ResourcesMap[address]
+	if !hasKey {
+		return nil, ResourceNotFoundInState(address)
+	}
+
+	value, found := getAttributeAtPath(resource.AttributeValues, attributePath)
+	if !found {
+		return nil, AttributeNotFound{Address: address, Path: attributePath}
+	}
+	return value, nil
+}
+
+// getAttributeAtPath walks the given attribute values (as decoded from the terraform json output) along the given dot
+// separated path, and returns the value at the end of the path and whether it was found.
+func getAttributeAtPath(attributes map[string]interface{}, attributePath string) (interface{}, bool) {
+	var current interface{} = attributes
+	for _, part := range strings.Split(attributePath, ".") {
+		switch typed := current.(type) {
+		case map[string]interface{}:
+			value, hasKey := typed[part]
+			if !hasKey {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(typed) {
+				return nil, false
+			}
+			current = typed[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
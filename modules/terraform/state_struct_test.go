@@ -0,0 +1,118 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const stateJSONWithModulesAndIndexes = `{
+  "format_version": "1.0",
+  "terraform_version": "1.5.7",
+  "values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "null_resource.single",
+          "mode": "managed",
+          "type": "null_resource",
+          "name": "single",
+          "provider_name": "registry.terraform.io/hashicorp/null",
+          "schema_version": 0,
+          "values": {"id": "1", "triggers": {"name": "single"}}
+        },
+        {
+          "address": "null_resource.each[\"a\"]",
+          "mode": "managed",
+          "type": "null_resource",
+          "name": "each",
+          "index": "a",
+          "provider_name": "registry.terraform.io/hashicorp/null",
+          "schema_version": 0,
+          "values": {"id": "2", "triggers": {"name": "a"}, "list": [{"size": 10}]}
+        }
+      ],
+      "child_modules": [
+        {
+          "address": "module.child[\"x\"]",
+          "resources": [
+            {
+              "address": "module.child[\"x\"].null_resource.counted[1]",
+              "mode": "managed",
+              "type": "null_resource",
+              "name": "counted",
+              "index": 1,
+              "provider_name": "registry.terraform.io/hashicorp/null",
+              "schema_version": 0,
+              "values": {"id": "3", "triggers": {"index": "1", "name": "x"}}
+            }
+          ]
+        }
+      ]
+    }
+  }
+}`
+
+func TestParseStateJSONWithModulesAndIndexes(t *testing.T) {
+	t.Parallel()
+
+	state, err := ParseStateJSON(stateJSONWithModulesAndIndexes)
+	require.NoError(t, err)
+	assert.Len(t, state.ResourcesMap, 3)
+
+	RequireResourceExists(t, state, "null_resource.single")
+	RequireResourceExists(t, state, `null_resource.each["a"]`)
+	RequireResourceExists(t, state, `module.child["x"].null_resource.counted[1]`)
+
+	assert.Equal(t, "single", GetResourceAttribute(t, state, "null_resource.single", "triggers.name"))
+	assert.Equal(t, float64(10), GetResourceAttribute(t, state, `null_resource.each["a"]`, "list.0.size"))
+	assert.Equal(t, "x", GetResourceAttribute(t, state, `module.child["x"].null_resource.counted[1]`, "triggers.name"))
+}
+
+func TestGetResourceAttributeEErrors(t *testing.T) {
+	t.Parallel()
+
+	state, err := ParseStateJSON(stateJSONWithModulesAndIndexes)
+	require.NoError(t, err)
+
+	_, err = GetResourceAttributeE(t, state, "null_resource.missing", "id")
+	assert.Equal(t, ResourceNotFoundInState("null_resource.missing"), err)
+
+	_, err = GetResourceAttributeE(t, state, "null_resource.single", "triggers.missing")
+	assert.Equal(t, AttributeNotFound{Address: "null_resource.single", Path: "triggers.missing"}, err)
+
+	_, err = GetResourceAttributeE(t, state, `null_resource.each["a"]`, "list.1.size")
+	assert.Equal(t, AttributeNotFound{Address: `null_resource.each["a"]`, Path: "list.1.size"}, err)
+}
+
+func TestParseStateJSONEmptyState(t *testing.T) {
+	t.Parallel()
+
+	state, err := ParseStateJSON(`{"format_version": "1.0"}`)
+	require.NoError(t, err)
+	assert.Empty(t, state.ResourcesMap)
+}
+
+func TestShowStateWithStruct(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-state", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	defer Destroy(t, options)
+	InitAndApply(t, options)
+
+	state := ShowStateWithStruct(t, options)
+	RequireResourceExists(t, state, "null_resource.single")
+	RequireResourceExists(t, state, `null_resource.each["a"]`)
+	RequireResourceExists(t, state, `null_resource.each["b"]`)
+	RequireResourceExists(t, state, `module.child["x"].null_resource.counted[0]`)
+	RequireResourceExists(t, state, `module.child["x"].null_resource.counted[1]`)
+	assert.Equal(t, "b", GetResourceAttribute(t, state, `null_resource.each["b"]`, "triggers.name"))
+	assert.Equal(t, "1", GetResourceAttribute(t, state, `module.child["x"].null_resource.counted[1]`, "triggers.index"))
+}
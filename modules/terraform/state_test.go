@@ -0,0 +1,112 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replaceInFile replaces all occurrences of old with new in the file at the given path, to simulate refactoring the
+// Terraform code after it has been applied.
+func replaceInFile(t *testing.T, path string, old string, new string) {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.ReplaceAll(string(content), old, new)), 0644))
+}
+
+func TestStateMv(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-state-commands", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	defer Destroy(t, options)
+	InitAndApply(t, options)
+	assert.Equal(t, []string{"null_resource.original"}, StateList(t, options))
+
+	replaceInFile(t, filepath.Join(testFolder, "main.tf"), "original", "renamed")
+	StateMv(t, options, "null_resource.original", "null_resource.renamed")
+
+	assert.Equal(t, []string{"null_resource.renamed"}, StateList(t, options))
+	assert.Equal(t, DefaultSuccessExitCode, PlanExitCode(t, options))
+}
+
+func TestStateRm(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-state-commands", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	InitAndApply(t, options)
+
+	require.NoError(t, os.WriteFile(filepath.Join(testFolder, "main.tf"), []byte(""), 0644))
+	StateRm(t, options, "null_resource.original")
+
+	assert.Empty(t, StateList(t, options))
+	assert.Equal(t, DefaultSuccessExitCode, PlanExitCode(t, options))
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-state-commands", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	defer Destroy(t, options)
+	Init(t, options)
+
+	Import(t, options, "null_resource.original", "imported-id")
+
+	state := StatePull(t, options)
+	require.Len(t, state.Resources, 1)
+	assert.Equal(t, "original", state.Resources[0].Name)
+	require.Len(t, state.Resources[0].Instances, 1)
+	assert.Equal(t, "imported-id", state.Resources[0].Instances[0].Attributes["id"])
+	assert.Equal(t, DefaultSuccessExitCode, PlanExitCode(t, options))
+}
+
+func TestStatePullAndPush(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-state-commands", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	InitAndApply(t, options)
+
+	statePath := filepath.Join(testFolder, "pulled.tfstate")
+	require.NoError(t, os.WriteFile(statePath, []byte(StatePullJson(t, options)), 0644))
+	pulled := StatePull(t, options)
+
+	// Push the state into a fresh copy of the module, as if the state had been migrated.
+	otherTestFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-state-commands", t.Name())
+	require.NoError(t, err)
+
+	otherOptions := &Options{
+		TerraformDir: otherTestFolder,
+	}
+	defer Destroy(t, otherOptions)
+	Init(t, otherOptions)
+	assert.Empty(t, StateList(t, otherOptions))
+
+	StatePush(t, otherOptions, statePath)
+	assert.Equal(t, []string{"null_resource.original"}, StateList(t, otherOptions))
+	assert.Equal(t, pulled.Lineage, StatePull(t, otherOptions).Lineage)
+	assert.Equal(t, DefaultSuccessExitCode, PlanExitCode(t, otherOptions))
+}
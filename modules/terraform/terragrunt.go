@@ -0,0 +1,262 @@
+package terraform
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// TgDependencyGraph is the dependency graph of the units (the folders with a terragrunt.hcl) of a terragrunt stack.
+type TgDependencyGraph struct {
+	// The paths of the units, relative to the TerraformDir of the stack, sorted.
+	Units []string
+
+	// A map that maps the path of each unit to the paths of the units it depends on, sorted.
+	Dependencies map[string][]string
+}
+
+// Dependents returns the paths of the units that depend on the given unit, sorted.
+func (graph *TgDependencyGraph) Dependents(unit string) []string {
+	dependents := []string{}
+	for _, candidate := range graph.Units {
+		for _, dependency := range graph.Dependencies[candidate] {
+			if dependency == unit {
+				dependents = append(dependents, candidate)
+				break
+			}
+		}
+	}
+	return dependents
+}
+
+// Order returns the paths of the units in an order in which they can be applied, i.e., every unit comes after all of
+// the units it depends on. Units that don't depend on each other are sorted by path. Returns an error if the graph has a
+// cycle. Reverse the order to destroy the stack.
+func (graph *TgDependencyGraph) Order() ([]string, error) {
+	return dependencyOrder(graph.Units, graph.Dependencies)
+}
+
+// dependencyOrder returns the given units in an order in which every unit comes after all of the units it depends on,
+// as given by dependencies. Units that don't depend on each other are sorted. Returns an error if there is a cycle.
+func dependencyOrder(units []string, dependencies map[string][]string) ([]string, error) {
+	remaining := map[string]int{}
+	dependents := map[string][]string{}
+	for _, unit := range units {
+		remaining[unit] = len(dependencies[unit])
+		for _, dependency := range dependencies[unit] {
+			dependents[dependency] = append(dependents[dependency], unit)
+		}
+	}
+
+	order := []string{}
+	for len(remaining) > 0 {
+		ready := []string{}
+		for unit, count := range remaining {
+			if count == 0 {
+				ready = append(ready, unit)
+			}
+		}
+		if len(ready) == 0 {
+			return nil, fmt.Errorf("dependency cycle between %v", sortedKeys(remaining))
+		}
+
+		sort.Strings(ready)
+		for _, unit := range ready {
+			delete(remaining, unit)
+			for _, dependent := range dependents[unit] {
+				if _, isRemaining := remaining[dependent]; isRemaining {
+					remaining[dependent]--
+				}
+			}
+		}
+		order = append(order, ready...)
+	}
+	return order, nil
+}
+
+// FormatTerragruntArgs formats the given terragrunt options as command-line args for terragrunt. The
+// --terragrunt-non-interactive flag is always included, so that terragrunt never prompts for input in tests.
+func FormatTerragruntArgs(tgOptions *TerragruntOptions) []string {
+	args := []string{"--terragrunt-non-interactive"}
+	if tgOptions == nil {
+		return args
+	}
+
+	args = append(args, FormatTerraformArgs("--terragrunt-include-dir", tgOptions.IncludeDirs)...)
+	args = append(args, FormatTerraformArgs("--terragrunt-exclude-dir", tgOptions.ExcludeDirs)...)
+	if tgOptions.IgnoreDependencyErrors {
+		args = append(args, "--terragrunt-ignore-dependency-errors")
+	}
+	if tgOptions.Parallelism > 0 {
+		args = append(args, fmt.Sprintf("--terragrunt-parallelism=%d", tgOptions.Parallelism))
+	}
+	if tgOptions.LogLevel != "" {
+		args = append(args, "--terragrunt-log-level", tgOptions.LogLevel)
+	}
+	return args
+}
+
+// TgGraphDependencies runs terragrunt graph-dependencies in options.TerraformDir and returns the dependency graph of the
+// units of the stack. This will fail the test if there is an error in the command.
+func TgGraphDependencies(t testing.TestingT, options *Options) *TgDependencyGraph {
+	graph, err := TgGraphDependenciesE(t, options)
+	require.NoError(t, err)
+	return graph
+}
+
+// TgGraphDependenciesE runs terragrunt graph-dependencies in options.TerraformDir and returns the dependency graph of the
+// units of the stack.
+func TgGraphDependenciesE(t testing.TestingT, options *Options) (*TgDependencyGraph, error) {
+	if !isTerragrunt(options) {
+		return nil, TgInvalidBinary(options.TerraformBinary)
+	}
+
+	out, err := RunTerraformCommandAndGetStdoutE(t, options, "graph-dependencies")
+	if err != nil {
+		return nil, err
+	}
+	return parseTgDependencyGraph(out, options.TerraformDir)
+}
+
+// TgOutputAll runs terraform output in every unit of the terragrunt stack in options.TerraformDir and returns the
+// outputs of each unit, keyed by the path of the unit relative to options.TerraformDir. This will fail the test if
+// there is an error in the commands.
+func TgOutputAll(t testing.TestingT, options *Options) map[string]map[string]interface{} {
+	out, err := TgOutputAllE(t, options)
+	require.NoError(t, err)
+	return out
+}
+
+// TgOutputAllE runs terraform output in every unit of the terragrunt stack in options.TerraformDir and returns the
+// outputs of each unit, keyed by the path of the unit relative to options.TerraformDir.
+func TgOutputAllE(t testing.TestingT, options *Options) (map[string]map[string]interface{}, error) {
+	graph, err := TgGraphDependenciesE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := map[string]map[string]interface{}{}
+	for _, unit := range graph.Units {
+		unitOutputs, err := OutputAllE(t, tgUnitOptions(options, unit))
+		if err != nil {
+			return nil, err
+		}
+		outputs[unit] = unitOutputs
+	}
+	return outputs, nil
+}
+
+// TgValidateAll runs terragrunt run-all validate with the given options and returns stdout/stderr. This will fail the
+// test if there is an error in the command.
+func TgValidateAll(t testing.TestingT, options *Options) string {
+	out, err := TgValidateAllE(t, options)
+	require.NoError(t, err)
+	return out
+}
+
+// TgValidateAllE runs terragrunt run-all validate with the given options and returns stdout/stderr.
+func TgValidateAllE(t testing.TestingT, options *Options) (string, error) {
+	if !isTerragrunt(options) {
+		return "", TgInvalidBinary(options.TerraformBinary)
+	}
+
+	return RunTerraformCommandE(t, options, FormatArgs(options, "run-all", "validate")...)
+}
+
+// TgInit runs terragrunt init in the unit at the given path, relative to options.TerraformDir, and returns
+// stdout/stderr. This will fail the test if there is an error in the command.
+func TgInit(t testing.TestingT, options *Options, unit string) string {
+	out, err := TgInitE(t, options, unit)
+	require.NoError(t, err)
+	return out
+}
+
+// TgInitE runs terragrunt init in the unit at the given path, relative to options.TerraformDir, and returns
+// stdout/stderr.
+func TgInitE(t testing.TestingT, options *Options, unit string) (string, error) {
+	if !isTerragrunt(options) {
+		return "", TgInvalidBinary(options.TerraformBinary)
+	}
+
+	return InitE(t, tgUnitOptions(options, unit))
+}
+
+// isTerragrunt returns true if the given options run terragrunt, either because Terragrunt options are set or because
+// TerraformBinary is terragrunt.
+func isTerragrunt(options *Options) bool {
+	return options.Terragrunt != nil || filepath.Base(options.TerraformBinary) == TerragruntDefaultPath
+}
+
+// tgUnitOptions returns a copy of the given options for running commands in the unit at the given path, relative to
+// options.TerraformDir.
+func tgUnitOptions(options *Options, unit string) *Options {
+	unitOptions := *options
+	unitOptions.TerraformDir = filepath.Join(options.TerraformDir, unit)
+	return &unitOptions
+}
+
+var (
+	tgGraphEdgeRegexp = regexp.MustCompile(`^"([^"]+)"\s*->\s*"([^"]+)"\s*;?$`)
+	tgGraphNodeRegexp = regexp.MustCompile(`^"([^"]+)"\s*;?$`)
+)
+
+// parseTgDependencyGraph parses the output of terragrunt graph-dependencies, which is a graph in the DOT format, e.g.:
+//
+//	digraph {
+//		"/live/app" ;
+//		"/live/app" -> "/live/vpc";
+//		"/live/vpc" ;
+//	}
+//
+// Lines that are not nodes or edges of the graph (e.g., terragrunt logs) are ignored. The paths of the units are made
+// relative to rootDir.
+func parseTgDependencyGraph(out string, rootDir string) (*TgDependencyGraph, error) {
+	absRootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(absRootDir); err == nil {
+		absRootDir = resolved
+	}
+
+	relativePath := func(path string) string {
+		if !filepath.IsAbs(path) {
+			return filepath.Clean(path)
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		if rel, err := filepath.Rel(absRootDir, path); err == nil {
+			return rel
+		}
+		return path
+	}
+
+	units := map[string]bool{}
+	dependencies := map[string]map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if matches := tgGraphEdgeRegexp.FindStringSubmatch(line); matches != nil {
+			from, to := relativePath(matches[1]), relativePath(matches[2])
+			units[from] = true
+			units[to] = true
+			if dependencies[from] == nil {
+				dependencies[from] = map[string]bool{}
+			}
+			dependencies[from][to] = true
+		} else if matches := tgGraphNodeRegexp.FindStringSubmatch(line); matches != nil {
+			units[relativePath(matches[1])] = true
+		}
+	}
+
+	graph := &TgDependencyGraph{Units: sortedKeys(units), Dependencies: map[string][]string{}}
+	for _, unit := range graph.Units {
+		graph.Dependencies[unit] = sortedKeys(dependencies[unit])
+	}
+	return graph, nil
+}
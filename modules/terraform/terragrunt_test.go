@@ -0,0 +1,120 @@
+package terraform
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTgDependencyGraph(t *testing.T) {
+	t.Parallel()
+
+	rootDir := t.TempDir()
+	out := `time=2024-06-26T10:00:00Z level=info msg=Found 3 units
+digraph {
+	"` + filepath.Join(rootDir, "app") + `" ;
+	"` + filepath.Join(rootDir, "app") + `" -> "` + filepath.Join(rootDir, "vpc") + `";
+	"` + filepath.Join(rootDir, "app") + `" -> "` + filepath.Join(rootDir, "db") + `";
+	"` + filepath.Join(rootDir, "db") + `" ;
+	"` + filepath.Join(rootDir, "db") + `" -> "` + filepath.Join(rootDir, "vpc") + `";
+	"` + filepath.Join(rootDir, "vpc") + `" ;
+}
+`
+
+	graph, err := parseTgDependencyGraph(out, rootDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"app", "db", "vpc"}, graph.Units)
+	assert.Equal(t, map[string][]string{"app": {"db", "vpc"}, "db": {"vpc"}, "vpc": {}}, graph.Dependencies)
+	assert.Equal(t, []string{"app", "db"}, graph.Dependents("vpc"))
+
+	order, err := graph.Order()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vpc", "db", "app"}, order)
+}
+
+func TestTgDependencyGraphOrderWithCycle(t *testing.T) {
+	t.Parallel()
+
+	graph := &TgDependencyGraph{
+		Units:        []string{"a", "b", "c"},
+		Dependencies: map[string][]string{"a": {"b"}, "b": {"a"}},
+	}
+	_, err := graph.Order()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[a b]")
+}
+
+func TestFormatTerragruntArgs(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"--terragrunt-non-interactive"}, FormatTerragruntArgs(nil))
+	assert.Equal(t, []string{
+		"--terragrunt-non-interactive",
+		"--terragrunt-include-dir", "app",
+		"--terragrunt-exclude-dir", "legacy/*",
+		"--terragrunt-ignore-dependency-errors",
+		"--terragrunt-parallelism=2",
+		"--terragrunt-log-level", "error",
+	}, FormatTerragruntArgs(&TerragruntOptions{
+		IncludeDirs:            []string{"app"},
+		ExcludeDirs:            []string{"legacy/*"},
+		IgnoreDependencyErrors: true,
+		Parallelism:            2,
+		LogLevel:               "error",
+	}))
+}
+
+func TestGetCommonOptionsWithTerragruntOptions(t *testing.T) {
+	t.Parallel()
+
+	options, args := GetCommonOptions(&Options{Terragrunt: &TerragruntOptions{LogLevel: "debug"}}, "plan")
+	assert.Equal(t, TerragruntDefaultPath, options.TerraformBinary)
+	assert.Equal(t, []string{"plan", "--terragrunt-non-interactive", "--terragrunt-log-level", "debug"}, args)
+
+	options, args = GetCommonOptions(&Options{TerraformBinary: "/usr/local/bin/terragrunt"}, "plan")
+	assert.Equal(t, "/usr/local/bin/terragrunt", options.TerraformBinary)
+	assert.Equal(t, []string{"plan", "--terragrunt-non-interactive"}, args)
+}
+
+func TestTgFunctionsRequireTerragrunt(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{TerraformBinary: "terraform"}
+
+	_, err := TgValidateAllE(t, options)
+	assert.IsType(t, TgInvalidBinary(""), err)
+	_, err = TgInitE(t, options, "app")
+	assert.IsType(t, TgInvalidBinary(""), err)
+	_, err = TgOutputAllE(t, options)
+	assert.IsType(t, TgInvalidBinary(""), err)
+}
+
+func TestTgOutputAll(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerragruntFolderToTemp("../../test/fixtures/terragrunt/terragrunt-stack", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+		Terragrunt:   &TerragruntOptions{},
+	}
+	defer TgDestroyAll(t, options)
+
+	TgValidateAll(t, options)
+	TgApplyAll(t, options)
+
+	graph := TgGraphDependencies(t, options)
+	assert.Equal(t, []string{"app", "vpc"}, graph.Units)
+	assert.Equal(t, []string{"vpc"}, graph.Dependencies["app"])
+
+	outputs := TgOutputAll(t, options)
+	assert.Equal(t, map[string]map[string]interface{}{
+		"app": {"app_vpc_id": "vpc-12345678"},
+		"vpc": {"vpc_id": "vpc-12345678"},
+	}, outputs)
+}
@@ -0,0 +1,374 @@
+package terraform
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	gotesting "testing"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// Statuses of test files and run blocks reported by `terraform test`.
+const (
+	TestStatusPending = "pending"
+	TestStatusPass    = "pass"
+	TestStatusFail    = "fail"
+	TestStatusError   = "error"
+	TestStatusSkip    = "skip"
+)
+
+// testAssertionFailedSummary is the summary of the diagnostic terraform test reports for a failed assert block.
+const testAssertionFailedSummary = "Test assertion failed"
+
+// TestResults are the results of running `terraform test`, as parsed from its machine readable (-json) output.
+type TestResults struct {
+	// The test files, in the order they were run.
+	Files []*TestFileResult
+
+	// The overall result of the run, as reported by terraform at the end of the run.
+	Summary TestSummary
+
+	// Diagnostics that could not be attributed to a test file (e.g., errors loading the configuration).
+	Diagnostics []Diagnostic
+}
+
+// TestFileResult is the result of a single test file (e.g., tests/main.tftest.hcl).
+type TestFileResult struct {
+	Path   string
+	Status string
+
+	// The run blocks of the test file, in the order they are declared.
+	Runs []*TestRunResult
+
+	// Diagnostics that could not be attributed to a run block of the file.
+	Diagnostics []Diagnostic
+}
+
+// TestRunResult is the result of a single run block within a test file.
+type TestRunResult struct {
+	File        string
+	Name        string
+	Status      string
+	Diagnostics []Diagnostic
+
+	// The plan of the run block. Only set for run blocks with `command = plan`, when terraform test is run in verbose
+	// mode (see InitAndPlanWithMocks).
+	Plan *PlanStruct
+}
+
+// TestSummary is the summary terraform test reports at the end of the run.
+type TestSummary struct {
+	Status  string `json:"status"`
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+	Errored int    `json:"errored"`
+	Skipped int    `json:"skipped"`
+}
+
+// FailedAssertions returns the diagnostics of the assert blocks of the run block that failed. Their Detail contains
+// the error_message of the assert block.
+func (run *TestRunResult) FailedAssertions() []Diagnostic {
+	failed := []Diagnostic{}
+	for _, diag := range run.Diagnostics {
+		if diag.Summary == testAssertionFailedSummary {
+			failed = append(failed, diag)
+		}
+	}
+	return failed
+}
+
+// Failed returns true if any test file or run block failed or errored.
+func (results *TestResults) Failed() bool {
+	if results.Summary.Status == TestStatusFail || results.Summary.Status == TestStatusError {
+		return true
+	}
+	for _, file := range results.Files {
+		if file.Status == TestStatusFail || file.Status == TestStatusError {
+			return true
+		}
+	}
+	return false
+}
+
+// Run returns the result of the run block with the given name in the test file with the given path, or nil if there
+// is no such run block.
+func (results *TestResults) Run(path string, name string) *TestRunResult {
+	for _, file := range results.Files {
+		if file.Path != path {
+			continue
+		}
+		for _, run := range file.Runs {
+			if run.Name == name {
+				return run
+			}
+		}
+	}
+	return nil
+}
+
+// Test runs terraform test with the given options and reports each test file and each run block in it as a Go
+// subtest (if t supports subtests, as *testing.T does), so that native Terraform tests show up in the results of the
+// Go test suite. If filters are given, only the test files matching them are run (-filter). Returns the parsed results.
+// This will fail the test if any run block fails, or if there is an error running the command.
+func Test(t testing.TestingT, options *Options, filters ...string) *TestResults {
+	results, err := TestE(t, options, filters...)
+	require.NoError(t, err)
+	ReportTestResults(t, results)
+	return results
+}
+
+// TestE runs terraform test with the given options and returns the parsed results. If filters are given, only the test
+// files matching them are run (-filter). Failing run blocks do NOT result in an error: check the results (e.g., with
+// TestResults.Failed) instead. An error is only returned if terraform test could not be run or its output could not be
+// parsed.
+func TestE(t testing.TestingT, options *Options, filters ...string) (*TestResults, error) {
+	return runTestE(t, options, false, filters...)
+}
+
+// runTestE runs terraform test with the given options and returns the parsed results. If verbose is set, the plan of
+// each run block is included in the results.
+func runTestE(t testing.TestingT, options *Options, verbose bool, filters ...string) (*TestResults, error) {
+	args := FormatTestArgs(options, filters...)
+	if verbose {
+		args = append(args, "-verbose")
+	}
+	out, runErr := RunTerraformCommandE(t, options, args...)
+
+	results, err := ParseTestJSONOutput(out)
+	if err != nil {
+		return nil, err
+	}
+	// terraform test exits with an error when tests fail. That is only an error for us if we couldn't get a summary.
+	if runErr != nil && results.Summary.Status == "" {
+		return results, runErr
+	}
+	return results, nil
+}
+
+// InitAndTest runs terraform init and test with the given options and reports the results as Go subtests. See Test for
+// details. This will fail the test if any run block fails, or if there is an error running the commands.
+func InitAndTest(t testing.TestingT, options *Options, filters ...string) *TestResults {
+	results, err := InitAndTestE(t, options, filters...)
+	require.NoError(t, err)
+	ReportTestResults(t, results)
+	return results
+}
+
+// InitAndTestE runs terraform init and test with the given options and returns the parsed results. See TestE for
+// details.
+func InitAndTestE(t testing.TestingT, options *Options, filters ...string) (*TestResults, error) {
+	if _, err := InitE(t, options); err != nil {
+		return nil, err
+	}
+
+	return TestE(t, options, filters...)
+}
+
+// FormatTestArgs formats the arguments for the terraform test command. terraform test only supports a subset of the
+// args supported by plan and apply, so we don't use FormatArgs here.
+func FormatTestArgs(options *Options, filters ...string) []string {
+	args := []string{"test", "-json"}
+	if options.SetVarsAfterVarFiles {
+		args = append(args, FormatTerraformArgs("-var-file", options.VarFiles)...)
+		args = append(args, FormatTerraformVarsAsArgs(options.Vars)...)
+	} else {
+		args = append(args, FormatTerraformVarsAsArgs(options.Vars)...)
+		args = append(args, FormatTerraformArgs("-var-file", options.VarFiles)...)
+	}
+	for _, filter := range filters {
+		args = append(args, fmt.Sprintf("-filter=%s", filter))
+	}
+	if options.NoColor {
+		args = append(args, "-no-color")
+	}
+	return args
+}
+
+// subtestRunner is implemented by *testing.T.
+type subtestRunner interface {
+	Run(name string, f func(t *gotesting.T)) bool
+}
+
+// ReportTestResults reports the given terraform test results to t. If t supports subtests (as *testing.T does), each
+// test file is reported as a subtest of t, and each run block as a subtest of its file. Otherwise, every failure is
+// reported directly on t.
+func ReportTestResults(t testing.TestingT, results *TestResults) {
+	for _, diag := range results.Diagnostics {
+		reportTestDiagnostic(t, diag)
+	}
+
+	runner, supportsSubtests := t.(subtestRunner)
+	for _, file := range results.Files {
+		file := file
+		if !supportsSubtests {
+			reportTestFile(t, file)
+			continue
+		}
+		runner.Run(file.Path, func(t *gotesting.T) {
+			reportTestFile(t, file)
+		})
+	}
+}
+
+// reportTestFile reports the result of the given test file, and of each of its run blocks, to t.
+func reportTestFile(t testing.TestingT, file *TestFileResult) {
+	for _, diag := range file.Diagnostics {
+		reportTestDiagnostic(t, diag)
+	}
+
+	runner, supportsSubtests := t.(subtestRunner)
+	for _, run := range file.Runs {
+		run := run
+		if !supportsSubtests {
+			reportTestRun(t, run)
+			continue
+		}
+		runner.Run(run.Name, func(t *gotesting.T) {
+			reportTestRun(t, run)
+		})
+	}
+}
+
+// reportTestRun reports the result of the given run block to t.
+func reportTestRun(t testing.TestingT, run *TestRunResult) {
+	for _, diag := range run.Diagnostics {
+		reportTestDiagnostic(t, diag)
+	}
+
+	switch run.Status {
+	case TestStatusFail, TestStatusError:
+		t.Errorf("run %q in %s finished with status %s", run.Name, run.File, run.Status)
+	case TestStatusSkip:
+		if skipper, canSkip := t.(interface{ Skip(args ...interface{}) }); canSkip {
+			skipper.Skip(fmt.Sprintf("run %q in %s was skipped", run.Name, run.File))
+		}
+	}
+}
+
+// reportTestDiagnostic reports the given diagnostic to t. Errors fail the test, warnings are only logged.
+func reportTestDiagnostic(t testing.TestingT, diag Diagnostic) {
+	if diag.Severity == "error" {
+		t.Errorf("%s", diag.Error())
+		return
+	}
+	if logger, canLog := t.(interface {
+		Logf(format string, args ...interface{})
+	}); canLog {
+		logger.Logf("%s", diag.Error())
+	}
+}
+
+// testJSONMessage is a single line of the machine readable output of terraform test. See
+// https://developer.hashicorp.com/terraform/internals/machine-readable-ui for details.
+type testJSONMessage struct {
+	Type string `json:"type"`
+	File string `json:"@testfile"`
+	Run  string `json:"@testrun"`
+
+	TestAbstract map[string][]string `json:"test_abstract"`
+	TestFile     *testFileStatus     `json:"test_file"`
+	TestRun      *testRunStatus      `json:"test_run"`
+	TestSummary  *TestSummary        `json:"test_summary"`
+	TestPlan     json.RawMessage     `json:"test_plan"`
+	Diagnostic   *Diagnostic         `json:"diagnostic"`
+}
+
+type testFileStatus struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+type testRunStatus struct {
+	Path   string `json:"path"`
+	Run    string `json:"run"`
+	Status string `json:"status"`
+}
+
+// ParseTestJSONOutput parses the machine readable output of `terraform test -json` into TestResults. Lines that are
+// not json objects (e.g., output that terraform writes to stderr) are ignored.
+func ParseTestJSONOutput(out string) (*TestResults, error) {
+	results := &TestResults{}
+	files := map[string]*TestFileResult{}
+
+	getFile := func(path string) *TestFileResult {
+		file, exists := files[path]
+		if !exists {
+			file = &TestFileResult{Path: path, Status: TestStatusPending}
+			files[path] = file
+			results.Files = append(results.Files, file)
+		}
+		return file
+	}
+	getRun := func(path string, name string) *TestRunResult {
+		file := getFile(path)
+		for _, run := range file.Runs {
+			if run.Name == name {
+				return run
+			}
+		}
+		run := &TestRunResult{File: path, Name: name, Status: TestStatusPending}
+		file.Runs = append(file.Runs, run)
+		return run
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var msg testJSONMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case msg.TestAbstract != nil:
+			// terraform runs the test files in alphabetical order, and the run blocks in declaration order.
+			paths := make([]string, 0, len(msg.TestAbstract))
+			for path := range msg.TestAbstract {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			for _, path := range paths {
+				for _, name := range msg.TestAbstract[path] {
+					getRun(path, name)
+				}
+			}
+		case msg.TestFile != nil:
+			getFile(msg.TestFile.Path).Status = msg.TestFile.Status
+		case msg.TestRun != nil:
+			getRun(msg.TestRun.Path, msg.TestRun.Run).Status = msg.TestRun.Status
+		case msg.TestSummary != nil:
+			results.Summary = *msg.TestSummary
+		case msg.TestPlan != nil && msg.File != "" && msg.Run != "":
+			plan, err := ParsePlanJSON(string(msg.TestPlan))
+			if err != nil {
+				return nil, err
+			}
+			getRun(msg.File, msg.Run).Plan = plan
+		case msg.Diagnostic != nil:
+			switch {
+			case msg.File != "" && msg.Run != "":
+				run := getRun(msg.File, msg.Run)
+				run.Diagnostics = append(run.Diagnostics, *msg.Diagnostic)
+			case msg.File != "":
+				file := getFile(msg.File)
+				file.Diagnostics = append(file.Diagnostics, *msg.Diagnostic)
+			default:
+				results.Diagnostics = append(results.Diagnostics, *msg.Diagnostic)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
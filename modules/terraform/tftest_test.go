@@ -0,0 +1,108 @@
+package terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJSONOutputWithFailure = `{"@level":"info","@message":"Found 1 file and 3 run blocks","@module":"terraform.ui","test_abstract":{"tests/main.tftest.hcl":["valid","invalid","skipped"]},"type":"test_abstract"}
+{"@level":"info","@message":"tests/main.tftest.hcl... in progress","@module":"terraform.ui","@testfile":"tests/main.tftest.hcl","test_file":{"path":"tests/main.tftest.hcl","status":"pending"},"type":"test_file"}
+{"@level":"info","@message":"  \"valid\"... pass","@module":"terraform.ui","@testfile":"tests/main.tftest.hcl","@testrun":"valid","test_run":{"path":"tests/main.tftest.hcl","run":"valid","status":"pass"},"type":"test_run"}
+{"@level":"error","@message":"Error: Test assertion failed","@module":"terraform.ui","@testfile":"tests/main.tftest.hcl","@testrun":"invalid","diagnostic":{"severity":"error","summary":"Test assertion failed","detail":"greeting did not match","range":{"filename":"tests/main.tftest.hcl","start":{"line":12,"column":21,"byte":150},"end":{"line":12,"column":55,"byte":184}}},"type":"diagnostic"}
+{"@level":"info","@message":"  \"invalid\"... fail","@module":"terraform.ui","@testfile":"tests/main.tftest.hcl","@testrun":"invalid","test_run":{"path":"tests/main.tftest.hcl","run":"invalid","status":"fail"},"type":"test_run"}
+{"@level":"info","@message":"  \"skipped\"... skip","@module":"terraform.ui","@testfile":"tests/main.tftest.hcl","@testrun":"skipped","test_run":{"path":"tests/main.tftest.hcl","run":"skipped","status":"skip"},"type":"test_run"}
+{"@level":"info","@message":"tests/main.tftest.hcl... fail","@module":"terraform.ui","@testfile":"tests/main.tftest.hcl","test_file":{"path":"tests/main.tftest.hcl","status":"fail"},"type":"test_file"}
+{"@level":"info","@message":"Failure! 1 passed, 1 failed, 1 skipped.","@module":"terraform.ui","test_summary":{"status":"fail","passed":1,"failed":1,"errored":0,"skipped":1},"type":"test_summary"}
+`
+
+// recordingT is a TestingT that records the errors reported to it, without supporting subtests.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Fail()                                     {}
+func (t *recordingT) FailNow()                                  {}
+func (t *recordingT) Fatal(args ...interface{})                 { t.Error(args...) }
+func (t *recordingT) Fatalf(format string, args ...interface{}) { t.Errorf(format, args...) }
+func (t *recordingT) Error(args ...interface{})                 { t.errors = append(t.errors, fmt.Sprint(args...)) }
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+func (t *recordingT) Name() string { return "recordingT" }
+
+func TestParseTestJSONOutput(t *testing.T) {
+	t.Parallel()
+
+	results, err := ParseTestJSONOutput(testJSONOutputWithFailure)
+	require.NoError(t, err)
+
+	assert.True(t, results.Failed())
+	assert.Equal(t, TestSummary{Status: TestStatusFail, Passed: 1, Failed: 1, Skipped: 1}, results.Summary)
+	require.Len(t, results.Files, 1)
+	assert.Equal(t, "tests/main.tftest.hcl", results.Files[0].Path)
+	assert.Equal(t, TestStatusFail, results.Files[0].Status)
+
+	require.Len(t, results.Files[0].Runs, 3)
+	assert.Equal(t, TestStatusPass, results.Run("tests/main.tftest.hcl", "valid").Status)
+	assert.Equal(t, TestStatusSkip, results.Run("tests/main.tftest.hcl", "skipped").Status)
+	assert.Nil(t, results.Run("tests/main.tftest.hcl", "missing"))
+
+	invalid := results.Run("tests/main.tftest.hcl", "invalid")
+	assert.Equal(t, TestStatusFail, invalid.Status)
+	failed := invalid.FailedAssertions()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "greeting did not match", failed[0].Detail)
+	assert.Equal(t, 12, failed[0].Range.Start.Line)
+}
+
+func TestParseTestJSONOutputIgnoresNonJsonLines(t *testing.T) {
+	t.Parallel()
+
+	results, err := ParseTestJSONOutput("Running command terraform test\n" + `{"test_summary":{"status":"pass","passed":2},"type":"test_summary"}`)
+	require.NoError(t, err)
+	assert.False(t, results.Failed())
+	assert.Equal(t, 2, results.Summary.Passed)
+}
+
+func TestReportTestResultsWithoutSubtests(t *testing.T) {
+	t.Parallel()
+
+	results, err := ParseTestJSONOutput(testJSONOutputWithFailure)
+	require.NoError(t, err)
+
+	recorder := &recordingT{}
+	ReportTestResults(recorder, results)
+	require.Len(t, recorder.errors, 2)
+	assert.Contains(t, recorder.errors[0], "greeting did not match")
+	assert.Contains(t, recorder.errors[1], `run "invalid" in tests/main.tftest.hcl finished with status fail`)
+}
+
+func TestFormatTestArgs(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		Vars:     map[string]interface{}{"name": "World"},
+		VarFiles: []string{"test.tfvars"},
+		NoColor:  true,
+	}
+	args := FormatTestArgs(options, "tests/main.tftest.hcl")
+	assert.Equal(t, []string{"test", "-json", "-var", "name=World", "-var-file", "test.tfvars", "-filter=tests/main.tftest.hcl", "-no-color"}, args)
+}
+
+func TestInitAndTest(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-test", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+	results := InitAndTest(t, options)
+	assert.False(t, results.Failed())
+	assert.Equal(t, 2, results.Summary.Passed)
+}
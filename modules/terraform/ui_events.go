@@ -0,0 +1,277 @@
+package terraform
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// Types of the messages Terraform streams in its machine readable UI (-json). See
+// https://developer.hashicorp.com/terraform/internals/machine-readable-ui for details.
+const (
+	UIEventVersion           = "version"
+	UIEventLog               = "log"
+	UIEventDiagnostic        = "diagnostic"
+	UIEventPlannedChange     = "planned_change"
+	UIEventResourceDrift     = "resource_drift"
+	UIEventChangeSummary     = "change_summary"
+	UIEventOutputs           = "outputs"
+	UIEventApplyStart        = "apply_start"
+	UIEventApplyProgress     = "apply_progress"
+	UIEventApplyComplete     = "apply_complete"
+	UIEventApplyErrored      = "apply_errored"
+	UIEventRefreshStart      = "refresh_start"
+	UIEventRefreshComplete   = "refresh_complete"
+	UIEventProvisionStart    = "provision_start"
+	UIEventProvisionComplete = "provision_complete"
+)
+
+// UIEvent is a single message of Terraform's machine readable UI. Which of the optional fields is set depends on the
+// Type of the event.
+type UIEvent struct {
+	Level     string    `json:"@level"`
+	Message   string    `json:"@message"`
+	Module    string    `json:"@module"`
+	Timestamp time.Time `json:"@timestamp"`
+	Type      string    `json:"type"`
+
+	// Set for the apply_*, refresh_* and provision_* events.
+	Hook *UIHook `json:"hook,omitempty"`
+
+	// Set for planned_change and resource_drift events.
+	Change *UIResourceChange `json:"change,omitempty"`
+
+	// Set for change_summary events.
+	Changes *UIChangeSummary `json:"changes,omitempty"`
+
+	// Set for diagnostic events.
+	Diagnostic *Diagnostic `json:"diagnostic,omitempty"`
+
+	// Set for outputs events.
+	Outputs map[string]UIOutput `json:"outputs,omitempty"`
+}
+
+// UIResource identifies the resource an event refers to.
+type UIResource struct {
+	Addr            string      `json:"addr"`
+	Module          string      `json:"module"`
+	Resource        string      `json:"resource"`
+	ImpliedProvider string      `json:"implied_provider"`
+	ResourceType    string      `json:"resource_type"`
+	ResourceName    string      `json:"resource_name"`
+	ResourceKey     interface{} `json:"resource_key"`
+}
+
+// UIHook is the progress of an operation on a single resource.
+type UIHook struct {
+	Resource       UIResource `json:"resource"`
+	Action         string     `json:"action"`
+	IDKey          string     `json:"id_key,omitempty"`
+	IDValue        string     `json:"id_value,omitempty"`
+	ElapsedSeconds int        `json:"elapsed_seconds,omitempty"`
+}
+
+// UIResourceChange is a change terraform plans to make to a resource (planned_change), or a change that was made to a
+// resource outside of terraform (resource_drift).
+type UIResourceChange struct {
+	Resource UIResource `json:"resource"`
+	Action   string     `json:"action"`
+	Reason   string     `json:"reason,omitempty"`
+}
+
+// UIChangeSummary is the number of resources added, changed, imported and removed by a plan, apply or destroy.
+type UIChangeSummary struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Import    int    `json:"import"`
+	Remove    int    `json:"remove"`
+	Operation string `json:"operation"`
+}
+
+// UIOutput is an output value as reported in an outputs event.
+type UIOutput struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type,omitempty"`
+	Value     interface{}     `json:"value,omitempty"`
+	Action    string          `json:"action,omitempty"`
+}
+
+// UIEvents is the log of events Terraform streamed while running a command with the machine readable UI.
+type UIEvents struct {
+	Events []UIEvent
+}
+
+// OfType returns the events of any of the given types, in the order they were streamed.
+func (events *UIEvents) OfType(types ...string) []UIEvent {
+	matching := []UIEvent{}
+	for _, event := range events.Events {
+		for _, eventType := range types {
+			if event.Type == eventType {
+				matching = append(matching, event)
+				break
+			}
+		}
+	}
+	return matching
+}
+
+// Diagnostics returns all the warnings and errors reported by terraform.
+func (events *UIEvents) Diagnostics() []Diagnostic {
+	return events.diagnosticsWithSeverity("")
+}
+
+// Errors returns the error diagnostics reported by terraform.
+func (events *UIEvents) Errors() []Diagnostic {
+	return events.diagnosticsWithSeverity("error")
+}
+
+// Warnings returns the warning diagnostics reported by terraform.
+func (events *UIEvents) Warnings() []Diagnostic {
+	return events.diagnosticsWithSeverity("warning")
+}
+
+func (events *UIEvents) diagnosticsWithSeverity(severity string) []Diagnostic {
+	diags := []Diagnostic{}
+	for _, event := range events.OfType(UIEventDiagnostic) {
+		if event.Diagnostic != nil && (severity == "" || event.Diagnostic.Severity == severity) {
+			diags = append(diags, *event.Diagnostic)
+		}
+	}
+	return diags
+}
+
+// ChangeSummary returns the last change summary reported by terraform, or nil if there is none (e.g., because the
+// command failed).
+func (events *UIEvents) ChangeSummary() *UIChangeSummary {
+	summaries := events.OfType(UIEventChangeSummary)
+	if len(summaries) == 0 {
+		return nil
+	}
+	return summaries[len(summaries)-1].Changes
+}
+
+// AppliedResources returns the addresses of the resources terraform successfully applied a change to, in the order
+// the changes completed.
+func (events *UIEvents) AppliedResources() []string {
+	addresses := []string{}
+	for _, event := range events.OfType(UIEventApplyComplete) {
+		if event.Hook != nil {
+			addresses = append(addresses, event.Hook.Resource.Addr)
+		}
+	}
+	return addresses
+}
+
+// ParseUIEvents parses the output of a terraform command run with -json (see Options.JsonUI) into a log of typed
+// events. Lines that are not json objects (e.g., output that terraform writes to stderr, or pretty printed json) are
+// ignored.
+func ParseUIEvents(out string) (*UIEvents, error) {
+	events := &UIEvents{Events: []UIEvent{}}
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var event UIEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue
+		}
+		events.Events = append(events.Events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// PlanWithEvents runs terraform plan with the given options and the machine readable UI, and returns stdout/stderr
+// along with the parsed events. This will fail the test if there is an error in the command.
+func PlanWithEvents(t testing.TestingT, options *Options) (string, *UIEvents) {
+	out, events, err := PlanWithEventsE(t, options)
+	require.NoError(t, err)
+	return out, events
+}
+
+// PlanWithEventsE runs terraform plan with the given options and the machine readable UI, and returns stdout/stderr
+// along with the parsed events. The events are also returned if the command fails, so that its diagnostics can be
+// inspected.
+func PlanWithEventsE(t testing.TestingT, options *Options) (string, *UIEvents, error) {
+	return runWithEventsE(t, options, "plan", "-input=false", "-lock=false")
+}
+
+// ApplyWithEvents runs terraform apply with the given options and the machine readable UI, and returns stdout/stderr
+// along with the parsed events. This will fail the test if there is an error in the command. Note that this method does
+// NOT call destroy and assumes the caller is responsible for cleaning up any resources created by running apply.
+func ApplyWithEvents(t testing.TestingT, options *Options) (string, *UIEvents) {
+	out, events, err := ApplyWithEventsE(t, options)
+	require.NoError(t, err)
+	return out, events
+}
+
+// ApplyWithEventsE runs terraform apply with the given options and the machine readable UI, and returns stdout/stderr
+// along with the parsed events. The events are also returned if the command fails, so that its diagnostics can be
+// inspected.
+func ApplyWithEventsE(t testing.TestingT, options *Options) (string, *UIEvents, error) {
+	return runWithEventsE(t, options, "apply", "-input=false", "-auto-approve")
+}
+
+// DestroyWithEvents runs terraform destroy with the given options and the machine readable UI, and returns
+// stdout/stderr along with the parsed events. This will fail the test if there is an error in the command.
+func DestroyWithEvents(t testing.TestingT, options *Options) (string, *UIEvents) {
+	out, events, err := DestroyWithEventsE(t, options)
+	require.NoError(t, err)
+	return out, events
+}
+
+// DestroyWithEventsE runs terraform destroy with the given options and the machine readable UI, and returns
+// stdout/stderr along with the parsed events. The events are also returned if the command fails, so that its
+// diagnostics can be inspected.
+func DestroyWithEventsE(t testing.TestingT, options *Options) (string, *UIEvents, error) {
+	options, cancel := withCleanupContext(options)
+	defer cancel()
+
+	return runWithEventsE(t, options, "destroy", "-auto-approve", "-input=false")
+}
+
+// runWithEventsE runs the given terraform command with the machine readable UI enabled, regardless of
+// options.JsonUI, and parses its output.
+func runWithEventsE(t testing.TestingT, options *Options, args ...string) (string, *UIEvents, error) {
+	jsonOptions := *options
+	jsonOptions.JsonUI = true
+
+	out, err := RunTerraformCommandE(t, &jsonOptions, FormatArgs(&jsonOptions, args...)...)
+	events, parseErr := ParseUIEvents(out)
+	if parseErr != nil {
+		if err == nil {
+			err = parseErr
+		}
+		return out, nil, err
+	}
+	return out, events, err
+}
+
+// withDiagnostics wraps the given error of the given terraform command in a DiagnosticsError carrying the error
+// diagnostics terraform reported in the given output, if the command was run with the machine readable UI.
+func withDiagnostics(options *Options, args []string, out string, err error) error {
+	if !usesJsonUI(options, args) {
+		return err
+	}
+	events, parseErr := ParseUIEvents(out)
+	if parseErr != nil {
+		return err
+	}
+	diags := events.Errors()
+	if len(diags) == 0 {
+		return err
+	}
+	return DiagnosticsError{Diagnostics: diags, Underlying: err}
+}
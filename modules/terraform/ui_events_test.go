@@ -0,0 +1,144 @@
+package terraform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/nholuongut/terratest/modules/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const applyJsonUIOutput = `{"@level":"info","@message":"Terraform 1.9.0","@module":"terraform.ui","@timestamp":"2024-06-26T10:00:00.000000+00:00","terraform":"1.9.0","type":"version","ui":"1.2"}
+{"@level":"info","@message":"null_resource.test[0]: Plan to create","@module":"terraform.ui","@timestamp":"2024-06-26T10:00:00.100000+00:00","change":{"resource":{"addr":"null_resource.test[0]","module":"","resource":"null_resource.test[0]","implied_provider":"null","resource_type":"null_resource","resource_name":"test","resource_key":0},"action":"create"},"type":"planned_change"}
+{"@level":"warn","@message":"Warning: Deprecated attribute","@module":"terraform.ui","@timestamp":"2024-06-26T10:00:00.200000+00:00","diagnostic":{"severity":"warning","summary":"Deprecated attribute","detail":"The attribute \"triggers\" is deprecated.","address":"null_resource.test[0]","range":{"filename":"main.tf","start":{"line":3,"column":3,"byte":40},"end":{"line":3,"column":11,"byte":48}}},"type":"diagnostic"}
+{"@level":"info","@message":"null_resource.test[0]: Creating...","@module":"terraform.ui","@timestamp":"2024-06-26T10:00:00.300000+00:00","hook":{"resource":{"addr":"null_resource.test[0]","module":"","resource":"null_resource.test[0]","implied_provider":"null","resource_type":"null_resource","resource_name":"test","resource_key":0},"action":"create"},"type":"apply_start"}
+{"@level":"info","@message":"null_resource.test[0]: Creation complete after 0s [id=123]","@module":"terraform.ui","@timestamp":"2024-06-26T10:00:00.400000+00:00","hook":{"resource":{"addr":"null_resource.test[0]","module":"","resource":"null_resource.test[0]","implied_provider":"null","resource_type":"null_resource","resource_name":"test","resource_key":0},"action":"create","id_key":"id","id_value":"123","elapsed_seconds":0},"type":"apply_complete"}
+{"@level":"info","@message":"Apply complete! Resources: 1 added, 0 changed, 0 destroyed.","@module":"terraform.ui","@timestamp":"2024-06-26T10:00:00.500000+00:00","changes":{"add":1,"change":0,"import":0,"remove":0,"operation":"apply"},"type":"change_summary"}
+{"@level":"info","@message":"Outputs: 1","@module":"terraform.ui","@timestamp":"2024-06-26T10:00:00.600000+00:00","outputs":{"id":{"sensitive":false,"type":"string","value":"123"}},"type":"outputs"}`
+
+const failedJsonUIOutput = `{"@level":"info","@message":"Terraform 1.9.0","@module":"terraform.ui","@timestamp":"2024-06-26T10:00:00.000000+00:00","terraform":"1.9.0","type":"version","ui":"1.2"}
+{"@level":"error","@message":"Error: Invalid reference","@module":"terraform.ui","@timestamp":"2024-06-26T10:00:00.100000+00:00","diagnostic":{"severity":"error","summary":"Invalid reference","detail":"A reference to a resource type must be followed by at least one attribute access.","range":{"filename":"main.tf","start":{"line":7,"column":11,"byte":90},"end":{"line":7,"column":15,"byte":94}}},"type":"diagnostic"}`
+
+func TestParseUIEvents(t *testing.T) {
+	t.Parallel()
+
+	events, err := ParseUIEvents("Some text terraform wrote to stderr\n{\n" + applyJsonUIOutput)
+	require.NoError(t, err)
+
+	require.Len(t, events.Events, 7)
+	assert.Len(t, events.OfType(UIEventApplyStart, UIEventApplyComplete), 2)
+	assert.Equal(t, []string{"null_resource.test[0]"}, events.AppliedResources())
+	assert.Equal(t, &UIChangeSummary{Add: 1, Operation: "apply"}, events.ChangeSummary())
+	assert.Empty(t, events.Errors())
+
+	planned := events.OfType(UIEventPlannedChange)
+	require.Len(t, planned, 1)
+	assert.Equal(t, "create", planned[0].Change.Action)
+	assert.Equal(t, "null_resource", planned[0].Change.Resource.ResourceType)
+
+	warnings := events.Warnings()
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "Deprecated attribute", warnings[0].Summary)
+	assert.Equal(t, "null_resource.test[0]", warnings[0].Address)
+	assert.Equal(t, 3, warnings[0].Range.Start.Line)
+
+	outputs := events.OfType(UIEventOutputs)
+	require.Len(t, outputs, 1)
+	assert.Equal(t, "123", outputs[0].Outputs["id"].Value)
+}
+
+func TestGetResourceCountEJsonUI(t *testing.T) {
+	t.Parallel()
+
+	cnt, err := GetResourceCountE(t, applyJsonUIOutput)
+	require.NoError(t, err)
+	assert.Equal(t, &ResourceCount{Add: 1}, cnt)
+}
+
+func TestWithDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	runErr := errors.New("exit status 1")
+
+	assert.Equal(t, runErr, withDiagnostics(&Options{}, []string{"apply"}, failedJsonUIOutput, runErr))
+
+	err := withDiagnostics(&Options{JsonUI: true}, []string{"apply"}, failedJsonUIOutput, runErr)
+	var diagErr DiagnosticsError
+	require.ErrorAs(t, err, &diagErr)
+	require.ErrorIs(t, err, runErr)
+	require.Len(t, diagErr.Diagnostics, 1)
+	assert.Equal(t, "main.tf", diagErr.Diagnostics[0].Range.Filename)
+	assert.Contains(t, err.Error(), "Invalid reference")
+}
+
+func TestHasWarningJsonUI(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, hasWarning(&Options{JsonUI: true, WarningsAsErrors: map[string]string{"^Value for undeclared variable$": ""}}, []string{"apply"}, applyJsonUIOutput))
+
+	err := hasWarning(&Options{JsonUI: true, WarningsAsErrors: map[string]string{"^Deprecated": "no deprecations allowed"}}, []string{"apply"}, applyJsonUIOutput)
+	var diagErr DiagnosticsError
+	require.ErrorAs(t, err, &diagErr)
+	assert.Equal(t, "null_resource.test[0]", diagErr.Diagnostics[0].Address)
+	assert.Contains(t, err.Error(), "no deprecations allowed")
+}
+
+func TestHasWarningJsonUIOnlyForCommandsWithJsonUISupport(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{JsonUI: true, WarningsAsErrors: map[string]string{"Deprecated": "no deprecations allowed"}}
+
+	outputJson := "{\n  \"id\": {\n    \"sensitive\": false,\n    \"type\": \"string\",\n    \"value\": \"123\"\n  }\n}\n"
+	assert.NoError(t, hasWarning(options, []string{"output", "-no-color", "-json"}, outputJson))
+
+	initOutput := "Initializing the backend...\n\nWarning: Deprecated parameter\n\nThe parameter is deprecated.\n"
+	err := hasWarning(options, []string{"init", "-upgrade=false"}, initOutput)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no deprecations allowed")
+}
+
+func TestWithRetryableDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{JsonUI: true}
+	runErr := errors.New("exit status 1")
+	action := func() (string, error) {
+		return failedJsonUIOutput, withDiagnostics(options, []string{"apply"}, failedJsonUIOutput, runErr)
+	}
+
+	retryable, err := withRetryableDiagnostics(t, "apply", map[string]string{"^A reference to a resource type": "retry it"}, action)
+	require.NoError(t, err)
+	_, err = retryable()
+	require.ErrorIs(t, err, runErr)
+	_, isFatalErr := err.(retry.FatalError)
+	assert.False(t, isFatalErr)
+
+	// The raw json output contains the message too, but only the summary and detail of the diagnostics are matched.
+	fatal, err := withRetryableDiagnostics(t, "apply", map[string]string{`"severity":"error"`: "retry it"}, action)
+	require.NoError(t, err)
+	_, err = fatal()
+	_, isFatalErr = err.(retry.FatalError)
+	assert.True(t, isFatalErr)
+}
+
+func TestApplyWithEvents(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-basic-configuration", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+		Vars: map[string]interface{}{
+			"cnt": 1,
+		},
+	}
+	defer Destroy(t, options)
+
+	Init(t, options)
+	_, events := ApplyWithEvents(t, options)
+	assert.Equal(t, 1, events.ChangeSummary().Add)
+	assert.Len(t, events.AppliedResources(), 1)
+}
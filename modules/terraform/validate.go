@@ -26,7 +26,7 @@ func ValidateE(t testing.TestingT, options *Options) (string, error) {
 
 // ValidateInputsE calls terragrunt validate-inputs and returns stdout/stderr
 func ValidateInputsE(t testing.TestingT, options *Options) (string, error) {
-	if options.TerraformBinary != "terragrunt" {
+	if !isTerragrunt(options) {
 		return "", TgInvalidBinary(options.TerraformBinary)
 	}
 	return RunTerraformCommandE(t, options, FormatArgs(options, "validate-inputs")...)
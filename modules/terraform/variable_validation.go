@@ -0,0 +1,183 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	gotesting "testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// invalidVariableValueSummary is the summary of the diagnostic terraform reports when a validation rule of a variable
+// fails.
+const invalidVariableValueSummary = "Invalid value for variable"
+
+// VariableValidationResult is the result of planning a module with a single variable set to a given value.
+type VariableValidationResult struct {
+	Variable string
+	Value    interface{}
+
+	// True if a validation rule of the variable rejected the value.
+	Rejected bool
+
+	// The error_message of each validation rule of the variable that rejected the value.
+	ErrorMessages []string
+
+	// The diagnostics of the failed validation rules.
+	Diagnostics []Diagnostic
+}
+
+// VariableValidationTestCase is a single check of TestVariableValidations.
+type VariableValidationTestCase struct {
+	// The name of the subtest. Defaults to "<variable>=<value>".
+	Name string
+
+	// The variable to set, and the value to set it to.
+	Variable string
+	Value    interface{}
+
+	// A regular expression that the error_message of a validation rule rejecting the value must match. If empty, the
+	// value is expected to be accepted.
+	ExpectedError string
+}
+
+// ValidateVariable copies options.TerraformDir to a temp folder, and runs terraform init and plan there with the given
+// variable set to the given value (in addition to options.Vars), to check whether the validation rules of the variable
+// accept the value. This will fail the test if there is an error other than a failed validation rule of the variable.
+func ValidateVariable(t testing.TestingT, options *Options, variable string, value interface{}) *VariableValidationResult {
+	result, err := ValidateVariableE(t, options, variable, value)
+	require.NoError(t, err)
+	return result
+}
+
+// ValidateVariableE copies options.TerraformDir to a temp folder, and runs terraform init and plan there with the
+// given variable set to the given value (in addition to options.Vars), to check whether the validation rules of the
+// variable accept the value. An error is returned if planning fails for any reason other than a failed validation rule
+// of the variable. Note that the module is planned with -refresh=false, but its providers must still be configurable.
+func ValidateVariableE(t testing.TestingT, options *Options, variable string, value interface{}) (*VariableValidationResult, error) {
+	testFolder, err := files.CopyTerraformFolderToTemp(options.TerraformDir, fmt.Sprintf("validate-var-%s", variable))
+	if err != nil {
+		return nil, err
+	}
+
+	varOptions, err := options.Clone()
+	if err != nil {
+		return nil, err
+	}
+	varOptions.TerraformDir = testFolder
+	varOptions.Vars[variable] = value
+
+	if _, err := InitE(t, varOptions); err != nil {
+		return nil, err
+	}
+	_, events, planErr := runWithEventsE(t, varOptions, "plan", "-input=false", "-lock=false", "-refresh=false")
+	if events == nil {
+		return nil, planErr
+	}
+
+	result := &VariableValidationResult{Variable: variable, Value: value, ErrorMessages: []string{}, Diagnostics: []Diagnostic{}}
+	for _, diag := range variableValidationDiagnostics(events.Errors(), variable) {
+		result.Rejected = true
+		result.Diagnostics = append(result.Diagnostics, diag)
+		result.ErrorMessages = append(result.ErrorMessages, validationErrorMessage(diag))
+	}
+	if planErr != nil && !result.Rejected {
+		return nil, planErr
+	}
+	return result, nil
+}
+
+// AssertVariableRejected checks that a validation rule of the given variable rejects the given value with an
+// error_message matching the given regular expression, failing the test if it does not. See ValidateVariable.
+func AssertVariableRejected(t testing.TestingT, options *Options, variable string, value interface{}, errorRegex string) {
+	assert.NoError(t, checkVariableValidation(t, options, variable, value, errorRegex))
+}
+
+// AssertVariableAccepted checks that all the validation rules of the given variable accept the given value, failing
+// the test if they do not. See ValidateVariable.
+func AssertVariableAccepted(t testing.TestingT, options *Options, variable string, value interface{}) {
+	assert.NoError(t, checkVariableValidation(t, options, variable, value, ""))
+}
+
+// TestVariableValidations runs each of the given test cases as a parallel subtest of t, checking that the value of the
+// test case is rejected with the expected error (see AssertVariableRejected), or accepted if no error is expected. Each
+// test case runs on its own copy of options.TerraformDir. This returns once all test cases have finished.
+func TestVariableValidations(t *gotesting.T, options *Options, testCases []VariableValidationTestCase) {
+	t.Run("VariableValidations", func(t *gotesting.T) {
+		for _, testCase := range testCases {
+			testCase := testCase
+			name := testCase.Name
+			if name == "" {
+				name = fmt.Sprintf("%s=%v", testCase.Variable, testCase.Value)
+			}
+			t.Run(name, func(t *gotesting.T) {
+				t.Parallel()
+				assert.NoError(t, checkVariableValidation(t, options, testCase.Variable, testCase.Value, testCase.ExpectedError))
+			})
+		}
+	})
+}
+
+// checkVariableValidation checks that the given value of the given variable is rejected with an error_message matching
+// the given regular expression, or that it is accepted if errorRegex is empty.
+func checkVariableValidation(t testing.TestingT, options *Options, variable string, value interface{}, errorRegex string) error {
+	result, err := ValidateVariableE(t, options, variable, value)
+	if err != nil {
+		return err
+	}
+
+	if errorRegex == "" {
+		if result.Rejected {
+			return fmt.Errorf("expected value %v of variable %q to be accepted, but it was rejected with: %q", value, variable, result.ErrorMessages)
+		}
+		return nil
+	}
+
+	re, err := regexp.Compile(errorRegex)
+	if err != nil {
+		return err
+	}
+	if !result.Rejected {
+		return fmt.Errorf("expected value %v of variable %q to be rejected with an error matching %q, but it was accepted", value, variable, errorRegex)
+	}
+	for _, message := range result.ErrorMessages {
+		if re.MatchString(message) {
+			return nil
+		}
+	}
+	return fmt.Errorf("expected value %v of variable %q to be rejected with an error matching %q, but it was rejected with: %q", value, variable, errorRegex, result.ErrorMessages)
+}
+
+// variableValidationDiagnostics returns the diagnostics of failed validation rules of the given variable. Recent
+// versions of terraform attribute these to the source of the value, which is "<value for var.NAME>" for values set with
+// -var. Older versions attribute them to the validation rule instead, in which case all the diagnostics of failed
+// validation rules are returned.
+func variableValidationDiagnostics(diags []Diagnostic, variable string) []Diagnostic {
+	valueSource := fmt.Sprintf("<value for var.%s>", variable)
+	invalid := []Diagnostic{}
+	matching := []Diagnostic{}
+	for _, diag := range diags {
+		if diag.Summary != invalidVariableValueSummary {
+			continue
+		}
+		invalid = append(invalid, diag)
+		if diag.Range != nil && diag.Range.Filename == valueSource {
+			matching = append(matching, diag)
+		}
+	}
+	if len(matching) == 0 {
+		return invalid
+	}
+	return matching
+}
+
+// validationErrorMessage returns the error_message of the validation rule that produced the given diagnostic, which
+// terraform reports as the detail of the diagnostic, followed by the location of the rule.
+func validationErrorMessage(diag Diagnostic) string {
+	message, _, _ := strings.Cut(diag.Detail, "\n\nThis was checked by the validation rule at")
+	return strings.TrimSpace(message)
+}
@@ -0,0 +1,64 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const variableValidationUIOutput = `{"@level":"info","@message":"Terraform 1.5.7","type":"version","terraform":"1.5.7","ui":"1.1"}
+{"@level":"error","@message":"Error: Invalid value for variable","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid value for variable","detail":"The instance count must be at most 10.\n\nThis was checked by the validation rule at main.tf:20,3-13.","range":{"filename":"<value for var.instance_count>","start":{"line":1,"column":1,"byte":0},"end":{"line":1,"column":1,"byte":0}}}}
+{"@level":"error","@message":"Error: Invalid value for variable","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid value for variable","detail":"The environment must be one of dev, stage or prod.\n\nThis was checked by the validation rule at main.tf:5,3-13.","range":{"filename":"<value for var.environment>","start":{"line":1,"column":1,"byte":0},"end":{"line":1,"column":1,"byte":0}}}}
+{"@level":"error","@message":"Error: Unsupported argument","type":"diagnostic","diagnostic":{"severity":"error","summary":"Unsupported argument","detail":"An argument named \"foo\" is not expected here."}}
+`
+
+func TestVariableValidationDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	events, err := ParseUIEvents(variableValidationUIOutput)
+	require.NoError(t, err)
+
+	diags := variableValidationDiagnostics(events.Errors(), "instance_count")
+	require.Len(t, diags, 1)
+	assert.Equal(t, "The instance count must be at most 10.", validationErrorMessage(diags[0]))
+
+	diags = variableValidationDiagnostics(events.Errors(), "environment")
+	require.Len(t, diags, 1)
+	assert.Equal(t, "The environment must be one of dev, stage or prod.", validationErrorMessage(diags[0]))
+}
+
+func TestVariableValidationDiagnosticsWithoutValueSource(t *testing.T) {
+	t.Parallel()
+
+	diags := []Diagnostic{
+		{Severity: "error", Summary: "Invalid value for variable", Detail: "The environment must be one of dev, stage or prod.", Range: &DiagnosticRange{Filename: "main.tf"}},
+		{Severity: "error", Summary: "Unsupported argument"},
+	}
+
+	matching := variableValidationDiagnostics(diags, "environment")
+	require.Len(t, matching, 1)
+	assert.Equal(t, "The environment must be one of dev, stage or prod.", validationErrorMessage(matching[0]))
+}
+
+func TestVariableValidationsWithFixture(t *testing.T) {
+	t.Parallel()
+
+	testFolder, err := files.CopyTerraformFolderToTemp("../../test/fixtures/terraform-variable-validation", t.Name())
+	require.NoError(t, err)
+
+	options := &Options{
+		TerraformDir: testFolder,
+	}
+
+	AssertVariableRejected(t, options, "environment", "qa", "must be one of dev, stage or prod")
+	AssertVariableAccepted(t, options, "environment", "prod")
+
+	TestVariableValidations(t, options, []VariableValidationTestCase{
+		{Variable: "instance_count", Value: 0, ExpectedError: "must be positive"},
+		{Variable: "instance_count", Value: 11, ExpectedError: "at most 10"},
+		{Variable: "instance_count", Value: 5},
+		{Name: "InvalidEnvironment", Variable: "environment", Value: "test", ExpectedError: "dev, stage or prod"},
+	})
+}
@@ -2,12 +2,13 @@ package test_structure
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 
 	"github.com/nholuongut/terratest/modules/aws"
-	"github.com/nholuongut/terratest/modules/files"
 	"github.com/nholuongut/terratest/modules/k8s"
 	"github.com/nholuongut/terratest/modules/logger"
 	"github.com/nholuongut/terratest/modules/packer"
@@ -63,9 +64,10 @@ func formatPackerOptionsPath(testFolder string) string {
 }
 
 // SaveEc2KeyPair serializes and saves an Ec2KeyPair into the given folder. This allows you to create an Ec2KeyPair during setup
-// and to reuse that Ec2KeyPair later during validation and teardown.
+// and to reuse that Ec2KeyPair later during validation and teardown. If the TERRATEST_TEST_DATA_ENCRYPTION_KEY environment
+// variable is set, the Ec2KeyPair is encrypted with it.
 func SaveEc2KeyPair(t testing.TestingT, testFolder string, keyPair *aws.Ec2Keypair) {
-	SaveTestData(t, formatEc2KeyPairPath(testFolder), true, keyPair)
+	saveKeyPair(t, formatEc2KeyPairPath(testFolder), keyPair)
 }
 
 // LoadEc2KeyPair loads and unserializes an Ec2KeyPair from the given folder. This allows you to reuse an Ec2KeyPair that was
@@ -82,9 +84,10 @@ func formatEc2KeyPairPath(testFolder string) string {
 }
 
 // SaveSshKeyPair serializes and saves an SshKeyPair into the given folder. This allows you to create an SshKeyPair during setup
-// and to reuse that SshKeyPair later during validation and teardown.
+// and to reuse that SshKeyPair later during validation and teardown. If the TERRATEST_TEST_DATA_ENCRYPTION_KEY environment
+// variable is set, the SshKeyPair is encrypted with it.
 func SaveSshKeyPair(t testing.TestingT, testFolder string, keyPair *ssh.KeyPair) {
-	SaveTestData(t, formatSshKeyPairPath(testFolder), true, keyPair)
+	saveKeyPair(t, formatSshKeyPairPath(testFolder), keyPair)
 }
 
 // LoadSshKeyPair loads and unserializes an SshKeyPair from the given folder. This allows you to reuse an SshKeyPair that was
@@ -100,6 +103,16 @@ func formatSshKeyPairPath(testFolder string) string {
 	return FormatTestDataPath(testFolder, "SshKeyPair.json")
 }
 
+// saveKeyPair saves the given key pair to the given path, encrypted with the passphrase in the
+// TERRATEST_TEST_DATA_ENCRYPTION_KEY environment variable if it is set.
+func saveKeyPair(t testing.TestingT, path string, keyPair interface{}) {
+	if passphrase := os.Getenv(TestDataEncryptionKeyEnvVar); passphrase != "" {
+		SaveEncryptedTestData(t, path, true, keyPair, passphrase)
+	} else {
+		SaveTestData(t, path, true, keyPair)
+	}
+}
+
 // SaveKubectlOptions serializes and saves KubectlOptions into the given folder. This allows you to create a KubectlOptions during setup
 // and reuse that KubectlOptions later during validation and teardown.
 func SaveKubectlOptions(t testing.TestingT, testFolder string, kubectlOptions *k8s.KubectlOptions) {
@@ -188,18 +201,37 @@ func FormatTestDataPath(testFolder string, filename string) string {
 	return filepath.Join(testFolder, ".test-data", filename)
 }
 
-// SaveTestData serializes and saves a value used at test time to the given path. This allows you to create some sort of test data
+// SaveTestData serializes and saves a value used at test time to the given path in the test data store (see
+// GetTestDataStore), which is the local file system by default. This allows you to create some sort of test data
 // (e.g., TerraformOptions) during setup and to reuse this data later during validation and teardown. If `overwrite` is `true`,
 // any contents that exist in the file found at `path` will be overwritten. This has the potential for causing duplicated resources
 // and should be used with caution. If `overwrite` is `false`, the save will be skipped and a warning will be logged.
 func SaveTestData(t testing.TestingT, path string, overwrite bool, value interface{}) {
+	saveTestData(t, path, overwrite, value, "")
+}
+
+// SaveEncryptedTestData serializes and saves a value used at test time to the given path, as SaveTestData does, but
+// encrypts it with the given passphrase first. The value can be loaded with LoadEncryptedTestData, or with LoadTestData
+// if the TERRATEST_TEST_DATA_ENCRYPTION_KEY environment variable is set to the passphrase.
+func SaveEncryptedTestData(t testing.TestingT, path string, overwrite bool, value interface{}, passphrase string) {
+	saveTestData(t, path, overwrite, value, passphrase)
+}
+
+// saveTestData saves the given value to the given path, encrypted with the given passphrase unless it is empty. The
+// value is not logged if it is encrypted.
+func saveTestData(t testing.TestingT, path string, overwrite bool, value interface{}, passphrase string) {
 	logger.Default.Logf(t, "Storing test data in %s so it can be reused later", path)
 
+	loggedValue := value
+	if passphrase != "" {
+		loggedValue = "<encrypted>"
+	}
+
 	if IsTestDataPresent(t, path) {
 		if overwrite {
-			logger.Default.Logf(t, "[WARNING] The named test data at path %s is non-empty. Save operation will overwrite existing value with \"%v\".\n.", path, value)
+			logger.Default.Logf(t, "[WARNING] The named test data at path %s is non-empty. Save operation will overwrite existing value with \"%v\".\n.", path, loggedValue)
 		} else {
-			logger.Default.Logf(t, "[WARNING] The named test data at path %s is non-empty. Skipping save operation to prevent overwriting existing value with \"%v\".\n.", path, value)
+			logger.Default.Logf(t, "[WARNING] The named test data at path %s is non-empty. Skipping save operation to prevent overwriting existing value with \"%v\".\n.", path, loggedValue)
 			return
 		}
 	}
@@ -209,46 +241,67 @@ func SaveTestData(t testing.TestingT, path string, overwrite bool, value interfa
 		t.Fatalf("Failed to convert value %s to JSON: %v", path, err)
 	}
 
-	logger.Default.Logf(t, "Marshalled JSON: %s", string(bytes))
-
-	parentDir := filepath.Dir(path)
-	if err := os.MkdirAll(parentDir, 0777); err != nil {
-		t.Fatalf("Failed to create folder %s: %v", parentDir, err)
+	if passphrase != "" {
+		bytes, err = encryptTestDataE(bytes, passphrase)
+		if err != nil {
+			t.Fatalf("Failed to encrypt value %s: %v", path, err)
+		}
 	}
 
-	if err := os.WriteFile(path, bytes, 0644); err != nil {
+	logger.Default.Logf(t, "Marshalled JSON: %s", string(bytes))
+
+	if err := GetTestDataStore(t).Write(path, bytes); err != nil {
 		t.Fatalf("Failed to save value %s: %v", path, err)
 	}
 }
 
-// LoadTestData loads and unserializes a value stored at the given path. The value should be a pointer to a struct into which the
-// value will be deserialized. This allows you to reuse some sort of test data (e.g., TerraformOptions) from earlier
-// setup steps in later validation and teardown steps.
+// LoadTestData loads and unserializes a value stored at the given path in the test data store (see GetTestDataStore).
+// The value should be a pointer to a struct into which the value will be deserialized. This allows you to reuse some
+// sort of test data (e.g., TerraformOptions) from earlier setup steps in later validation and teardown steps. If the
+// value was encrypted (see SaveEncryptedTestData), it is decrypted with the passphrase in the
+// TERRATEST_TEST_DATA_ENCRYPTION_KEY environment variable.
 func LoadTestData(t testing.TestingT, path string, value interface{}) {
+	loadTestData(t, path, value, os.Getenv(TestDataEncryptionKeyEnvVar))
+}
+
+// LoadEncryptedTestData loads, decrypts with the given passphrase and unserializes a value that was stored at the given
+// path with SaveEncryptedTestData.
+func LoadEncryptedTestData(t testing.TestingT, path string, value interface{}, passphrase string) {
+	loadTestData(t, path, value, passphrase)
+}
+
+// loadTestData loads the value stored at the given path into the given value, decrypting it with the given passphrase if
+// it is encrypted.
+func loadTestData(t testing.TestingT, path string, value interface{}, passphrase string) {
 	logger.Default.Logf(t, "Loading test data from %s", path)
 
-	bytes, err := os.ReadFile(path)
+	bytes, err := GetTestDataStore(t).Read(path)
 	if err != nil {
 		t.Fatalf("Failed to load value from %s: %v", path, err)
 	}
 
+	if _, isEncrypted := parseEncryptedTestData(bytes); isEncrypted {
+		if passphrase == "" {
+			t.Fatalf("The value at %s is encrypted, but no passphrase was given: set the %s environment variable", path, TestDataEncryptionKeyEnvVar)
+		}
+		bytes, err = decryptTestDataE(bytes, passphrase)
+		if err != nil {
+			t.Fatalf("Failed to decrypt value %s: %v", path, err)
+		}
+	}
+
 	if err := json.Unmarshal(bytes, value); err != nil {
 		t.Fatalf("Failed to parse JSON for value %s: %v", path, err)
 	}
 }
 
-// IsTestDataPresent returns true if a file exists at $path and the test data there is non-empty.
+// IsTestDataPresent returns true if test data exists at $path in the test data store (see GetTestDataStore) and the
+// test data there is non-empty.
 func IsTestDataPresent(t testing.TestingT, path string) bool {
-	exists, err := files.FileExistsE(path)
-	if err != nil {
-		t.Fatalf("Failed to load test data from %s due to unexpected error: %v", path, err)
-	}
-	if !exists {
+	bytes, err := GetTestDataStore(t).Read(path)
+	if errors.Is(err, fs.ErrNotExist) {
 		return false
 	}
-
-	bytes, err := os.ReadFile(path)
-
 	if err != nil {
 		t.Fatalf("Failed to load test data from %s due to unexpected error: %v", path, err)
 	}
@@ -305,11 +358,12 @@ func isEmptyJSON(t testing.TestingT, bytes []byte) bool {
 	return false
 }
 
-// CleanupTestData cleans up the test data at the given path.
+// CleanupTestData cleans up the test data at the given path in the test data store (see GetTestDataStore).
 func CleanupTestData(t testing.TestingT, path string) {
-	if files.FileExists(path) {
+	store := GetTestDataStore(t)
+	if _, err := store.Read(path); err == nil {
 		logger.Default.Logf(t, "Cleaning up test data from %s", path)
-		if err := os.Remove(path); err != nil {
+		if err := store.Delete(path); err != nil {
 			t.Fatalf("Failed to clean up file at %s: %v", path, err)
 		}
 	} else {
@@ -317,27 +371,27 @@ func CleanupTestData(t testing.TestingT, path string) {
 	}
 }
 
-// CleanupTestDataFolder cleans up the .test-data folder inside the given folder.
+// CleanupTestDataFolder cleans up the .test-data folder inside the given folder in the test data store (see
+// GetTestDataStore).
 // If there are any errors, fail the test.
 func CleanupTestDataFolder(t testing.TestingT, path string) {
 	err := CleanupTestDataFolderE(t, path)
 	require.NoError(t, err)
 }
 
-// CleanupTestDataFolderE cleans up the .test-data folder inside the given folder.
+// CleanupTestDataFolderE cleans up the .test-data folder inside the given folder in the test data store (see
+// GetTestDataStore).
 func CleanupTestDataFolderE(t testing.TestingT, path string) error {
 	path = filepath.Join(path, ".test-data")
-	exists, err := files.FileExistsE(path)
+
+	store, err := GetTestDataStoreE(t)
 	if err != nil {
 		logger.Default.Logf(t, "Failed to clean up test data folder at %s: %v", path, err)
 		return err
 	}
 
-	if !exists {
-		logger.Default.Logf(t, "%s does not exist. Nothing to cleanup.", path)
-		return nil
-	}
-	if err := os.RemoveAll(path); err != nil {
+	logger.Default.Logf(t, "Cleaning up test data folder at %s", path)
+	if err := store.DeleteFolder(path); err != nil {
 		logger.Default.Logf(t, "Failed to clean up test data folder at %s: %v", path, err)
 		return err
 	}
@@ -0,0 +1,92 @@
+package test_structure
+
+import (
+	"sync"
+	go_test "testing"
+
+	"github.com/nholuongut/terratest/modules/terraform"
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// ApplyStack applies the roots of the given terraform stack in dependency order (see terraform.ApplyStackE) in the
+// "setup" test stage, saving the options and outputs of each root that was applied with SaveStack in its TerraformDir,
+// even if another root failed to apply. If the setup stage is skipped (SKIP_setup is set), the options and outputs
+// saved by an earlier run are loaded instead (see LoadStack). The destroy of the stack is registered with t.Cleanup
+// before anything is applied, and runs in the "teardown" test stage (so it is skipped if SKIP_teardown is set), so that
+// the roots are destroyed in reverse order even if a root fails to apply.
+//
+// Note that if any of the SKIP_<stage> environment variables is set, the root folders should not be copied to temp
+// folders (see CopyTerraformFolderToTemp), so that the saved options can be found by the next run.
+func ApplyStack(t *go_test.T, stack *terraform.Stack) {
+	t.Cleanup(func() {
+		RunTestStage(t, "teardown", func() {
+			terraform.DestroyStack(t, stack)
+		})
+	})
+
+	setupRan := false
+	RunTestStage(t, "setup", func() {
+		setupRan = true
+
+		// The roots are applied concurrently, so only record which roots were applied here, and save them on the test
+		// goroutine once the stack was applied, as saving can fail the test.
+		var mutex sync.Mutex
+		applied := &terraform.Stack{}
+		afterApply := stack.AfterApply
+		stack.AfterApply = func(t testing.TestingT, root *terraform.StackRoot) error {
+			mutex.Lock()
+			applied.Roots = append(applied.Roots, root)
+			mutex.Unlock()
+
+			if afterApply != nil {
+				return afterApply(t, root)
+			}
+			return nil
+		}
+		err := terraform.ApplyStackE(t, stack)
+		stack.AfterApply = afterApply
+
+		SaveStack(t, applied)
+		require.NoError(t, err)
+	})
+
+	if !setupRan {
+		LoadStack(t, stack)
+	}
+}
+
+// SaveStack serializes and saves the options and outputs of each root of the given stack into the TerraformDir of the
+// root. This allows you to apply a stack during setup and to reuse its options and outputs later during validation and
+// teardown.
+func SaveStack(t testing.TestingT, stack *terraform.Stack) {
+	for _, root := range stack.Roots {
+		SaveTerraformOptions(t, root.Options.TerraformDir, root.Options)
+		SaveTestData(t, formatStackOutputsPath(root.Options.TerraformDir), true, root.Outputs)
+	}
+}
+
+// LoadStack loads the options and outputs of each root of the given stack that were saved into the TerraformDir of the
+// root (see SaveStack), replacing the options and outputs of the root. Roots without saved options are left unchanged.
+// This allows you to reuse the options of a stack (including the outputs of upstream roots that were passed in as vars)
+// and the outputs of its roots that were applied during an earlier setup step in later validation and teardown steps.
+func LoadStack(t testing.TestingT, stack *terraform.Stack) {
+	for _, root := range stack.Roots {
+		if !IsTestDataPresent(t, formatTerraformOptionsPath(root.Options.TerraformDir)) {
+			continue
+		}
+
+		terraformDir := root.Options.TerraformDir
+		root.Options = LoadTerraformOptions(t, terraformDir)
+		if IsTestDataPresent(t, formatStackOutputsPath(terraformDir)) {
+			outputs := map[string]interface{}{}
+			LoadTestData(t, formatStackOutputsPath(terraformDir), &outputs)
+			root.Outputs = outputs
+		}
+	}
+}
+
+// formatStackOutputsPath formats a path to save the outputs of a stack root in the given folder.
+func formatStackOutputsPath(testFolder string) string {
+	return FormatTestDataPath(testFolder, "StackOutputs.json")
+}
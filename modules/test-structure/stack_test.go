@@ -0,0 +1,47 @@
+package test_structure
+
+import (
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveAndLoadStack(t *testing.T) {
+	t.Parallel()
+
+	networkDir := t.TempDir()
+	appDir := t.TempDir()
+
+	saved := &terraform.Stack{
+		Roots: []*terraform.StackRoot{
+			{
+				Name:    "network",
+				Options: &terraform.Options{TerraformDir: networkDir},
+				Outputs: map[string]interface{}{"vpc_id": "vpc-123", "subnet_ids": []interface{}{"subnet-1", "subnet-2"}},
+			},
+			{
+				Name:    "app",
+				Options: &terraform.Options{TerraformDir: appDir, Vars: map[string]interface{}{"vpc_id": "vpc-123"}},
+				Inputs:  map[string]string{"vpc_id": "network.vpc_id"},
+				Outputs: map[string]interface{}{"url": "http://localhost:8080"},
+			},
+		},
+	}
+	SaveStack(t, saved)
+
+	loaded := &terraform.Stack{
+		Roots: []*terraform.StackRoot{
+			{Name: "network", Options: &terraform.Options{TerraformDir: networkDir}},
+			{Name: "app", Options: &terraform.Options{TerraformDir: appDir}, Inputs: map[string]string{"vpc_id": "network.vpc_id"}},
+			{Name: "dns", Options: &terraform.Options{TerraformDir: t.TempDir()}},
+		},
+	}
+	LoadStack(t, loaded)
+
+	for i, root := range saved.Roots {
+		assert.Equal(t, root.Options, loaded.Roots[i].Options, root.Name)
+		assert.Equal(t, root.Outputs, loaded.Roots[i].Outputs, root.Name)
+	}
+	assert.Nil(t, loaded.Roots[2].Outputs, "Roots without saved options should be left unchanged")
+}
@@ -0,0 +1,476 @@
+package test_structure
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	go_test "testing"
+
+	"github.com/nholuongut/terratest/modules/logger"
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// The names of the stages of a typical Suite.
+const (
+	StageSetup    = "setup"
+	StageDeploy   = "deploy"
+	StageValidate = "validate"
+	StageTeardown = "teardown"
+)
+
+// StagesEnvVar is the environment variable that selects the stages of a Suite to run, as a comma separated list of stage
+// names (e.g., TERRATEST_STAGES=deploy,validate). If it is not set, all the stages run.
+const StagesEnvVar = "TERRATEST_STAGES"
+
+// SkipStagesEnvVar is the environment variable that explicitly skips stages of a Suite, as a comma separated list of
+// stage names (e.g., TERRATEST_SKIP_STAGES=teardown). This is the only way to skip teardown stages.
+const SkipStagesEnvVar = "TERRATEST_SKIP_STAGES"
+
+// The command line flags that select and skip the stages of a Suite, which take precedence over StagesEnvVar and
+// SkipStagesEnvVar (e.g., go test -run TestFoo -args -terratest.stages=validate).
+var (
+	stagesFlag     = flag.String("terratest.stages", "", "Comma separated list of the test_structure.Suite stages to run (overrides "+StagesEnvVar+")")
+	skipStagesFlag = flag.String("terratest.skip-stages", "", "Comma separated list of the test_structure.Suite stages to skip (overrides "+SkipStagesEnvVar+")")
+)
+
+// Suite is a test broken up into stages (e.g., setup, deploy, validate and teardown) that run in dependency order, each
+// as a subtest. The stages can save typed state with SaveStageState, which is persisted in the .test-data folder of
+// TestFolder, so that later stages can load it with LoadStageState, even when they run in a later `go test` run. This
+// allows you to select the stages to run with TERRATEST_STAGES (or -terratest.stages), so that you can, for example,
+// deploy once and then re-run just the validation over and over again while you work out the kinks:
+//
+//	suite := &test_structure.Suite{TestFolder: exampleFolder}
+//	suite.Stages = []test_structure.Stage{
+//		{Name: test_structure.StageDeploy, Run: func(t *testing.T) {
+//			terraformOptions := &terraform.Options{TerraformDir: exampleFolder}
+//			test_structure.SaveStageState(t, suite, test_structure.StageDeploy, terraformOptions)
+//			terraform.InitAndApply(t, terraformOptions)
+//		}},
+//		{Name: test_structure.StageValidate, DependsOn: []string{test_structure.StageDeploy}, Run: func(t *testing.T) {
+//			terraformOptions := test_structure.LoadStageState[*terraform.Options](t, suite, test_structure.StageDeploy)
+//			...
+//		}},
+//		{Name: test_structure.StageTeardown, Teardown: true, DependsOn: []string{test_structure.StageDeploy}, Run: func(t *testing.T) {
+//			terraformOptions := test_structure.LoadStageState[*terraform.Options](t, suite, test_structure.StageDeploy)
+//			terraform.Destroy(t, terraformOptions)
+//		}},
+//	}
+//	suite.Run(t)
+//
+// A stage only runs if the stages it depends on completed, either earlier in the same run or in an earlier run. Teardown
+// stages run at the end, even if other stages failed or were not selected, unless they are explicitly skipped with
+// TERRATEST_SKIP_STAGES (or -terratest.skip-stages, or the SKIP_<stage> environment variable used by RunTestStage). At
+// the end, the suite logs which stages to select to re-run just the validation.
+//
+// Note that if stages are selected or skipped, the TestFolder should not be copied to a temp folder (see
+// CopyTerraformFolderToTemp), so that the state can be found by the next run.
+type Suite struct {
+	// The folder in which the state of the stages is persisted, in its .test-data folder (e.g., the folder of the
+	// Terraform module under test).
+	TestFolder string
+
+	// The stages of the suite. Stages that don't depend on each other run in the order they are declared in.
+	Stages []Stage
+}
+
+// Stage is a single stage of a Suite.
+type Stage struct {
+	// The unique name of the stage within the suite.
+	Name string
+
+	// The names of the stages that must complete before this stage runs. For teardown stages, these are the stages whose
+	// resources the stage tears down.
+	DependsOn []string
+
+	// Whether this is a teardown stage. Teardown stages run after all other stages, in the reverse order of the stages
+	// they depend on, unless they are explicitly skipped. Once a teardown stage completed, the state of the stages it
+	// depends on is cleaned up, as their resources are gone. No stage can depend on a teardown stage.
+	Teardown bool
+
+	// The function that runs the stage, as a subtest of the test that runs the suite. The stage fails if the subtest
+	// fails. Note that the subtest must not call t.Parallel, as that would break the order of the stages.
+	Run func(t *go_test.T)
+}
+
+// stageStatus is the status of a stage, as persisted in the .test-data folder.
+type stageStatus string
+
+const (
+	// The stage started, but did not complete (e.g., because it failed).
+	stageStarted stageStatus = "started"
+	// The stage completed successfully.
+	stageCompleted stageStatus = "completed"
+)
+
+// Run runs the stages of the suite that are selected with TERRATEST_STAGES (all of them by default) in dependency
+// order, each as a subtest of the given test, followed by the teardown stages that are not explicitly skipped. See
+// Suite for details.
+func (suite *Suite) Run(t *go_test.T) {
+	suite.run(t, t.Run)
+}
+
+// run runs the stages of the suite as Run does, using runStage to run each stage as a subtest.
+func (suite *Suite) run(t *go_test.T, runStage func(name string, run func(t *go_test.T)) bool) {
+	stages, err := sortStagesE(suite.Stages)
+	require.NoError(t, err)
+
+	selected, err := parseStageNamesE(stages, selectedStages())
+	require.NoError(t, err)
+	skipped, err := parseStageNamesE(stages, skippedStages())
+	require.NoError(t, err)
+	for _, stage := range stages {
+		if os.Getenv(SKIP_STAGE_ENV_VAR_PREFIX+stage.Name) != "" {
+			skipped[stage.Name] = true
+		}
+	}
+
+	statuses := suite.loadStageStatuses(t)
+
+	// The stages that failed in this run, or that were not run because a stage they depend on failed.
+	failed := map[string]bool{}
+
+	for _, stage := range stages {
+		if stage.Teardown {
+			continue
+		}
+		if skipped[stage.Name] {
+			logger.Default.Logf(t, "Stage '%s' is explicitly skipped, so skipping it.", stage.Name)
+			continue
+		}
+		if len(selected) > 0 && !selected[stage.Name] {
+			logger.Default.Logf(t, "Stage '%s' is not selected, so skipping it.", stage.Name)
+			continue
+		}
+
+		if dependency, hasFailed := firstStage(stage.DependsOn, func(name string) bool { return failed[name] }); hasFailed {
+			logger.Default.Logf(t, "Stage '%s' depends on stage '%s', which failed, so skipping it.", stage.Name, dependency)
+			failed[stage.Name] = true
+			continue
+		}
+		if dependency, isIncomplete := firstStage(stage.DependsOn, func(name string) bool { return statuses[name] != stageCompleted }); isIncomplete {
+			t.Errorf(
+				"Stage '%s' depends on stage '%s', which has not completed in this or an earlier run. %s",
+				stage.Name,
+				dependency,
+				formatResumeHint(stages, statuses, []string{stage.Name}),
+			)
+			failed[stage.Name] = true
+			continue
+		}
+
+		statuses[stage.Name] = stageStarted
+		suite.saveStageStatuses(t, statuses)
+		if runStage(stage.Name, stage.Run) {
+			statuses[stage.Name] = stageCompleted
+		} else {
+			failed[stage.Name] = true
+		}
+		suite.saveStageStatuses(t, statuses)
+	}
+
+	for _, stage := range teardownOrder(stages) {
+		if skipped[stage.Name] {
+			logger.Default.Logf(t, "Teardown stage '%s' is explicitly skipped, so leaving the resources of %v in place.", stage.Name, stage.DependsOn)
+			continue
+		}
+		if _, hasStarted := firstStage(stage.DependsOn, func(name string) bool { return statuses[name] != "" }); len(stage.DependsOn) > 0 && !hasStarted {
+			logger.Default.Logf(t, "None of the stages %v that teardown stage '%s' depends on have run, so there is nothing to tear down.", stage.DependsOn, stage.Name)
+			continue
+		}
+
+		if !runStage(stage.Name, stage.Run) {
+			continue
+		}
+		for _, dependency := range stage.DependsOn {
+			delete(statuses, dependency)
+			CleanupTestData(t, suite.formatStageStatePath(dependency))
+		}
+		suite.saveStageStatuses(t, statuses)
+	}
+
+	if targets := validationStages(stages); len(targets) > 0 {
+		logger.Default.Logf(t, "%s", formatResumeHint(stages, statuses, targets))
+	}
+}
+
+// SaveStageState serializes and saves the state of the given stage of the suite (e.g., the TerraformOptions of the
+// deploy stage) into the .test-data folder of the TestFolder of the suite. This allows later stages, including stages
+// that run in a later run, to load the state with LoadStageState.
+func SaveStageState[T any](t testing.TestingT, suite *Suite, stageName string, state T) {
+	SaveTestData(t, suite.formatStageStatePath(stageName), true, state)
+}
+
+// LoadStageState loads and unserializes the state of the given stage of the suite that was saved with SaveStageState,
+// in this or an earlier run.
+func LoadStageState[T any](t testing.TestingT, suite *Suite, stageName string) T {
+	var state T
+	LoadTestData(t, suite.formatStageStatePath(stageName), &state)
+	return state
+}
+
+// IsStageStatePresent returns true if state was saved for the given stage of the suite with SaveStageState.
+func IsStageStatePresent(t testing.TestingT, suite *Suite, stageName string) bool {
+	return IsTestDataPresent(t, suite.formatStageStatePath(stageName))
+}
+
+// formatStageStatePath formats a path to save the state of the given stage of the suite.
+func (suite *Suite) formatStageStatePath(stageName string) string {
+	return FormatTestDataPath(suite.TestFolder, filepath.Join("stages", stageName+".json"))
+}
+
+// formatStageStatusesPath formats a path to save the statuses of the stages of the suite.
+func (suite *Suite) formatStageStatusesPath() string {
+	return FormatTestDataPath(suite.TestFolder, "Stages.json")
+}
+
+// loadStageStatuses loads the statuses of the stages of the suite that ran in this or earlier runs. Stages that never
+// ran, or whose resources were torn down, have no status.
+func (suite *Suite) loadStageStatuses(t testing.TestingT) map[string]stageStatus {
+	statuses := map[string]stageStatus{}
+
+	bytes, err := GetTestDataStore(t).Read(suite.formatStageStatusesPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return statuses
+	}
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(bytes, &statuses))
+	return statuses
+}
+
+// saveStageStatuses saves the statuses of the stages of the suite. Unlike SaveTestData, this doesn't log anything, as
+// the statuses are saved before and after each stage.
+func (suite *Suite) saveStageStatuses(t testing.TestingT, statuses map[string]stageStatus) {
+	bytes, err := json.Marshal(statuses)
+	require.NoError(t, err)
+	require.NoError(t, GetTestDataStore(t).Write(suite.formatStageStatusesPath(), bytes))
+}
+
+// sortStagesE returns the given stages in dependency order. Stages that don't depend on each other stay in the order
+// they are declared in. Returns an error if the stages are not a valid dependency graph.
+func sortStagesE(stages []Stage) ([]Stage, error) {
+	byName := map[string]Stage{}
+	for _, stage := range stages {
+		if stage.Name == "" {
+			return nil, fmt.Errorf("suite has a stage without a name")
+		}
+		if strings.Contains(stage.Name, ",") {
+			return nil, fmt.Errorf("the name of stage %q must not contain a comma", stage.Name)
+		}
+		if stage.Run == nil {
+			return nil, fmt.Errorf("stage %q has no Run function", stage.Name)
+		}
+		if _, isDuplicate := byName[stage.Name]; isDuplicate {
+			return nil, fmt.Errorf("suite has multiple stages named %q", stage.Name)
+		}
+		byName[stage.Name] = stage
+	}
+	for _, stage := range stages {
+		for _, dependency := range stage.DependsOn {
+			upstream, exists := byName[dependency]
+			if !exists {
+				return nil, fmt.Errorf("stage %q depends on unknown stage %q", stage.Name, dependency)
+			}
+			if upstream.Teardown {
+				return nil, fmt.Errorf("stage %q depends on teardown stage %q", stage.Name, dependency)
+			}
+		}
+	}
+
+	sorted := []Stage{}
+	placed := map[string]bool{}
+	for len(sorted) < len(stages) {
+		progressed := false
+		for _, stage := range stages {
+			if placed[stage.Name] {
+				continue
+			}
+			if _, isWaiting := firstStage(stage.DependsOn, func(name string) bool { return !placed[name] }); isWaiting {
+				continue
+			}
+			sorted = append(sorted, stage)
+			placed[stage.Name] = true
+			progressed = true
+			break
+		}
+		if !progressed {
+			remaining := []string{}
+			for _, stage := range stages {
+				if !placed[stage.Name] {
+					remaining = append(remaining, stage.Name)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle between stages %v", remaining)
+		}
+	}
+	return sorted, nil
+}
+
+// teardownOrder returns the teardown stages of the given stages, which must be in dependency order, in the order in
+// which they run: in the reverse order of the last stage they depend on, so that resources are torn down in the reverse
+// order they were created in. Teardown stages without dependencies run last.
+func teardownOrder(stages []Stage) []Stage {
+	position := map[string]int{}
+	for i, stage := range stages {
+		position[stage.Name] = i
+	}
+	lastDependency := func(stage Stage) int {
+		last := -1
+		for _, dependency := range stage.DependsOn {
+			last = max(last, position[dependency])
+		}
+		return last
+	}
+
+	teardowns := []Stage{}
+	for i := len(stages) - 1; i >= 0; i-- {
+		if stages[i].Teardown {
+			teardowns = append(teardowns, stages[i])
+		}
+	}
+	sort.SliceStable(teardowns, func(i, j int) bool {
+		return lastDependency(teardowns[i]) > lastDependency(teardowns[j])
+	})
+	return teardowns
+}
+
+// validationStages returns the names of the stages that validate the resources of the suite: the stages, other than
+// teardown stages, that no other such stage depends on (e.g., the validate stage).
+func validationStages(stages []Stage) []string {
+	dependedOn := map[string]bool{}
+	for _, stage := range stages {
+		if !stage.Teardown {
+			for _, dependency := range stage.DependsOn {
+				dependedOn[dependency] = true
+			}
+		}
+	}
+
+	names := []string{}
+	for _, stage := range stages {
+		if !stage.Teardown && !dependedOn[stage.Name] {
+			names = append(names, stage.Name)
+		}
+	}
+	return names
+}
+
+// formatResumeHint formats a hint on how to re-run just the given stages, given the statuses of the stages: which stages
+// to select (the given stages, and the stages they depend on that have not completed), and which teardown stages to skip
+// to keep the resources for the run after.
+func formatResumeHint(stages []Stage, statuses map[string]stageStatus, targets []string) string {
+	byName := map[string]Stage{}
+	for _, stage := range stages {
+		byName[stage.Name] = stage
+	}
+
+	needed := map[string]bool{}
+	var need func(name string)
+	need = func(name string) {
+		if needed[name] {
+			return
+		}
+		needed[name] = true
+		for _, dependency := range byName[name].DependsOn {
+			if statuses[dependency] != stageCompleted {
+				need(dependency)
+			}
+		}
+	}
+	for _, target := range targets {
+		need(target)
+	}
+
+	selected := []string{}
+	teardowns := []string{}
+	for _, stage := range stages {
+		if stage.Teardown {
+			teardowns = append(teardowns, stage.Name)
+		} else if needed[stage.Name] {
+			selected = append(selected, stage.Name)
+		}
+	}
+
+	hint := fmt.Sprintf("To re-run just the %s stage(s), run the test with %s=%s", strings.Join(targets, ","), StagesEnvVar, strings.Join(selected, ","))
+	if len(teardowns) == 0 {
+		return hint + "."
+	}
+	return fmt.Sprintf(
+		"%s %s=%s (leave out %s to also tear down the resources at the end).",
+		hint,
+		SkipStagesEnvVar,
+		strings.Join(teardowns, ","),
+		SkipStagesEnvVar,
+	)
+}
+
+// selectedStages returns the names of the stages selected with -terratest.stages or TERRATEST_STAGES.
+func selectedStages() []string {
+	return splitStageNames(*stagesFlag, os.Getenv(StagesEnvVar))
+}
+
+// skippedStages returns the names of the stages explicitly skipped with -terratest.skip-stages or
+// TERRATEST_SKIP_STAGES.
+func skippedStages() []string {
+	return splitStageNames(*skipStagesFlag, os.Getenv(SkipStagesEnvVar))
+}
+
+// splitStageNames splits the first non-empty of the given comma separated lists of stage names.
+func splitStageNames(lists ...string) []string {
+	for _, list := range lists {
+		names := []string{}
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			return names
+		}
+	}
+	return nil
+}
+
+// parseStageNamesE returns the set of the given stage names, or an error if a name is not the name of one of the given
+// stages.
+func parseStageNamesE(stages []Stage, names []string) (map[string]bool, error) {
+	known := map[string]bool{}
+	for _, stage := range stages {
+		known[stage.Name] = true
+	}
+
+	set := map[string]bool{}
+	for _, name := range names {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown stage %q: the stages of the suite are %v", name, sortedStageNames(known))
+		}
+		set[name] = true
+	}
+	return set, nil
+}
+
+// sortedStageNames returns the given set of stage names, sorted.
+func sortedStageNames(names map[string]bool) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// firstStage returns the first of the given stage names that matches the given predicate, if any.
+func firstStage(names []string, predicate func(name string) bool) (string, bool) {
+	for _, name := range names {
+		if predicate(name) {
+			return name, true
+		}
+	}
+	return "", false
+}
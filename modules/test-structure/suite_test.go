@@ -0,0 +1,210 @@
+package test_structure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deployState struct {
+	Endpoint string
+	Replicas int
+}
+
+// newRecordingSuite returns a suite with setup, deploy, validate and teardown stages in the given folder, which record
+// the names of the stages that ran. The deploy stage saves a deployState that the validate stage checks.
+func newRecordingSuite(testFolder string, ran *[]string) *Suite {
+	suite := &Suite{TestFolder: testFolder}
+	suite.Stages = []Stage{
+		{
+			Name:      StageTeardown,
+			Teardown:  true,
+			DependsOn: []string{StageDeploy},
+			Run: func(t *testing.T) {
+				*ran = append(*ran, StageTeardown)
+			},
+		},
+		{
+			Name:      StageValidate,
+			DependsOn: []string{StageDeploy},
+			Run: func(t *testing.T) {
+				*ran = append(*ran, StageValidate)
+				state := LoadStageState[*deployState](t, suite, StageDeploy)
+				assert.Equal(t, &deployState{Endpoint: "http://localhost:8080", Replicas: 3}, state)
+			},
+		},
+		{
+			Name:      StageDeploy,
+			DependsOn: []string{StageSetup},
+			Run: func(t *testing.T) {
+				*ran = append(*ran, StageDeploy)
+				SaveStageState(t, suite, StageDeploy, &deployState{Endpoint: "http://localhost:8080", Replicas: 3})
+			},
+		},
+		{
+			Name: StageSetup,
+			Run: func(t *testing.T) {
+				*ran = append(*ran, StageSetup)
+			},
+		},
+	}
+	return suite
+}
+
+func TestSuiteRunsAllStagesInDependencyOrder(t *testing.T) {
+	t.Setenv(StagesEnvVar, "")
+	t.Setenv(SkipStagesEnvVar, "")
+
+	ran := []string{}
+	suite := newRecordingSuite(t.TempDir(), &ran)
+	suite.Run(t)
+
+	assert.Equal(t, []string{StageSetup, StageDeploy, StageValidate, StageTeardown}, ran)
+	assert.False(t, IsStageStatePresent(t, suite, StageDeploy), "The state of the deploy stage should be cleaned up by the teardown")
+	assert.Equal(t, map[string]stageStatus{StageSetup: stageCompleted, StageValidate: stageCompleted}, suite.loadStageStatuses(t))
+}
+
+func TestSuiteRunsSelectedStagesAcrossRuns(t *testing.T) {
+	testFolder := t.TempDir()
+
+	// Deploy once, keeping the resources
+	t.Setenv(StagesEnvVar, "setup,deploy")
+	t.Setenv(SkipStagesEnvVar, StageTeardown)
+	ran := []string{}
+	suite := newRecordingSuite(testFolder, &ran)
+	suite.Run(t)
+	assert.Equal(t, []string{StageSetup, StageDeploy}, ran)
+	assert.True(t, IsStageStatePresent(t, suite, StageDeploy))
+
+	// Re-run just the validation, twice
+	t.Setenv(StagesEnvVar, StageValidate)
+	for i := 0; i < 2; i++ {
+		ran = []string{}
+		suite = newRecordingSuite(testFolder, &ran)
+		suite.Run(t)
+		assert.Equal(t, []string{StageValidate}, ran)
+	}
+
+	// Teardown runs even though it is not selected
+	t.Setenv(SkipStagesEnvVar, "")
+	ran = []string{}
+	suite = newRecordingSuite(testFolder, &ran)
+	suite.Run(t)
+	assert.Equal(t, []string{StageValidate, StageTeardown}, ran)
+	assert.False(t, IsStageStatePresent(t, suite, StageDeploy))
+}
+
+func TestSuiteSkipsDependentStagesOfFailedStage(t *testing.T) {
+	t.Setenv(StagesEnvVar, "")
+	t.Setenv(SkipStagesEnvVar, "")
+
+	ran := []string{}
+	suite := newRecordingSuite(t.TempDir(), &ran)
+	suite.run(t, func(name string, run func(t *testing.T)) bool {
+		if name == StageDeploy {
+			ran = append(ran, StageDeploy)
+			return false
+		}
+		return t.Run(name, run)
+	})
+
+	assert.Equal(t, []string{StageSetup, StageDeploy, StageTeardown}, ran)
+	assert.Equal(t, map[string]stageStatus{StageSetup: stageCompleted}, suite.loadStageStatuses(t))
+}
+
+func TestSuiteSkipsTeardownWhenNothingWasDeployed(t *testing.T) {
+	t.Setenv(StagesEnvVar, StageSetup)
+	t.Setenv(SkipStagesEnvVar, "")
+
+	ran := []string{}
+	suite := newRecordingSuite(t.TempDir(), &ran)
+	suite.Run(t)
+
+	assert.Equal(t, []string{StageSetup}, ran)
+}
+
+func TestSortStagesE(t *testing.T) {
+	t.Parallel()
+
+	noop := func(t *testing.T) {}
+
+	stages, err := sortStagesE([]Stage{
+		{Name: "validate", DependsOn: []string{"deploy-app"}, Run: noop},
+		{Name: "deploy-app", DependsOn: []string{"deploy-network"}, Run: noop},
+		{Name: "lint", Run: noop},
+		{Name: "deploy-network", Run: noop},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lint", "deploy-network", "deploy-app", "validate"}, stageNames(stages))
+
+	testCases := []struct {
+		name   string
+		stages []Stage
+	}{
+		{"duplicate", []Stage{{Name: "deploy", Run: noop}, {Name: "deploy", Run: noop}}},
+		{"unknown dependency", []Stage{{Name: "validate", DependsOn: []string{"deploy"}, Run: noop}}},
+		{"cycle", []Stage{{Name: "a", DependsOn: []string{"b"}, Run: noop}, {Name: "b", DependsOn: []string{"a"}, Run: noop}}},
+		{"depends on teardown", []Stage{{Name: "teardown", Teardown: true, Run: noop}, {Name: "validate", DependsOn: []string{"teardown"}, Run: noop}}},
+		{"no run function", []Stage{{Name: "deploy"}}},
+		{"comma in name", []Stage{{Name: "deploy,validate", Run: noop}}},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := sortStagesE(testCase.stages)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTeardownOrder(t *testing.T) {
+	t.Parallel()
+
+	noop := func(t *testing.T) {}
+
+	stages, err := sortStagesE([]Stage{
+		{Name: "cleanup", Teardown: true, Run: noop},
+		{Name: "teardown-network", Teardown: true, DependsOn: []string{"deploy-network"}, Run: noop},
+		{Name: "teardown-app", Teardown: true, DependsOn: []string{"deploy-app"}, Run: noop},
+		{Name: "deploy-network", Run: noop},
+		{Name: "deploy-app", DependsOn: []string{"deploy-network"}, Run: noop},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"teardown-app", "teardown-network", "cleanup"}, stageNames(teardownOrder(stages)))
+}
+
+func TestFormatResumeHint(t *testing.T) {
+	t.Parallel()
+
+	ran := []string{}
+	stages, err := sortStagesE(newRecordingSuite("", &ran).Stages)
+	require.NoError(t, err)
+	targets := validationStages(stages)
+	assert.Equal(t, []string{StageValidate}, targets)
+
+	assert.Equal(
+		t,
+		"To re-run just the validate stage(s), run the test with TERRATEST_STAGES=setup,deploy,validate TERRATEST_SKIP_STAGES=teardown (leave out TERRATEST_SKIP_STAGES to also tear down the resources at the end).",
+		formatResumeHint(stages, map[string]stageStatus{}, targets),
+	)
+	assert.Equal(
+		t,
+		"To re-run just the validate stage(s), run the test with TERRATEST_STAGES=deploy,validate TERRATEST_SKIP_STAGES=teardown (leave out TERRATEST_SKIP_STAGES to also tear down the resources at the end).",
+		formatResumeHint(stages, map[string]stageStatus{StageSetup: stageCompleted, StageDeploy: stageStarted}, targets),
+	)
+	assert.Equal(
+		t,
+		"To re-run just the validate stage(s), run the test with TERRATEST_STAGES=validate TERRATEST_SKIP_STAGES=teardown (leave out TERRATEST_SKIP_STAGES to also tear down the resources at the end).",
+		formatResumeHint(stages, map[string]stageStatus{StageSetup: stageCompleted, StageDeploy: stageCompleted}, targets),
+	)
+}
+
+func stageNames(stages []Stage) []string {
+	names := []string{}
+	for _, stage := range stages {
+		names = append(names, stage.Name)
+	}
+	return names
+}
@@ -0,0 +1,107 @@
+package test_structure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// TestDataEncryptionKeyEnvVar is the environment variable that sets the passphrase with which key pairs saved with
+// SaveSshKeyPair and SaveEc2KeyPair are encrypted, and with which encrypted test data is decrypted by LoadTestData. If it
+// is not set, key pairs are saved unencrypted.
+const TestDataEncryptionKeyEnvVar = "TERRATEST_TEST_DATA_ENCRYPTION_KEY"
+
+// The encryption of encrypted test data: AES-256-GCM, with a key derived from the passphrase with scrypt.
+const testDataEncryption = "scrypt-aes-256-gcm"
+
+// The parameters of scrypt, as recommended by its documentation for interactive logins.
+const (
+	scryptN       = 32768
+	scryptR       = 8
+	scryptP       = 1
+	scryptKeySize = 32
+	scryptSalt    = 16
+)
+
+// encryptedTestData is the format in which encrypted test data is stored.
+type encryptedTestData struct {
+	Encryption string `json:"terratest_encryption"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// encryptTestDataE encrypts the given test data with the given passphrase, in the format of encryptedTestData.
+func encryptTestDataE(data []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("the passphrase to encrypt test data with must not be empty")
+	}
+
+	salt := make([]byte, scryptSalt)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newTestDataCipherE(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encryptedTestData{
+		Encryption: testDataEncryption,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, data, nil),
+	})
+}
+
+// decryptTestDataE decrypts the given test data that was encrypted with encryptTestDataE with the given passphrase.
+func decryptTestDataE(data []byte, passphrase string) ([]byte, error) {
+	encrypted, isEncrypted := parseEncryptedTestData(data)
+	if !isEncrypted {
+		return nil, errors.New("the test data is not encrypted")
+	}
+
+	gcm, err := newTestDataCipherE(passphrase, encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size %d", len(encrypted.Nonce))
+	}
+	decrypted, err := gcm.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt test data (wrong passphrase?): %w", err)
+	}
+	return decrypted, nil
+}
+
+// parseEncryptedTestData parses the given test data if it is encrypted.
+func parseEncryptedTestData(data []byte) (*encryptedTestData, bool) {
+	var encrypted encryptedTestData
+	if err := json.Unmarshal(data, &encrypted); err != nil || encrypted.Encryption != testDataEncryption {
+		return nil, false
+	}
+	return &encrypted, true
+}
+
+// newTestDataCipherE returns the AES-GCM cipher with the key derived from the given passphrase and salt.
+func newTestDataCipherE(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,338 @@
+package test_structure
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/nholuongut/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDataStoreEnvVar is the environment variable that configures the store of the test data saved with SaveTestData
+// (and all the other Save functions of this package) when DefaultTestDataStore is not set, as a URL:
+//
+//   - s3://<bucket>/<prefix> stores the test data in an S3 bucket (or any store that speaks the S3 API, such as MinIO,
+//     with TestDataStoreEndpointEnvVar).
+//   - gs://<bucket>/<prefix> stores the test data in a GCS bucket through its S3 compatible API, using HMAC keys as the
+//     AWS credentials.
+//   - If not set, the test data is stored on the local file system.
+//
+// This allows you to run the stages of a test (e.g., deploy and validate) in separate CI jobs or on separate runners.
+const TestDataStoreEnvVar = "TERRATEST_TEST_DATA_STORE"
+
+// TestDataStoreEndpointEnvVar is the environment variable that sets the endpoint of the S3 API of the store configured
+// with TestDataStoreEnvVar (e.g., http://localhost:9000 for MinIO).
+const TestDataStoreEndpointEnvVar = "TERRATEST_TEST_DATA_STORE_ENDPOINT"
+
+// The endpoint of the S3 compatible API of GCS.
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// TestDataStore stores the test data saved with SaveTestData, by path (e.g., the path returned by FormatTestDataPath).
+type TestDataStore interface {
+	// Read returns the test data at the given path, or an error that wraps fs.ErrNotExist if there is none.
+	Read(path string) ([]byte, error)
+
+	// Write writes the given test data to the given path, replacing any test data at that path.
+	Write(path string, data []byte) error
+
+	// Delete deletes the test data at the given path, if any.
+	Delete(path string) error
+
+	// DeleteFolder deletes all the test data in the given folder, if any.
+	DeleteFolder(folder string) error
+}
+
+// DefaultTestDataStore is the store used by SaveTestData, LoadTestData and the other functions of this package that save
+// and load test data. If nil, the store is configured with TestDataStoreEnvVar, and defaults to the local file system.
+var DefaultTestDataStore TestDataStore
+
+var (
+	// The stores configured with TestDataStoreEnvVar, by URL and endpoint, so that the clients are reused.
+	testDataStoresByURL      = map[string]TestDataStore{}
+	testDataStoresByURLMutex sync.Mutex
+)
+
+// GetTestDataStore returns the store of the test data: DefaultTestDataStore if set, or else the store configured with
+// TestDataStoreEnvVar.
+func GetTestDataStore(t testing.TestingT) TestDataStore {
+	store, err := GetTestDataStoreE(t)
+	require.NoError(t, err)
+	return store
+}
+
+// GetTestDataStoreE returns the store of the test data: DefaultTestDataStore if set, or else the store configured with
+// TestDataStoreEnvVar.
+func GetTestDataStoreE(t testing.TestingT) (TestDataStore, error) {
+	if DefaultTestDataStore != nil {
+		return DefaultTestDataStore, nil
+	}
+
+	storeURL := os.Getenv(TestDataStoreEnvVar)
+	if storeURL == "" {
+		return LocalTestDataStore{}, nil
+	}
+
+	testDataStoresByURLMutex.Lock()
+	defer testDataStoresByURLMutex.Unlock()
+
+	cacheKey := storeURL + " " + os.Getenv(TestDataStoreEndpointEnvVar)
+	if store, exists := testDataStoresByURL[cacheKey]; exists {
+		return store, nil
+	}
+	store, err := NewTestDataStoreFromURLE(t, storeURL)
+	if err != nil {
+		return nil, err
+	}
+	testDataStoresByURL[cacheKey] = store
+	return store, nil
+}
+
+// NewTestDataStoreFromURLE creates the store of test data described by the given URL (see TestDataStoreEnvVar). The
+// endpoint of the S3 API is read from TestDataStoreEndpointEnvVar, and the region from the AWS_REGION or
+// AWS_DEFAULT_REGION environment variables.
+func NewTestDataStoreFromURLE(t testing.TestingT, storeURL string) (TestDataStore, error) {
+	parsedURL, err := url.Parse(storeURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid test data store URL %q: %w", storeURL, err)
+	}
+
+	options := S3TestDataStoreOptions{
+		Bucket:   parsedURL.Host,
+		Prefix:   strings.Trim(parsedURL.Path, "/"),
+		Region:   firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		Endpoint: os.Getenv(TestDataStoreEndpointEnvVar),
+	}
+	switch parsedURL.Scheme {
+	case "file":
+		return LocalTestDataStore{}, nil
+	case "s3":
+	case "gs":
+		options.Endpoint = firstNonEmpty(options.Endpoint, gcsEndpoint)
+	default:
+		return nil, fmt.Errorf("invalid test data store URL %q: expected an s3://, gs:// or file:// URL", storeURL)
+	}
+	if options.Bucket == "" {
+		return nil, fmt.Errorf("invalid test data store URL %q: no bucket", storeURL)
+	}
+	return NewS3TestDataStoreE(t, options)
+}
+
+// LocalTestDataStore stores test data in files on the local file system.
+type LocalTestDataStore struct{}
+
+// Read returns the contents of the file at the given path.
+func (store LocalTestDataStore) Read(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// Write writes the given test data to the file at the given path, creating its parent folders if needed.
+func (store LocalTestDataStore) Write(path string, data []byte) error {
+	parentDir := filepath.Dir(path)
+	if err := os.MkdirAll(parentDir, 0777); err != nil {
+		return fmt.Errorf("failed to create folder %s: %w", parentDir, err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Delete deletes the file at the given path, if it exists.
+func (store LocalTestDataStore) Delete(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DeleteFolder deletes the given folder and everything in it, if it exists.
+func (store LocalTestDataStore) DeleteFolder(folder string) error {
+	return os.RemoveAll(folder)
+}
+
+// S3TestDataStoreOptions are the options for NewS3TestDataStore.
+type S3TestDataStoreOptions struct {
+	// The bucket to store the test data in.
+	Bucket string
+
+	// The prefix of the keys of the test data in the bucket (e.g., the ID of the CI pipeline).
+	Prefix string
+
+	// The region of the bucket.
+	Region string
+
+	// The endpoint of the S3 API, for stores other than S3 (e.g., http://localhost:9000 for MinIO, or
+	// https://storage.googleapis.com for GCS). Requests to custom endpoints use path-style addressing.
+	Endpoint string
+}
+
+// S3TestDataStore stores test data as objects in a bucket, using the S3 API. This works with S3 and any store that speaks
+// the S3 API, such as MinIO or GCS. The key of the object for a path is the prefix of the store, followed by the path
+// without any leading "/", "./" or "../" (e.g., "<prefix>/examples/foo/.test-data/TerraformOptions.json" for
+// "../examples/foo/.test-data/TerraformOptions.json"), so the test folders should have the same path on all the
+// machines that share the store.
+type S3TestDataStore struct {
+	Client s3iface.S3API
+	Bucket string
+	Prefix string
+}
+
+// NewS3TestDataStore creates a store of test data in the bucket with the given options, using the standard AWS
+// credentials (e.g., the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables).
+func NewS3TestDataStore(t testing.TestingT, options S3TestDataStoreOptions) *S3TestDataStore {
+	store, err := NewS3TestDataStoreE(t, options)
+	require.NoError(t, err)
+	return store
+}
+
+// NewS3TestDataStoreE creates a store of test data in the bucket with the given options, using the standard AWS
+// credentials (e.g., the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables).
+func NewS3TestDataStoreE(t testing.TestingT, options S3TestDataStoreOptions) (*S3TestDataStore, error) {
+	config := aws.NewConfig().WithRegion(options.Region)
+	if options.Endpoint != "" {
+		config = config.WithEndpoint(options.Endpoint).WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3TestDataStore{
+		Client: s3.New(sess),
+		Bucket: options.Bucket,
+		Prefix: options.Prefix,
+	}, nil
+}
+
+// Read returns the contents of the object for the given path.
+func (store *S3TestDataStore) Read(path string) ([]byte, error) {
+	key := store.key(path)
+	output, err := store.Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(key),
+	})
+	if isS3NotFoundError(err) {
+		return nil, &fs.PathError{Op: "read", Path: store.url(key), Err: fs.ErrNotExist}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", store.url(key), err)
+	}
+	defer output.Body.Close()
+
+	return io.ReadAll(output.Body)
+}
+
+// Write writes the given test data to the object for the given path.
+func (store *S3TestDataStore) Write(path string, data []byte) error {
+	key := store.key(path)
+	_, err := store.Client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(store.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", store.url(key), err)
+	}
+	return nil
+}
+
+// Delete deletes the object for the given path, if it exists.
+func (store *S3TestDataStore) Delete(path string) error {
+	key := store.key(path)
+	_, err := store.Client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil && !isS3NotFoundError(err) {
+		return fmt.Errorf("failed to delete %s: %w", store.url(key), err)
+	}
+	return nil
+}
+
+// DeleteFolder deletes the objects for all the paths in the given folder. The objects are deleted one by one, as not
+// all the stores that speak the S3 API support deleting multiple objects at once.
+func (store *S3TestDataStore) DeleteFolder(folder string) error {
+	prefix := store.key(folder) + "/"
+
+	keys := []string{}
+	err := store.Client.ListObjectsPages(
+		&s3.ListObjectsInput{
+			Bucket: aws.String(store.Bucket),
+			Prefix: aws.String(prefix),
+		},
+		func(page *s3.ListObjectsOutput, lastPage bool) bool {
+			for _, object := range page.Contents {
+				keys = append(keys, aws.StringValue(object.Key))
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", store.url(prefix), err)
+	}
+
+	for _, key := range keys {
+		_, err := store.Client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(store.Bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil && !isS3NotFoundError(err) {
+			return fmt.Errorf("failed to delete %s: %w", store.url(key), err)
+		}
+	}
+	return nil
+}
+
+// key returns the key of the object for the given path.
+func (store *S3TestDataStore) key(testDataPath string) string {
+	segments := strings.Split(path.Clean(filepath.ToSlash(testDataPath)), "/")
+	for len(segments) > 1 && (segments[0] == "" || segments[0] == "." || segments[0] == "..") {
+		segments = segments[1:]
+	}
+	return path.Join(store.Prefix, path.Join(segments...))
+}
+
+// url returns a URL of the object with the given key, for error messages.
+func (store *S3TestDataStore) url(key string) string {
+	return fmt.Sprintf("s3://%s/%s", store.Bucket, key)
+}
+
+// isS3NotFoundError returns true if the given error is an error of the S3 API for an object or bucket that doesn't
+// exist.
+func isS3NotFoundError(err error) bool {
+	awsErr, isAwsErr := err.(awserr.Error)
+	if !isAwsErr {
+		return false
+	}
+	switch awsErr.Code() {
+	case s3.ErrCodeNoSuchKey, "NotFound":
+		return true
+	default:
+		return false
+	}
+}
+
+// firstNonEmpty returns the first of the given values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,224 @@
+package test_structure
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nholuongut/terratest/modules/k8s"
+	"github.com/nholuongut/terratest/modules/ssh"
+	"github.com/nholuongut/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is a minimal in-memory implementation of the parts of the S3 API used by S3TestDataStore, with path-style
+// addressing.
+type fakeS3 struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+type fakeS3ListBucketResult struct {
+	XMLName     xml.Name `xml:"ListBucketResult"`
+	Name        string
+	Prefix      string
+	IsTruncated bool
+	Contents    []struct{ Key string }
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, string) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "terratest")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "terratest")
+	return fake, server.URL
+}
+
+func (fake *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		result := fakeS3ListBucketResult{Name: bucket, Prefix: r.URL.Query().Get("prefix")}
+		for _, objectKey := range fake.keys() {
+			if objectBucket, name, _ := strings.Cut(objectKey, "/"); objectBucket == bucket && strings.HasPrefix(name, result.Prefix) {
+				result.Contents = append(result.Contents, struct{ Key string }{name})
+			}
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodGet:
+		data, exists := fake.objects[bucket+"/"+key]
+		if !exists {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>")
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		fake.objects[bucket+"/"+key] = data
+	case r.Method == http.MethodDelete:
+		delete(fake.objects, bucket+"/"+key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// keys returns the sorted keys of the objects, in the format "<bucket>/<key>". Must be called with the mutex held.
+func (fake *fakeS3) keys() []string {
+	keys := []string{}
+	for key := range fake.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (fake *fakeS3) objectKeys() []string {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	return fake.keys()
+}
+
+func TestS3TestDataStore(t *testing.T) {
+	fake, endpoint := newFakeS3(t)
+
+	store := NewS3TestDataStore(t, S3TestDataStoreOptions{Bucket: "test-data", Prefix: "ci-123", Region: "us-east-1", Endpoint: endpoint})
+
+	_, err := store.Read("../examples/foo/.test-data/TerraformOptions.json")
+	assert.True(t, errors.Is(err, fs.ErrNotExist), "Expected a not exist error, but got %v", err)
+
+	require.NoError(t, store.Write("../examples/foo/.test-data/TerraformOptions.json", []byte(`{"TerraformDir":"foo"}`)))
+	require.NoError(t, store.Write("/examples/foo/.test-data/SshKeyPair.json", []byte(`{}`)))
+	require.NoError(t, store.Write("examples/bar/.test-data/TerraformOptions.json", []byte(`{"TerraformDir":"bar"}`)))
+	assert.Equal(
+		t,
+		[]string{
+			"test-data/ci-123/examples/bar/.test-data/TerraformOptions.json",
+			"test-data/ci-123/examples/foo/.test-data/SshKeyPair.json",
+			"test-data/ci-123/examples/foo/.test-data/TerraformOptions.json",
+		},
+		fake.objectKeys(),
+	)
+
+	data, err := store.Read("./examples/foo/.test-data/TerraformOptions.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"TerraformDir":"foo"}`, string(data))
+
+	require.NoError(t, store.DeleteFolder("../examples/foo/.test-data"))
+	assert.Equal(t, []string{"test-data/ci-123/examples/bar/.test-data/TerraformOptions.json"}, fake.objectKeys())
+
+	require.NoError(t, store.Delete("examples/bar/.test-data/TerraformOptions.json"))
+	require.NoError(t, store.Delete("examples/bar/.test-data/TerraformOptions.json"))
+	assert.Empty(t, fake.objectKeys())
+}
+
+func TestSaveAndLoadTestDataWithS3TestDataStoreFromEnv(t *testing.T) {
+	fake, endpoint := newFakeS3(t)
+	t.Setenv(TestDataStoreEnvVar, "s3://test-data/ci-123")
+	t.Setenv(TestDataStoreEndpointEnvVar, endpoint)
+	t.Setenv(TestDataEncryptionKeyEnvVar, "")
+
+	testFolder := "../../examples/terraform-basic-example"
+
+	terraformOptions := &terraform.Options{TerraformDir: testFolder, Vars: map[string]interface{}{"example": "foo"}}
+	SaveTerraformOptions(t, testFolder, terraformOptions)
+	kubectlOptions := &k8s.KubectlOptions{ContextName: "terratest-context", Namespace: "default"}
+	SaveKubectlOptions(t, testFolder, kubectlOptions)
+	keyPair := &ssh.KeyPair{PublicKey: "public", PrivateKey: "private"}
+	SaveSshKeyPair(t, testFolder, keyPair)
+
+	assert.Equal(
+		t,
+		[]string{
+			"test-data/ci-123/examples/terraform-basic-example/.test-data/KubectlOptions.json",
+			"test-data/ci-123/examples/terraform-basic-example/.test-data/SshKeyPair.json",
+			"test-data/ci-123/examples/terraform-basic-example/.test-data/TerraformOptions.json",
+		},
+		fake.objectKeys(),
+	)
+	assert.NoFileExists(t, formatTerraformOptionsPath(testFolder))
+
+	assert.Equal(t, terraformOptions, LoadTerraformOptions(t, testFolder))
+	assert.Equal(t, kubectlOptions, LoadKubectlOptions(t, testFolder))
+	assert.Equal(t, keyPair, LoadSshKeyPair(t, testFolder))
+
+	CleanupTestDataFolder(t, testFolder)
+	assert.Empty(t, fake.objectKeys())
+	assert.False(t, IsTestDataPresent(t, formatTerraformOptionsPath(testFolder)))
+}
+
+func TestSaveAndLoadEncryptedSshKeyPair(t *testing.T) {
+	t.Setenv(TestDataStoreEnvVar, "")
+	t.Setenv(TestDataEncryptionKeyEnvVar, "correct horse battery staple")
+
+	tmpFolder := t.TempDir()
+
+	keyPair := ssh.GenerateRSAKeyPair(t, 2048)
+	SaveSshKeyPair(t, tmpFolder, keyPair)
+
+	saved, err := os.ReadFile(filepath.Join(tmpFolder, ".test-data", "SshKeyPair.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(saved), "PRIVATE KEY")
+	assert.NotContains(t, string(saved), keyPair.PublicKey)
+
+	assert.Equal(t, keyPair, LoadSshKeyPair(t, tmpFolder))
+
+	var loaded ssh.KeyPair
+	LoadEncryptedTestData(t, formatSshKeyPairPath(tmpFolder), &loaded, "correct horse battery staple")
+	assert.Equal(t, *keyPair, loaded)
+
+	_, err = decryptTestDataE(saved, "wrong passphrase")
+	assert.Error(t, err)
+}
+
+func TestSaveSshKeyPairUnencryptedWithoutEncryptionKey(t *testing.T) {
+	t.Setenv(TestDataStoreEnvVar, "")
+	t.Setenv(TestDataEncryptionKeyEnvVar, "")
+
+	tmpFolder := t.TempDir()
+
+	keyPair := &ssh.KeyPair{PublicKey: "public", PrivateKey: "private"}
+	SaveSshKeyPair(t, tmpFolder, keyPair)
+
+	saved, err := os.ReadFile(filepath.Join(tmpFolder, ".test-data", "SshKeyPair.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"PublicKey":"public","PrivateKey":"private"}`, string(saved))
+}
+
+func TestNewTestDataStoreFromURLE(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv(TestDataStoreEndpointEnvVar, "")
+
+	store, err := NewTestDataStoreFromURLE(t, "gs://test-data/ci-123/")
+	require.NoError(t, err)
+	s3Store := store.(*S3TestDataStore)
+	assert.Equal(t, "test-data", s3Store.Bucket)
+	assert.Equal(t, "ci-123", s3Store.Prefix)
+
+	store, err = NewTestDataStoreFromURLE(t, "file://")
+	require.NoError(t, err)
+	assert.Equal(t, LocalTestDataStore{}, store)
+
+	_, err = NewTestDataStoreFromURLE(t, "ftp://test-data")
+	assert.Error(t, err)
+	_, err = NewTestDataStoreFromURLE(t, "s3:///ci-123")
+	assert.Error(t, err)
+}
@@ -33,9 +33,14 @@ func RunTestStage(t testing.TestingT, stageName string, stage func()) {
 	}
 }
 
-// SkipStageEnvVarSet returns true if an environment variable is set instructing Terratest to skip a test stage. This can be an easy way
-// to tell if the tests are running in a local dev environment vs a CI server.
+// SkipStageEnvVarSet returns true if an environment variable is set instructing Terratest to skip a test stage, including
+// the TERRATEST_STAGES and TERRATEST_SKIP_STAGES environment variables (or flags) that select the stages of a Suite. This
+// can be an easy way to tell if the tests are running in a local dev environment vs a CI server.
 func SkipStageEnvVarSet() bool {
+	if len(selectedStages()) > 0 || len(skippedStages()) > 0 {
+		return true
+	}
+
 	for _, environmentVariable := range os.Environ() {
 		if strings.HasPrefix(environmentVariable, SKIP_STAGE_ENV_VAR_PREFIX) {
 			return true
@@ -154,7 +159,7 @@ func ValidateAllTerraformModules(t *go_test.T, opts *ValidationOptions) {
 		opts,
 		func(t *go_test.T, fileType ValidateFileType, tfOpts *terraform.Options) {
 			if fileType == TG {
-				tfOpts.TerraformBinary = "terragrunt"
+				tfOpts.Terragrunt = &terraform.TerragruntOptions{}
 				// First call init and terraform validate
 				terraform.InitAndValidate(t, tfOpts)
 				// Next, call terragrunt validate-inputs which will catch mis-aligned inputs provided via Terragrunt
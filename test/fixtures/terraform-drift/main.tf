@@ -0,0 +1,16 @@
+terraform {
+  required_providers {
+    local = {
+      source = "hashicorp/local"
+    }
+  }
+}
+
+resource "local_file" "example" {
+  filename = "${path.module}/example.txt"
+  content  = "managed by terraform"
+}
+
+output "filename" {
+  value = local_file.example.filename
+}
@@ -0,0 +1,35 @@
+provider "aws" {
+  region = "us-east-1"
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+  owners      = ["099720109477"]
+}
+
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  instance_type = var.instance_type
+  tags          = merge(var.tags, { Name = var.name })
+}
+
+module "local" {
+  source = "./modules/local"
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+}
+
+module "unpinned_registry" {
+  source = "terraform-aws-modules/security-group/aws"
+}
+
+module "unpinned_git" {
+  source = "git::https://github.com/example/terraform-modules.git//vpc"
+}
+
+module "pinned_git" {
+  source = "git::https://github.com/example/terraform-modules.git//vpc?ref=v1.2.0"
+}
@@ -0,0 +1,8 @@
+output "instance_id" {
+  description = "The ID of the instance."
+  value       = aws_instance.web.id
+}
+
+output "public_ip" {
+  value = aws_instance.web.public_ip
+}
@@ -0,0 +1,22 @@
+variable "name" {
+  description = "The name of the instance."
+  type        = string
+}
+
+variable "instance_type" {
+  description = "The type of the instance."
+  type        = string
+  default     = "t3.micro"
+  nullable    = false
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+
+variable "db_password" {
+  description = "The password of the database."
+  type        = string
+  sensitive   = true
+}
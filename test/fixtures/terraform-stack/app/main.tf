@@ -0,0 +1,7 @@
+variable "vpc_id" {
+  type = string
+}
+
+output "endpoint" {
+  value = "https://app.${var.vpc_id}.example.com"
+}
@@ -0,0 +1,8 @@
+variable "name" {
+  type    = string
+  default = "network"
+}
+
+output "vpc_id" {
+  value = "vpc-${var.name}"
+}
@@ -0,0 +1,12 @@
+variable "name" {
+  type = string
+}
+
+resource "null_resource" "counted" {
+  count = 2
+
+  triggers = {
+    name  = var.name
+    index = count.index
+  }
+}
@@ -0,0 +1,20 @@
+resource "null_resource" "single" {
+  triggers = {
+    name = "single"
+  }
+}
+
+resource "null_resource" "each" {
+  for_each = toset(["a", "b"])
+
+  triggers = {
+    name = each.key
+  }
+}
+
+module "child" {
+  source   = "./child"
+  for_each = toset(["x"])
+
+  name = each.key
+}
@@ -0,0 +1,25 @@
+variables {
+  name = "World"
+}
+
+run "greets_world" {
+  command = plan
+
+  assert {
+    condition     = output.greeting == "Hello, World!"
+    error_message = "greeting did not match"
+  }
+}
+
+run "greets_terratest" {
+  command = plan
+
+  variables {
+    name = "Terratest"
+  }
+
+  assert {
+    condition     = output.greeting == "Hello, Terratest!"
+    error_message = "greeting did not match"
+  }
+}
@@ -0,0 +1,32 @@
+variable "environment" {
+  type    = string
+  default = "dev"
+
+  validation {
+    condition     = contains(["dev", "stage", "prod"], var.environment)
+    error_message = "The environment must be one of dev, stage or prod."
+  }
+}
+
+variable "instance_count" {
+  type    = number
+  default = 1
+
+  validation {
+    condition     = var.instance_count > 0
+    error_message = "The instance count must be positive."
+  }
+
+  validation {
+    condition     = var.instance_count <= 10
+    error_message = "The instance count must be at most 10."
+  }
+}
+
+output "environment" {
+  value = var.environment
+}
+
+output "instance_count" {
+  value = var.instance_count
+}
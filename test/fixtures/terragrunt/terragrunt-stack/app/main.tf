@@ -0,0 +1,7 @@
+variable "vpc_id" {
+  type = string
+}
+
+output "app_vpc_id" {
+  value = var.vpc_id
+}
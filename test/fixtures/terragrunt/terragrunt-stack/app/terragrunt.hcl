@@ -0,0 +1,12 @@
+dependency "vpc" {
+  config_path = "../vpc"
+
+  mock_outputs = {
+    vpc_id = "mock"
+  }
+  mock_outputs_allowed_terraform_commands = ["validate"]
+}
+
+inputs = {
+  vpc_id = dependency.vpc.outputs.vpc_id
+}